@@ -1,48 +1,180 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	v "github.com/yakwilikk/go-yamlvalidator"
 )
 
 func main() {
-	schemaPath := flag.String("schema", "", "path to YAML/JSON schema file describing FieldSchema")
+	var schemaPaths stringList
+	flag.Var(&schemaPaths, "schema", "path to YAML/JSON schema file describing FieldSchema; repeat to merge overlays in order")
+	policyPath := flag.String("policy", "", "path to a YAML/JSON policy file with requirePaths, forbidPaths and levelOverrides")
 	filePath := flag.String("file", "", "YAML file to validate (default: stdin)")
 	strictKeys := flag.Bool("strict-keys", false, "treat unknown keys as errors when policy is inherit")
 	stopFirst := flag.Bool("stop-on-first", false, "stop after the first error")
 	strictTypes := flag.Bool("strict-types", false, "infer types only from explicit YAML tags")
 	yaml11Bools := flag.Bool("yaml11-bools", true, "recognize YAML 1.1 boolean literals (yes/no/on/off)")
+	checkFS := flag.Bool("check-fs", false, "allow validators to check the local filesystem (e.g. path existence)")
+	allowInterp := flag.Bool("allow-interpolation", false, "skip type/value checks for scalars containing ${VAR} placeholders")
+	warnAmbiguous := flag.Bool("warn-ambiguous", false, "warn about unquoted strings that YAML 1.1 reads as bool/null/number")
+	warnDefaults := flag.Bool("warn-redundant-defaults", false, "warn about fields set to their schema default")
+	warnUnknownTags := flag.Bool("warn-unknown-tags", false, "warn about scalars with local tags (e.g. !Ref) whose type cannot be checked")
+	requireNonEmpty := flag.Bool("require-non-empty", false, "report an error when the input has no YAML content")
+	minDocs := flag.Int("min-docs", 0, "minimum number of non-empty documents (0 = no limit)")
+	maxDocs := flag.Int("max-docs", 0, "maximum number of non-empty documents (0 = no limit)")
+	finalNewline := flag.Bool("require-final-newline", false, "warn when the input does not end with a newline")
+	forbidBOM := flag.Bool("forbid-bom", false, "report an error when the input starts with a byte order mark")
+	allowExperimental := flag.Bool("allow-experimental", false, "do not report beta and experimental fields")
+	strictStability := flag.Bool("strict-stability", false, "treat experimental fields as errors unless -allow-experimental is set")
+	resyncDocs := flag.Bool("resync-docs", false, "continue with the next document after a YAML syntax error")
+	bestEffort := flag.Bool("best-effort", false, "validate the children of a mapping or sequence even after its type check fails")
+	trace := flag.Bool("trace", false, "write a trace of schema matching to stderr")
 	sortOutput := flag.Bool("sort", true, "sort messages by position")
+	format := flag.String("format", "text", "output format: text or json")
+	onlyPath := flag.String("only-path", "", "report only findings at this path or below it (e.g. spec.template)")
+	offsets := flag.Bool("offsets", false, "include byte offsets of positions in json output")
+	forbidAliases := flag.Bool("forbid-aliases", false, "report YAML anchors and aliases as errors instead of resolving them")
+	maxAliases := flag.Int("max-alias-expansions", 0, "maximum number of aliases followed per document (0 = no limit)")
+	maxNodes := flag.Int("max-nodes", 0, "maximum number of nodes visited per document, counting visits through aliases (0 = no limit)")
+	duplicateKeys := flag.String("duplicate-keys", "warn", "how to report keys repeated in one mapping: ignore, warn or error")
+	dumpOnly := flag.Bool("dump-schema", false, "print the resolved schema as YAML and exit without validating")
 	flag.Parse()
 
-	if *schemaPath == "" {
-		fmt.Fprintln(os.Stderr, "schema is required: provide -schema pointing to a YAML or JSON schema file")
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q: use text or json\n", *format)
 		os.Exit(2)
 	}
 
-	schema, err := loadSchemaFromFile(*schemaPath)
+	// Stdin can only be read once, so read it up front for -schema discovery.
+	// -dump-schema with -schema does not need the input at all.
+	var stdinData []byte
+	if *filePath == "" && !(*dumpOnly && len(schemaPaths) > 0) {
+		var err error
+		if stdinData, err = io.ReadAll(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "read input: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	if len(schemaPaths) == 0 {
+		data, dir := stdinData, "."
+		if *filePath != "" {
+			var err error
+			if data, err = os.ReadFile(*filePath); err != nil {
+				fmt.Fprintf(os.Stderr, "read input: %v\n", err)
+				os.Exit(2)
+			}
+			dir = filepath.Dir(*filePath)
+		}
+		ref := documentSchemaRef(data, dir)
+		if ref == "" {
+			fmt.Fprintln(os.Stderr, "schema is required: provide -schema pointing to a YAML or JSON schema file, or a $schema key in the document")
+			os.Exit(2)
+		}
+		schemaPaths = stringList{ref}
+	}
+
+	dupPolicy, err := parseDuplicateKeyPolicy(*duplicateKeys)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	schema, err := loadSchemaFiles(schemaPaths)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "load schema: %v\n", err)
 		os.Exit(2)
 	}
+	if *dumpOnly {
+		out, err := dumpSchema(schema)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "dump schema: %v\n", err)
+			os.Exit(2)
+		}
+		os.Stdout.Write(out)
+		return
+	}
 
-	data, err := readInput(*filePath)
+	var pol *policy
+	var validatorOpts []v.ValidatorOption
+	if *policyPath != "" {
+		pol, err = loadPolicyFromFile(*policyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "load policy: %v\n", err)
+			os.Exit(2)
+		}
+		validatorOpts = pol.validatorOptions()
+	}
+
+	opts := v.ValidationContext{
+		StrictKeys:            *strictKeys,
+		StopOnFirst:           *stopFirst,
+		StrictTypes:           *strictTypes,
+		YAML11Booleans:        *yaml11Bools,
+		CheckFilesystem:       *checkFS,
+		AllowInterpolation:    *allowInterp,
+		WarnAmbiguousUnquoted: *warnAmbiguous,
+		WarnRedundantDefaults: *warnDefaults,
+		WarnUnknownTags:       *warnUnknownTags,
+		BestEffortMultiDoc:    *resyncDocs,
+		BestEffort:            *bestEffort,
+		DuplicateKeyPolicy:    dupPolicy,
+		ForbidAliases:         *forbidAliases,
+		MaxAliasExpansions:    *maxAliases,
+		MaxNodes:              *maxNodes,
+		RequireNonEmpty:       *requireNonEmpty,
+		MinDocuments:          *minDocs,
+		MaxDocuments:          *maxDocs,
+		RequireFinalNewline:   *finalNewline,
+		ForbidBOM:             *forbidBOM,
+		AllowExperimental:     *allowExperimental,
+		StrictStability:       *strictStability,
+	}
+	if *trace {
+		opts.Trace = os.Stderr
+	}
+
+	validator := v.NewValidator(schema, validatorOpts...)
+	var result *v.ValidationResult
+	if *filePath != "" {
+		result, err = validator.ValidateFile(*filePath, opts)
+	} else {
+		result = validator.ValidateWithOptions(stdinData, opts)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "read input: %v\n", err)
 		os.Exit(2)
 	}
+	if pol != nil {
+		pol.applyLevelOverrides(result)
+	}
+	if *onlyPath != "" {
+		result = result.FilterByPathPrefix(*onlyPath)
+	}
 
-	validator := v.NewValidator(schema)
-	result := validator.ValidateWithOptions(data, v.ValidationContext{
-		StrictKeys:     *strictKeys,
-		StopOnFirst:    *stopFirst,
-		StrictTypes:    *strictTypes,
-		YAML11Booleans: *yaml11Bools,
-	})
+	if *format == "json" {
+		if *sortOutput {
+			result.SortByPosition()
+		}
+		if *offsets {
+			result.FillOffsets()
+		}
+		if err := writeJSON(os.Stdout, result.Collector.All()); err != nil {
+			fmt.Fprintf(os.Stderr, "write output: %v\n", err)
+			os.Exit(2)
+		}
+		if result.HasErrors() {
+			os.Exit(1)
+		}
+		return
+	}
 
 	if len(result.Collector.All()) == 0 {
 		fmt.Println("valid")
@@ -55,9 +187,34 @@ func main() {
 	}
 }
 
-func readInput(path string) ([]byte, error) {
-	if path == "" {
-		return io.ReadAll(os.Stdin)
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func writeJSON(w io.Writer, errs []v.ValidationError) error {
+	if errs == nil {
+		errs = []v.ValidationError{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(errs)
+}
+
+func parseDuplicateKeyPolicy(p string) (v.DuplicateKeyPolicy, error) {
+	switch p {
+	case "", "warn":
+		return v.DuplicateKeyWarn, nil
+	case "error":
+		return v.DuplicateKeyError, nil
+	case "ignore":
+		return v.DuplicateKeyIgnore, nil
+	default:
+		return v.DuplicateKeyWarn, fmt.Errorf("unknown duplicate-keys policy %q: use ignore, warn or error", p)
 	}
-	return os.ReadFile(path)
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// policySpec is an org-wide rule set applied on top of a schema (-policy).
+type policySpec struct {
+	RequirePaths   []string          `yaml:"requirePaths" json:"requirePaths"`
+	ForbidPaths    []string          `yaml:"forbidPaths" json:"forbidPaths"`
+	LevelOverrides map[string]string `yaml:"levelOverrides" json:"levelOverrides"` // path -> error|warning
+}
+
+type levelOverride struct {
+	path  string
+	re    *regexp.Regexp
+	level v.ErrorLevel
+}
+
+type policy struct {
+	spec      policySpec
+	overrides []levelOverride // most specific (longest) path first
+}
+
+func loadPolicyFromFile(path string) (*policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	var spec policySpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("unmarshal policy: %w", err)
+	}
+
+	p := &policy{spec: spec}
+	for path, level := range spec.LevelOverrides {
+		var lvl v.ErrorLevel
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("levelOverrides %q: %w", path, err)
+		}
+		p.overrides = append(p.overrides, levelOverride{path: path, re: pathPattern(path), level: lvl})
+	}
+	sort.Slice(p.overrides, func(i, j int) bool {
+		if len(p.overrides[i].path) != len(p.overrides[j].path) {
+			return len(p.overrides[i].path) > len(p.overrides[j].path)
+		}
+		return p.overrides[i].path < p.overrides[j].path
+	})
+	if err := v.NewValidator(nil, p.validatorOptions()...).Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// validatorOptions returns the path assertions of the policy.
+func (p *policy) validatorOptions() []v.ValidatorOption {
+	return []v.ValidatorOption{
+		v.WithRequirePaths(p.spec.RequirePaths...),
+		v.WithForbidPaths(p.spec.ForbidPaths...),
+	}
+}
+
+// applyLevelOverrides changes the level of findings at or below an overridden
+// path. The doc[N] prefix of later documents is ignored when matching.
+func (p *policy) applyLevelOverrides(result *v.ValidationResult) {
+	if len(p.overrides) == 0 {
+		return
+	}
+	collector := v.NewErrorCollector()
+	for _, err := range result.Collector.All() {
+		path := docPrefixRe.ReplaceAllString(err.Path, "")
+		for _, o := range p.overrides {
+			if o.re.MatchString(path) {
+				err.Level = o.level
+				break
+			}
+		}
+		collector.Add(err)
+	}
+	result.Collector = collector
+}
+
+var docPrefixRe = regexp.MustCompile(`^doc\[\d+\]\.?`)
+
+// quotedKeyRe matches a quoted key in a path, e.g. ["my.key"].
+var quotedKeyRe = regexp.MustCompile(`\["(?:[^"\\]|\\.)*"\]`)
+
+// pathPattern matches an error path equal to or below a selector path, where
+// "*" stands for any key and "[*]" for any index. Quoted keys (["my.key"])
+// are matched literally.
+func pathPattern(selector string) *regexp.Regexp {
+	var sb strings.Builder
+	rest := selector
+	for rest != "" {
+		loc := quotedKeyRe.FindStringIndex(rest)
+		if loc == nil {
+			loc = []int{len(rest), len(rest)}
+		}
+		part := regexp.QuoteMeta(rest[:loc[0]])
+		part = strings.ReplaceAll(part, `\[\*\]`, `\[\d+\]`)
+		part = strings.ReplaceAll(part, `\.\*`, `(?:\.[^.\["]+|`+quotedKeyRe.String()+`)`)
+		part = strings.ReplaceAll(part, `\*`, `(?:[^.\["]+|`+quotedKeyRe.String()+`)`)
+		sb.WriteString(part)
+		sb.WriteString(regexp.QuoteMeta(rest[loc[0]:loc[1]]))
+		rest = rest[loc[1]:]
+	}
+	return regexp.MustCompile(`^` + sb.String() + `($|[.\[])`)
+}
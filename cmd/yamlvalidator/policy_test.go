@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+)
+
+func TestLoadPolicyFromFile(t *testing.T) {
+	tmp := t.TempDir()
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	err := os.WriteFile(policyPath, []byte(`
+requirePaths:
+  - metadata.owner
+forbidPaths:
+  - spec.hostNetwork
+levelOverrides:
+  metadata.owner: warning
+  spec.containers[*].debug: warning
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+
+	pol, err := loadPolicyFromFile(policyPath)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+
+	schema := &v.FieldSchema{
+		Type:             v.TypeMap,
+		UnknownKeyPolicy: v.UnknownKeyIgnore,
+		AllowedKeys: map[string]*v.FieldSchema{
+			"spec": {
+				Type:             v.TypeMap,
+				UnknownKeyPolicy: v.UnknownKeyIgnore,
+				AllowedKeys: map[string]*v.FieldSchema{
+					"containers": {Type: v.TypeSequence, ItemSchema: &v.FieldSchema{
+						Type:        v.TypeMap,
+						AllowedKeys: map[string]*v.FieldSchema{"debug": {Type: v.TypeBool}},
+					}},
+				},
+			},
+		},
+	}
+	doc := `
+metadata: {name: web}
+spec:
+  hostNetwork: true
+  containers:
+    - debug: "yes"
+`
+	result := v.NewValidator(schema, pol.validatorOptions()...).ValidateString(doc)
+	pol.applyLevelOverrides(result)
+
+	errs := result.Collector.Errors()
+	if len(errs) != 1 || errs[0].Path != "spec.hostNetwork" || errs[0].Message != "path is forbidden" {
+		t.Fatalf("expected forbidden path error, got %v", errs)
+	}
+	warnings := result.Collector.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("expected overridden warnings for metadata.owner and debug, got %v", warnings)
+	}
+	for _, w := range warnings {
+		if w.Path != "metadata.owner" && w.Path != "spec.containers[0].debug" {
+			t.Errorf("unexpected warning: %v", w)
+		}
+	}
+}
+
+func TestLoadPolicyFromFile_InvalidLevel(t *testing.T) {
+	tmp := t.TempDir()
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("levelOverrides:\n  a.b: fatal\n"), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	if _, err := loadPolicyFromFile(policyPath); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
+
+func TestLoadPolicyFromFile_InvalidSelector(t *testing.T) {
+	tmp := t.TempDir()
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	for _, policy := range []string{"requirePaths:\n  - spec..name\n", "forbidPaths:\n  - spec..name\n"} {
+		if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+			t.Fatalf("write policy: %v", err)
+		}
+		if _, err := loadPolicyFromFile(policyPath); err == nil || !strings.Contains(err.Error(), "invalid selector") {
+			t.Fatalf("%q: expected invalid selector error, got %v", policy, err)
+		}
+	}
+}
+
+func TestPathPattern(t *testing.T) {
+	tests := []struct {
+		selector string
+		path     string
+		want     bool
+	}{
+		{"spec", "spec", true},
+		{"spec", "spec.replicas", true},
+		{"spec", "specs", false},
+		{"spec.containers[*]", "spec.containers[3].image", true},
+		{"spec.containers[*]", "spec.containers", false},
+		{"*.name", "metadata.name", true},
+		{"*.name", "metadata.labels.name", false},
+		{`metadata["my.key"]`, `metadata["my.key"]`, true},
+		{`metadata["my.key"]`, `metadata["my.key"].x`, true},
+		{`metadata["my.key"]`, "metadata.my.key", false},
+		{"metadata.*", `metadata["my.key"]`, true},
+		{`*["a*"]`, `x["a*"]`, true},
+		{`*["a*"]`, `x["ab"]`, false},
+	}
+	for _, tt := range tests {
+		if got := pathPattern(tt.selector).MatchString(tt.path); got != tt.want {
+			t.Errorf("pathPattern(%q).MatchString(%q) = %v, want %v", tt.selector, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+)
+
+// Custom validators registered by name, consulted before the built-in ones
+// when a schema file is loaded. Builds that embed the CLI register theirs from
+// an init function in an extra file of this package.
+var (
+	registryMu              sync.RWMutex
+	valueValidatorFactories = map[string]func(valueValidatorSpec) (v.ValueValidator, error){}
+	keyValidatorFactories   = map[string]func(keyValidatorSpec) (v.KeyValidator, error){}
+)
+
+// RegisterValueValidator makes a value validator available to schema files
+// under name, matched case-insensitively like the built-in names. A registered
+// name takes precedence over a built-in one. It panics if name is empty,
+// factory is nil, or name is already registered.
+func RegisterValueValidator(name string, factory func(valueValidatorSpec) (v.ValueValidator, error)) {
+	if factory == nil {
+		panic(fmt.Sprintf("RegisterValueValidator: nil factory for %q", name))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	key := registryKey("RegisterValueValidator", name)
+	if _, dup := valueValidatorFactories[key]; dup {
+		panic(fmt.Sprintf("RegisterValueValidator: %q registered twice", name))
+	}
+	valueValidatorFactories[key] = factory
+}
+
+// RegisterKeyValidator is RegisterValueValidator for key validators.
+func RegisterKeyValidator(name string, factory func(keyValidatorSpec) (v.KeyValidator, error)) {
+	if factory == nil {
+		panic(fmt.Sprintf("RegisterKeyValidator: nil factory for %q", name))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	key := registryKey("RegisterKeyValidator", name)
+	if _, dup := keyValidatorFactories[key]; dup {
+		panic(fmt.Sprintf("RegisterKeyValidator: %q registered twice", name))
+	}
+	keyValidatorFactories[key] = factory
+}
+
+func registryKey(fn, name string) string {
+	key := strings.ToLower(name)
+	if key == "" {
+		panic(fn + ": empty name")
+	}
+	return key
+}
+
+func registeredValueValidator(name string) func(valueValidatorSpec) (v.ValueValidator, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return valueValidatorFactories[strings.ToLower(name)]
+}
+
+func registeredKeyValidator(name string) func(keyValidatorSpec) (v.KeyValidator, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return keyValidatorFactories[strings.ToLower(name)]
+}
+
+// OptionInt returns the integer option name from the Options of a spec passed
+// to a registered factory. Any numeric form the schema decoder produces is
+// accepted as long as it is whole, so 3 and 3.0 (common in JSON schemas) both
+// give 3. ok is false if the option is missing or not a whole number within
+// ±2^53.
+func OptionInt(options map[string]interface{}, name string) (int, bool) {
+	f, ok := OptionFloat(options, name)
+	if !ok || f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// OptionFloat is OptionInt for numbers that may have a fractional part.
+func OptionFloat(options map[string]interface{}, name string) (float64, bool) {
+	switch n := options[name].(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// evenValidator rejects integers ending in 1, enough for the test.
+type evenValidator struct{ message string }
+
+func (vld evenValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind == yaml.ScalarNode && strings.HasSuffix(node.Value, "1") {
+		ctx.AddError(v.ValidationError{Level: v.LevelError, Path: path, Line: node.Line, Column: node.Column, Message: vld.message})
+	}
+}
+
+// upperKeyValidator rejects keys containing lowercase letters.
+type upperKeyValidator struct{}
+
+func (upperKeyValidator) ValidateKey(key string, keyNode *yaml.Node, path string, ctx *v.ValidationContext) {
+	if key != strings.ToUpper(key) {
+		ctx.AddError(v.ValidationError{Level: v.LevelError, Path: path, Line: keyNode.Line, Column: keyNode.Column, Message: "key must be upper case"})
+	}
+}
+
+func TestRegisterValidators(t *testing.T) {
+	RegisterValueValidator("Even", func(spec valueValidatorSpec) (v.ValueValidator, error) {
+		return evenValidator{message: spec.Message}, nil
+	})
+	RegisterKeyValidator("upper", func(keyValidatorSpec) (v.KeyValidator, error) {
+		return upperKeyValidator{}, nil
+	})
+	t.Cleanup(func() {
+		delete(valueValidatorFactories, "even")
+		delete(keyValidatorFactories, "upper")
+	})
+
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	if err := os.WriteFile(schemaPath, []byte(`
+type: map
+keyValidators:
+  - name: upper
+additionalProperties:
+  type: int
+  validators:
+    - name: even
+      message: must be even
+`), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+
+	result := v.NewValidator(schema).ValidateBytes([]byte("A: 2\nB: 11\nc: 4\n"))
+	var messages []string
+	for _, e := range result.Collector.Errors() {
+		messages = append(messages, e.Path+": "+e.Message)
+	}
+	want := []string{"B: must be even", "c: key must be upper case"}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("errors = %q, want %q", messages, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a name twice should panic")
+		}
+	}()
+	RegisterValueValidator("EVEN", func(valueValidatorSpec) (v.ValueValidator, error) { return nil, nil })
+}
+
+// multipleOfValidator rejects integers that are not a multiple of divisor.
+type multipleOfValidator struct{ divisor int }
+
+func (vld multipleOfValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	var n int
+	if node.Decode(&n) == nil && n%vld.divisor != 0 {
+		ctx.AddError(v.ValidationError{Level: v.LevelError, Path: path, Line: node.Line, Column: node.Column, Message: "not a multiple"})
+	}
+}
+
+func TestRegisterValidators_Options(t *testing.T) {
+	RegisterValueValidator("multipleOf", func(spec valueValidatorSpec) (v.ValueValidator, error) {
+		divisor, ok := OptionInt(spec.Options, "divisor")
+		if !ok || divisor == 0 {
+			return nil, fmt.Errorf("multipleOf validator: options.divisor must be a non-zero integer")
+		}
+		return multipleOfValidator{divisor: divisor}, nil
+	})
+	t.Cleanup(func() { delete(valueValidatorFactories, "multipleof") })
+
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	if err := os.WriteFile(schemaPath, []byte(`
+type: map
+allowedKeys:
+  port:
+    type: int
+    validators:
+      - name: multipleOf
+        options:
+          divisor: 3
+`), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	validator := v.NewValidator(schema)
+	if result := validator.ValidateBytes([]byte("port: 9\n")); result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Collector.Errors())
+	}
+	if result := validator.ValidateBytes([]byte("port: 10\n")); !result.HasErrors() {
+		t.Fatal("expected an error for 10")
+	}
+
+	if err := os.WriteFile(schemaPath, []byte(`
+type: int
+validators:
+  - name: multipleOf
+`), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	if _, err := loadSchemaFromFile(schemaPath); err == nil || !strings.Contains(err.Error(), "options.divisor") {
+		t.Fatalf("expected options error, got %v", err)
+	}
+	// JSON schemas often write whole numbers with a fraction.
+	jsonPath := filepath.Join(tmp, "schema.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"type": "int", "validators": [{"name": "multipleOf", "options": {"divisor": 3.0}}]}`), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	schema, err = loadSchemaFromFile(jsonPath)
+	if err != nil {
+		t.Fatalf("load JSON schema: %v", err)
+	}
+	if result := v.NewValidator(schema).ValidateBytes([]byte("10\n")); !result.HasErrors() {
+		t.Fatal("expected an error for 10 with the JSON schema")
+	}
+}
+
+func TestOptionInt(t *testing.T) {
+	options := map[string]interface{}{"int": 3, "float": 3.0, "fraction": 2.5, "string": "3", "big": 1e300}
+	tests := []struct {
+		name   string
+		want   int
+		wantOK bool
+	}{
+		{"int", 3, true},
+		{"float", 3, true},
+		{"fraction", 0, false},
+		{"string", 0, false},
+		{"big", 0, false},
+		{"missing", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := OptionInt(options, tt.name); got != tt.want || ok != tt.wantOK {
+			t.Errorf("OptionInt(%q) = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if f, ok := OptionFloat(options, "fraction"); !ok || f != 2.5 {
+		t.Errorf("OptionFloat(fraction) = %v, %v", f, ok)
+	}
+}
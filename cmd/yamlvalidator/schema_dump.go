@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	keyv "github.com/yakwilikk/go-yamlvalidator/pkg/keyvalidator"
+	mapv "github.com/yakwilikk/go-yamlvalidator/pkg/mapvalidator"
+	seqv "github.com/yakwilikk/go-yamlvalidator/pkg/seqvalidator"
+	valv "github.com/yakwilikk/go-yamlvalidator/pkg/valuevalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// dumpSchema serializes a resolved schema, e.g. the result of extends and
+// overlays, back to the schema file format, so that loading the output gives
+// an equivalent schema. File references are already resolved: enum lists read
+// from allowedFile are written inline. Validators the file format cannot
+// express (custom or registered ones) and recursive schemas are reported as
+// errors.
+func dumpSchema(schema *v.FieldSchema) ([]byte, error) {
+	d := schemaDumper{active: make(map[*v.FieldSchema]bool)}
+	sn, err := d.node(schema)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(sn); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type schemaDumper struct {
+	active map[*v.FieldSchema]bool // schemas being dumped, to detect recursion
+}
+
+func (d *schemaDumper) node(fs *v.FieldSchema) (*schemaNode, error) {
+	if d.active[fs] {
+		return nil, errors.New("recursive schema cannot be dumped")
+	}
+	d.active[fs] = true
+	defer delete(d.active, fs)
+
+	sn := &schemaNode{
+		Required:          fs.Required,
+		Nullable:          fs.Nullable,
+		NonEmpty:          fs.NonEmpty,
+		MaxNesting:        fs.MaxNesting,
+		Deprecated:        fs.Deprecated,
+		Aliases:           fs.Aliases,
+		Default:           fs.Default,
+		CaseInsensitive:   fs.CaseInsensitiveKeys,
+		NumericKeys:       fs.NumericKeys,
+		SkipValueOnKeyErr: fs.SkipValueOnKeyError,
+		MinItems:          fs.MinItems,
+		MaxItems:          fs.MaxItems,
+		ItemsNonNull:      fs.ItemsNonNull,
+		UniqueFields:      fs.UniqueFields,
+		AnyOf:             fs.AnyOf,
+		ExactlyOneOf:      fs.ExactlyOneOf,
+		ExactlyOneGroupOf: fs.ExactlyOneGroupOf,
+		MutuallyExclusive: fs.MutuallyExclusive,
+		AllOrNone:         fs.AllOrNone,
+	}
+	if fs.Type != v.TypeAny {
+		sn.Type = fs.Type.String()
+	}
+	if fs.Stability != v.StabilityStable {
+		sn.Stability = fs.Stability.String()
+	}
+	switch fs.UnknownKeyPolicy {
+	case v.UnknownKeyWarn:
+		sn.UnknownKeyPolicy = "warn"
+	case v.UnknownKeyError:
+		sn.UnknownKeyPolicy = "error"
+	case v.UnknownKeyIgnore:
+		sn.UnknownKeyPolicy = "ignore"
+	}
+	if fs.RequireStyle != v.StyleAny {
+		sn.RequireStyle = fs.RequireStyle.String()
+	}
+	if fs.RequireStyleLevel != nil {
+		text, _ := fs.RequireStyleLevel.MarshalText()
+		sn.RequireStyleLevel = string(text)
+	}
+	if fs.AdditionalPropertiesLevel != nil {
+		text, _ := fs.AdditionalPropertiesLevel.MarshalText()
+		sn.AdditionalLevel = string(text)
+	}
+	if di := fs.DeprecatedInfo; di != nil {
+		sn.Deprecation = &deprecationSpec{
+			Message:     di.Message,
+			Since:       di.Since,
+			RemoveIn:    di.RemoveIn,
+			Replacement: di.Replacement,
+		}
+	}
+
+	var err error
+	if fs.AllowedKeys != nil {
+		sn.AllowedKeys = make(map[string]*schemaNode, len(fs.AllowedKeys))
+		for _, key := range sortedKeys(fs.AllowedKeys) {
+			if sn.AllowedKeys[key], err = d.node(fs.AllowedKeys[key]); err != nil {
+				return nil, fmt.Errorf("allowedKeys[%s]: %w", key, err)
+			}
+		}
+	}
+	if fs.AdditionalProperties != nil {
+		if sn.AdditionalProps, err = d.node(fs.AdditionalProperties); err != nil {
+			return nil, fmt.Errorf("additionalProperties: %w", err)
+		}
+	}
+	if len(fs.AdditionalPropertiesByType) > 0 {
+		sn.AdditionalByType = make(map[string]*schemaNode, len(fs.AdditionalPropertiesByType))
+		for t, child := range fs.AdditionalPropertiesByType {
+			if sn.AdditionalByType[t.String()], err = d.node(child); err != nil {
+				return nil, fmt.Errorf("additionalPropertiesByType[%s]: %w", t, err)
+			}
+		}
+	}
+	if fs.ItemSchema != nil {
+		if sn.ItemSchema, err = d.node(fs.ItemSchema); err != nil {
+			return nil, fmt.Errorf("itemSchema: %w", err)
+		}
+	}
+
+	for _, val := range fs.Validators {
+		spec, err := dumpValueValidator(val)
+		if err != nil {
+			return nil, err
+		}
+		sn.Validators = append(sn.Validators, spec)
+	}
+	for _, val := range fs.KeyValidators {
+		spec, err := dumpKeyValidator(val)
+		if err != nil {
+			return nil, err
+		}
+		sn.KeyValidators = append(sn.KeyValidators, spec)
+	}
+	for _, val := range fs.MapValidators {
+		spec, err := dumpMapValidator(val)
+		if err != nil {
+			return nil, err
+		}
+		sn.MapValidators = append(sn.MapValidators, spec)
+	}
+	for _, val := range fs.SeqValidators {
+		spec, err := dumpSeqValidator(val)
+		if err != nil {
+			return nil, err
+		}
+		sn.SeqValidators = append(sn.SeqValidators, spec)
+	}
+	for _, c := range fs.AtLeastNOf {
+		sn.AtLeastNOf = append(sn.AtLeastNOf, keyCountSpec{Keys: c.Keys, N: c.N})
+	}
+	for _, c := range fs.AtMostNOf {
+		sn.AtMostNOf = append(sn.AtMostNOf, keyCountSpec{Keys: c.Keys, N: c.N})
+	}
+	for _, c := range fs.Conditions {
+		spec := conditionalSpec{
+			ConditionField: c.ConditionField,
+			ThenRequired:   c.ThenRequired,
+			ThenForbidden:  c.ThenForbidden,
+		}
+		if c.ConditionPattern != nil {
+			spec.ConditionPattern = c.ConditionPattern.String()
+		} else {
+			spec.ConditionValue = c.ConditionValue
+		}
+		if c.ThenSchema != nil {
+			then, err := d.node(c.ThenSchema)
+			if err != nil {
+				return nil, err
+			}
+			spec.ThenSchema = then
+		}
+		sn.Conditions = append(sn.Conditions, spec)
+	}
+	return sn, nil
+}
+
+func sortedKeys(m map[string]*v.FieldSchema) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dumpValueValidator is the inverse of buildValueValidator.
+func dumpValueValidator(val v.ValueValidator) (valueValidatorSpec, error) {
+	switch val := val.(type) {
+	case valv.EnumValidator:
+		return valueValidatorSpec{Name: "enum", Allowed: val.Allowed, Descriptions: val.Descriptions, Message: val.Message}, nil
+	case valv.NotInValidator:
+		return valueValidatorSpec{Name: "notin", Denied: val.Denied, Message: val.Message}, nil
+	case valv.EnumFromPathValidator:
+		return valueValidatorSpec{Name: "enumfrompath", Path: val.Path, Message: val.Message}, nil
+	case valv.RegexValidator:
+		return valueValidatorSpec{Name: "regex", Pattern: val.Pattern.String(), Message: val.Message}, nil
+	case valv.RangeValidator:
+		return valueValidatorSpec{Name: "range", Min: val.Min, Max: val.Max, Message: val.Message}, nil
+	case valv.IntRangeValidator:
+		return valueValidatorSpec{Name: "intrange", MinInt: val.Min, MaxInt: val.Max, Message: val.Message}, nil
+	case valv.IntWidthValidator:
+		return valueValidatorSpec{Name: "intwidth", Bits: val.Bits, Signed: val.Signed, Message: val.Message}, nil
+	case valv.PrecisionValidator:
+		return valueValidatorSpec{Name: "precision", MaxDecimals: val.MaxDecimals, Message: val.Message}, nil
+	case valv.PercentValidator:
+		return valueValidatorSpec{Name: "percent", AllowSuffix: val.AllowSuffix, Max: val.Max}, nil
+	case valv.CharsetValidator:
+		return valueValidatorSpec{Name: "charset", Chars: val.Allowed, DisallowChars: val.Disallowed, Message: val.Message}, nil
+	case valv.AnyOfValidator:
+		spec := valueValidatorSpec{Name: "anyof", Message: val.Message}
+		for i, alt := range val.Validators {
+			altSpec, err := dumpValueValidator(alt)
+			if err != nil {
+				return valueValidatorSpec{}, fmt.Errorf("anyof validator: alternative %d: %w", i, err)
+			}
+			spec.Validators = append(spec.Validators, altSpec)
+		}
+		return spec, nil
+	case valv.NonEmptyValidator:
+		return valueValidatorSpec{Name: "nonempty"}, nil
+	case valv.LengthValidator:
+		return valueValidatorSpec{Name: "length", MinLength: val.Min, MaxLength: val.Max, Message: val.Message}, nil
+	case valv.DisplayWidthValidator:
+		return valueValidatorSpec{Name: "displaywidth", MinLength: val.Min, MaxLength: val.Max}, nil
+	case valv.URLValidator:
+		return valueValidatorSpec{Name: "url", RequireScheme: val.RequireScheme, AllowedSchemes: val.AllowedSchemes}, nil
+	case valv.OneOfTypeValidator:
+		spec := valueValidatorSpec{Name: "oneoftype"}
+		for _, t := range val.Types {
+			spec.Types = append(spec.Types, t.String())
+		}
+		return spec, nil
+	case valv.LanguageTagValidator:
+		return valueValidatorSpec{Name: "languagetag", Message: val.Message}, nil
+	case valv.ASCIIValidator:
+		return valueValidatorSpec{Name: "ascii", AllowExtended: val.AllowExtended, Message: val.Message}, nil
+	case valv.SingleLineValidator:
+		return valueValidatorSpec{Name: "singleline", Message: val.Message}, nil
+	case valv.SemVerRangeValidator:
+		return valueValidatorSpec{Name: "semverrange", Message: val.Message}, nil
+	case valv.GlobValidator:
+		return valueValidatorSpec{Name: "glob", Message: val.Message}, nil
+	case valv.JSONPointerValidator:
+		return valueValidatorSpec{Name: "jsonpointer", Message: val.Message}, nil
+	case valv.GoTemplateValidator:
+		return valueValidatorSpec{Name: "gotemplate", Funcs: val.Funcs, RequireActions: val.RequireActions, ForbidActions: val.ForbidActions, Message: val.Message}, nil
+	case valv.HexColorValidator:
+		return valueValidatorSpec{Name: "hexcolor", AllowAlpha: val.AllowAlpha, Message: val.Message}, nil
+	case valv.FilePathValidator:
+		return valueValidatorSpec{
+			Name:          "filepath",
+			MustExist:     val.MustExist,
+			Mode:          string(val.Mode),
+			AllowAbsolute: val.AllowAbsolute,
+			AllowRelative: val.AllowRelative,
+			BaseDir:       val.BaseDir,
+			Message:       val.Message,
+		}, nil
+	case valv.DirectoryValidator:
+		return valueValidatorSpec{Name: "directory", MustExist: val.MustExist}, nil
+	default:
+		return valueValidatorSpec{}, fmt.Errorf("cannot dump validator of type %T", val)
+	}
+}
+
+// dumpKeyValidator is the inverse of buildKeyValidator.
+func dumpKeyValidator(val v.KeyValidator) (keyValidatorSpec, error) {
+	switch val := val.(type) {
+	case keyv.RegexKeyValidator:
+		return keyValidatorSpec{Name: "regex", Pattern: val.Pattern.String(), Message: val.Message}, nil
+	case keyv.ForbiddenKeyValidator:
+		spec := keyValidatorSpec{Name: "forbidden", Forbidden: val.Forbidden, Message: val.Message}
+		for _, re := range val.Patterns {
+			spec.Patterns = append(spec.Patterns, re.String())
+		}
+		return spec, nil
+	case keyv.LengthKeyValidator:
+		spec := keyValidatorSpec{Name: "length", MinLength: val.Min, MaxLength: val.Max}
+		if val.Unit == keyv.Bytes {
+			spec.Unit = "bytes"
+		}
+		return spec, nil
+	case keyv.EnumKeyValidator:
+		return keyValidatorSpec{Name: "enum", Allowed: val.Allowed, CaseInsensitive: val.CaseInsensitive, Message: val.Message}, nil
+	default:
+		return keyValidatorSpec{}, fmt.Errorf("cannot dump key validator of type %T", val)
+	}
+}
+
+// dumpMapValidator is the inverse of buildMapValidator.
+func dumpMapValidator(val v.MapValidator) (mapValidatorSpec, error) {
+	switch val := val.(type) {
+	case mapv.RequiredKeysValidator:
+		return mapValidatorSpec{Name: "requiredkeys", Keys: val.Keys, Message: val.Message}, nil
+	case mapv.DependentRequiredValidator:
+		return mapValidatorSpec{Name: "dependentrequired", Dependencies: val.Dependencies}, nil
+	case mapv.SiblingConditionValidator:
+		spec, err := dumpValueValidator(val.Validator)
+		if err != nil {
+			return mapValidatorSpec{}, fmt.Errorf("siblingcondition validator: %w", err)
+		}
+		return mapValidatorSpec{Name: "siblingcondition", Field: val.Field, Value: val.Value, Target: val.Target, Validator: &spec}, nil
+	case mapv.FieldComparisonValidator:
+		return mapValidatorSpec{Name: "fieldcomparison", Left: val.Left, Op: string(val.Op), Right: val.Right}, nil
+	case mapv.FieldsDifferValidator:
+		return mapValidatorSpec{Name: "fieldsdiffer", Left: val.Left, Right: val.Right, Message: val.Message}, nil
+	case seqv.SumValidator:
+		return mapValidatorSpec{Name: "sum", Field: val.Field, Op: string(val.Op), Total: val.Total, Tolerance: val.Tolerance}, nil
+	case mapv.AlphabeticalKeysValidator:
+		return mapValidatorSpec{Name: "alphabeticalkeys", CaseInsensitive: val.CaseInsensitive}, nil
+	case mapv.RequireWhenValueValidator:
+		return mapValidatorSpec{Name: "requirewhenvalue", Field: val.Field, Value: val.Value, Require: val.Require, Message: val.Message}, nil
+	default:
+		return mapValidatorSpec{}, fmt.Errorf("cannot dump map validator of type %T", val)
+	}
+}
+
+// dumpSeqValidator is the inverse of buildSeqValidator.
+func dumpSeqValidator(val v.SeqValidator) (seqValidatorSpec, error) {
+	switch val := val.(type) {
+	case seqv.MonotonicValidator:
+		return seqValidatorSpec{Name: "monotonic", Decreasing: val.Decreasing, Strict: val.Strict}, nil
+	case seqv.SortedValidator:
+		return seqValidatorSpec{Name: "sorted", Descending: val.Descending, Numeric: val.Numeric}, nil
+	case seqv.SumValidator:
+		return seqValidatorSpec{Name: "sum", Field: val.Field, Op: string(val.Op), Total: val.Total, Tolerance: val.Tolerance}, nil
+	default:
+		return seqValidatorSpec{}, fmt.Errorf("cannot dump sequence validator of type %T", val)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+)
+
+func TestDumpSchema_RoundTrip(t *testing.T) {
+	tmp := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmp, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+	write("envs.txt", "dev\nprod\n")
+	write("base.yaml", `
+type: map
+unknownKeyPolicy: error
+allowedKeys:
+  env:
+    type: string
+    required: true
+    validators:
+      - name: enum
+        allowedFile: envs.txt
+`)
+	schemaPath := write("schema.yaml", `
+extends: base.yaml
+mapValidators:
+  - name: fieldcomparison
+    left: min
+    op: "<="
+    right: max
+atLeastNOf:
+  - keys: [min, max, replicas]
+    count: 2
+conditions:
+  - conditionField: env
+    conditionValue: prod
+    thenRequired: [replicas]
+  - conditionField: name
+    conditionPattern: '^[A-Z]'
+    thenSchema:
+      unknownKeyPolicy: ignore
+      allowedKeys:
+        max: {type: int, validators: [{name: intrange, maxInt: 1}]}
+allowedKeys:
+  min: {type: int}
+  max: {type: int}
+  replicas:
+    type: int
+    default: 1
+    validators:
+      - name: intrange
+        minInt: 1
+        maxInt: 9007199254740993
+  name:
+    type: string
+    stability: beta
+    keyValidators:
+      - name: length
+        maxLength: 10
+        unit: bytes
+    validators:
+      - name: anyof
+        validators:
+          - name: regex
+            pattern: '^[a-z]+$'
+          - name: length
+            maxLength: 3
+  ports:
+    type: sequence
+    minItems: 1
+    itemSchema: {type: int}
+    seqValidators:
+      - name: sorted
+        numeric: true
+`)
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	dump, err := dumpSchema(schema)
+	if err != nil {
+		t.Fatalf("dump schema: %v", err)
+	}
+	for _, want := range []string{"allowed:", "- dev", "9007199254740993", "unit: bytes", "stability: beta", "count: 2", "thenSchema:", "conditionPattern: ^[A-Z]"} {
+		if !strings.Contains(string(dump), want) {
+			t.Errorf("dump lacks %q:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(string(dump), "extends") || strings.Contains(string(dump), "allowedFile") {
+		t.Errorf("dump should be self-contained:\n%s", dump)
+	}
+
+	reloaded, err := loadSchemaFromFile(write("dump.yaml", string(dump)))
+	if err != nil {
+		t.Fatalf("load dump: %v\n%s", err, dump)
+	}
+	again, err := dumpSchema(reloaded)
+	if err != nil {
+		t.Fatalf("dump reloaded schema: %v", err)
+	}
+	if string(again) != string(dump) {
+		t.Errorf("dump is not stable:\n%s\n---\n%s", dump, again)
+	}
+
+	doc := []byte("env: prod\nmin: 5\nmax: 2\nname: Abcd\nports: [2, 1]\nextra: 1\n")
+	want := v.NewValidator(schema).ValidateBytes(doc).Collector.AllSorted()
+	got := v.NewValidator(reloaded).ValidateBytes(doc).Collector.AllSorted()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded schema reports %v, want %v", got, want)
+	}
+	if len(want) < 4 {
+		t.Errorf("expected several findings, got %v", want)
+	}
+}
+
+func TestDumpSchema_Recursive(t *testing.T) {
+	schema := &v.FieldSchema{Type: v.TypeMap}
+	schema.AllowedKeys = map[string]*v.FieldSchema{"child": schema}
+	if _, err := dumpSchema(schema); err == nil || !strings.Contains(err.Error(), "recursive") {
+		t.Fatalf("expected recursion error, got %v", err)
+	}
+}
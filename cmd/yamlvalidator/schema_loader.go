@@ -3,71 +3,230 @@ package main
 import (
 	"errors"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	v "github.com/yakwilikk/go-yamlvalidator"
 	keyv "github.com/yakwilikk/go-yamlvalidator/pkg/keyvalidator"
+	mapv "github.com/yakwilikk/go-yamlvalidator/pkg/mapvalidator"
+	seqv "github.com/yakwilikk/go-yamlvalidator/pkg/seqvalidator"
 	valv "github.com/yakwilikk/go-yamlvalidator/pkg/valuevalidator"
 	"gopkg.in/yaml.v3"
 )
 
 type schemaNode struct {
-	Type              string                 `yaml:"type" json:"type"`
-	Required          bool                   `yaml:"required" json:"required"`
-	Nullable          bool                   `yaml:"nullable" json:"nullable"`
-	Deprecated        string                 `yaml:"deprecated" json:"deprecated"`
-	Default           interface{}            `yaml:"default" json:"default"`
-	AllowedKeys       map[string]*schemaNode `yaml:"allowedKeys" json:"allowedKeys"`
-	AdditionalProps   *schemaNode            `yaml:"additionalProperties" json:"additionalProperties"`
-	UnknownKeyPolicy  string                 `yaml:"unknownKeyPolicy" json:"unknownKeyPolicy"`
-	KeyValidators     []keyValidatorSpec     `yaml:"keyValidators" json:"keyValidators"`
-	ItemSchema        *schemaNode            `yaml:"itemSchema" json:"itemSchema"`
-	MinItems          *int                   `yaml:"minItems" json:"minItems"`
-	MaxItems          *int                   `yaml:"maxItems" json:"maxItems"`
-	Validators        []valueValidatorSpec   `yaml:"validators" json:"validators"`
-	AnyOf             [][]string             `yaml:"anyOf" json:"anyOf"`
-	ExactlyOneOf      []string               `yaml:"exactlyOneOf" json:"exactlyOneOf"`
-	MutuallyExclusive []string               `yaml:"mutuallyExclusive" json:"mutuallyExclusive"`
-	Conditions        []conditionalSpec      `yaml:"conditions" json:"conditions"`
+	Extends           pathList               `yaml:"extends,omitempty" json:"extends"` // root only
+	Type              string                 `yaml:"type,omitempty" json:"type"`
+	Required          bool                   `yaml:"required,omitempty" json:"required"`
+	Nullable          bool                   `yaml:"nullable,omitempty" json:"nullable"`
+	NonEmpty          bool                   `yaml:"nonEmpty,omitempty" json:"nonEmpty"`
+	MaxNesting        *int                   `yaml:"maxNesting,omitempty" json:"maxNesting"`
+	Deprecated        string                 `yaml:"deprecated,omitempty" json:"deprecated"`
+	Deprecation       *deprecationSpec       `yaml:"deprecation,omitempty" json:"deprecation"`
+	Stability         string                 `yaml:"stability,omitempty" json:"stability"`
+	RequireStyle      string                 `yaml:"requireStyle,omitempty" json:"requireStyle"`
+	RequireStyleLevel string                 `yaml:"requireStyleLevel,omitempty" json:"requireStyleLevel"`
+	Aliases           []string               `yaml:"aliases,omitempty" json:"aliases"`
+	Default           interface{}            `yaml:"default,omitempty" json:"default"`
+	AllowedKeys       map[string]*schemaNode `yaml:"allowedKeys,omitempty" json:"allowedKeys"`
+	CaseInsensitive   bool                   `yaml:"caseInsensitiveKeys,omitempty" json:"caseInsensitiveKeys"`
+	NumericKeys       bool                   `yaml:"numericKeys,omitempty" json:"numericKeys"`
+	AdditionalProps   *schemaNode            `yaml:"additionalProperties,omitempty" json:"additionalProperties"`
+	AdditionalByType  map[string]*schemaNode `yaml:"additionalPropertiesByType,omitempty" json:"additionalPropertiesByType"`
+	AdditionalLevel   string                 `yaml:"additionalPropertiesLevel,omitempty" json:"additionalPropertiesLevel"`
+	UnknownKeyPolicy  string                 `yaml:"unknownKeyPolicy,omitempty" json:"unknownKeyPolicy"`
+	KeyValidators     []keyValidatorSpec     `yaml:"keyValidators,omitempty" json:"keyValidators"`
+	SkipValueOnKeyErr bool                   `yaml:"skipValueOnKeyError,omitempty" json:"skipValueOnKeyError"`
+	MapValidators     []mapValidatorSpec     `yaml:"mapValidators,omitempty" json:"mapValidators"`
+	ItemSchema        *schemaNode            `yaml:"itemSchema,omitempty" json:"itemSchema"`
+	MinItems          *int                   `yaml:"minItems,omitempty" json:"minItems"`
+	MaxItems          *int                   `yaml:"maxItems,omitempty" json:"maxItems"`
+	ItemsNonNull      bool                   `yaml:"itemsNonNull,omitempty" json:"itemsNonNull"`
+	UniqueFields      []string               `yaml:"uniqueFields,omitempty" json:"uniqueFields"`
+	SeqValidators     []seqValidatorSpec     `yaml:"seqValidators,omitempty" json:"seqValidators"`
+	Validators        []valueValidatorSpec   `yaml:"validators,omitempty" json:"validators"`
+	AnyOf             [][]string             `yaml:"anyOf,omitempty" json:"anyOf"`
+	AtLeastNOf        []keyCountSpec         `yaml:"atLeastNOf,omitempty" json:"atLeastNOf"`
+	AtMostNOf         []keyCountSpec         `yaml:"atMostNOf,omitempty" json:"atMostNOf"`
+	ExactlyOneOf      []string               `yaml:"exactlyOneOf,omitempty" json:"exactlyOneOf"`
+	ExactlyOneGroupOf [][]string             `yaml:"exactlyOneGroupOf,omitempty" json:"exactlyOneGroupOf"`
+	MutuallyExclusive []string               `yaml:"mutuallyExclusive,omitempty" json:"mutuallyExclusive"`
+	AllOrNone         [][]string             `yaml:"allOrNone,omitempty" json:"allOrNone"`
+	Conditions        []conditionalSpec      `yaml:"conditions,omitempty" json:"conditions"`
 	AdditionalRaw     map[string]interface{} `yaml:"-" json:"-"` // catch-all for debugging
 }
 
+type deprecationSpec struct {
+	Message     string `yaml:"message,omitempty" json:"message"`
+	Since       string `yaml:"since,omitempty" json:"since"`
+	RemoveIn    string `yaml:"removeIn,omitempty" json:"removeIn"`
+	Replacement string `yaml:"replacement,omitempty" json:"replacement"`
+}
+
 type valueValidatorSpec struct {
-	Name           string   `yaml:"name" json:"name"`
-	Allowed        []string `yaml:"allowed" json:"allowed"`               // enum
-	Pattern        string   `yaml:"pattern" json:"pattern"`               // regex
-	Message        string   `yaml:"message" json:"message"`               // regex
-	Min            *float64 `yaml:"min" json:"min"`                       // range (float)
-	Max            *float64 `yaml:"max" json:"max"`                       // range (float)
-	MinLength      *int     `yaml:"minLength" json:"minLength"`           // length
-	MaxLength      *int     `yaml:"maxLength" json:"maxLength"`           // length
-	RequireScheme  bool     `yaml:"requireScheme" json:"requireScheme"`   // url
-	AllowedSchemes []string `yaml:"allowedSchemes" json:"allowedSchemes"` // url
-	Types          []string `yaml:"types" json:"types"`                   // one-of-type
+	Name           string               `yaml:"name,omitempty" json:"name"`
+	Allowed        []string             `yaml:"allowed,omitempty" json:"allowed"`               // enum
+	AllowedFile    string               `yaml:"allowedFile,omitempty" json:"allowedFile"`       // enum (relative to schema file)
+	Descriptions   map[string]string    `yaml:"descriptions,omitempty" json:"descriptions"`     // enum (keyed by allowed value)
+	Denied         []string             `yaml:"denied,omitempty" json:"denied"`                 // notin
+	DenyFile       string               `yaml:"denyFile,omitempty" json:"denyFile"`             // notin (relative to schema file)
+	Pattern        string               `yaml:"pattern,omitempty" json:"pattern"`               // regex
+	Message        string               `yaml:"message,omitempty" json:"message"`               // most validators; see each validator for placeholders
+	Min            *float64             `yaml:"min,omitempty" json:"min"`                       // range (float)
+	Max            *float64             `yaml:"max,omitempty" json:"max"`                       // range (float)
+	MinInt         *int64               `yaml:"minInt,omitempty" json:"minInt"`                 // intrange (exact int64)
+	MaxInt         *int64               `yaml:"maxInt,omitempty" json:"maxInt"`                 // intrange (exact int64)
+	MaxDecimals    int                  `yaml:"maxDecimals,omitempty" json:"maxDecimals"`       // precision
+	AllowSuffix    bool                 `yaml:"allowSuffix,omitempty" json:"allowSuffix"`       // percent (max reuses Max)
+	Chars          string               `yaml:"chars,omitempty" json:"chars"`                   // charset (allowed runes)
+	DisallowChars  string               `yaml:"disallowChars,omitempty" json:"disallowChars"`   // charset (forbidden runes)
+	MinLength      *int                 `yaml:"minLength,omitempty" json:"minLength"`           // length, displaywidth
+	MaxLength      *int                 `yaml:"maxLength,omitempty" json:"maxLength"`           // length, displaywidth
+	RequireScheme  bool                 `yaml:"requireScheme,omitempty" json:"requireScheme"`   // url
+	AllowedSchemes []string             `yaml:"allowedSchemes,omitempty" json:"allowedSchemes"` // url
+	Types          []string             `yaml:"types,omitempty" json:"types"`                   // one-of-type
+	AllowAlpha     bool                 `yaml:"allowAlpha,omitempty" json:"allowAlpha"`         // hexcolor
+	AllowExtended  bool                 `yaml:"allowExtended,omitempty" json:"allowExtended"`   // ascii (Latin-1)
+	MustExist      bool                 `yaml:"mustExist,omitempty" json:"mustExist"`           // filepath, directory
+	Mode           string               `yaml:"mode,omitempty" json:"mode"`                     // filepath
+	AllowAbsolute  bool                 `yaml:"allowAbsolute,omitempty" json:"allowAbsolute"`   // filepath
+	AllowRelative  bool                 `yaml:"allowRelative,omitempty" json:"allowRelative"`   // filepath
+	BaseDir        string               `yaml:"baseDir,omitempty" json:"baseDir"`               // filepath (relative to schema file)
+	Path           string               `yaml:"path,omitempty" json:"path"`                     // enumfrompath (selector)
+	Bits           int                  `yaml:"bits,omitempty" json:"bits"`                     // intwidth
+	Signed         bool                 `yaml:"signed,omitempty" json:"signed"`                 // intwidth
+	Funcs          []string             `yaml:"funcs,omitempty" json:"funcs"`                   // gotemplate (extra function names)
+	RequireActions bool                 `yaml:"requireActions,omitempty" json:"requireActions"` // gotemplate
+	ForbidActions  bool                 `yaml:"forbidActions,omitempty" json:"forbidActions"`   // gotemplate
+	Validators     []valueValidatorSpec `yaml:"validators,omitempty" json:"validators"`         // anyof (alternatives)
+	// Options holds parameters for registered validators (see
+	// RegisterValueValidator); built-in validators ignore it.
+	Options map[string]interface{} `yaml:"options,omitempty" json:"options"`
 }
 
 type keyValidatorSpec struct {
-	Name      string   `yaml:"name" json:"name"`
-	Pattern   string   `yaml:"pattern" json:"pattern"`     // regex
-	Message   string   `yaml:"message" json:"message"`     // regex
-	Forbidden []string `yaml:"forbidden" json:"forbidden"` // forbidden
-	MinLength *int     `yaml:"minLength" json:"minLength"` // length
-	Min       *int     `yaml:"min" json:"min"`             // alias for length
-	MaxLength *int     `yaml:"maxLength" json:"maxLength"` // length
-	Max       *int     `yaml:"max" json:"max"`             // alias for length
+	Name            string   `yaml:"name,omitempty" json:"name"`
+	Pattern         string   `yaml:"pattern,omitempty" json:"pattern"`                 // regex
+	Message         string   `yaml:"message,omitempty" json:"message"`                 // regex, forbidden, enum
+	Forbidden       []string `yaml:"forbidden,omitempty" json:"forbidden"`             // forbidden
+	Patterns        []string `yaml:"patterns,omitempty" json:"patterns"`               // forbidden (regex)
+	MinLength       *int     `yaml:"minLength,omitempty" json:"minLength"`             // length
+	Min             *int     `yaml:"min,omitempty" json:"min"`                         // alias for length
+	MaxLength       *int     `yaml:"maxLength,omitempty" json:"maxLength"`             // length
+	Max             *int     `yaml:"max,omitempty" json:"max"`                         // alias for length
+	Unit            string   `yaml:"unit,omitempty" json:"unit"`                       // length: runes (default) or bytes
+	Allowed         []string `yaml:"allowed,omitempty" json:"allowed"`                 // enum
+	CaseInsensitive bool     `yaml:"caseInsensitive,omitempty" json:"caseInsensitive"` // enum
+	// Options holds parameters for registered validators (see
+	// RegisterKeyValidator); built-in validators ignore it.
+	Options map[string]interface{} `yaml:"options,omitempty" json:"options"`
+}
+
+type mapValidatorSpec struct {
+	Name         string              `yaml:"name,omitempty" json:"name"`
+	Keys         []string            `yaml:"keys,omitempty" json:"keys"`                 // requiredkeys
+	Message      string              `yaml:"message,omitempty" json:"message"`           // requiredkeys, requirewhenvalue, fieldsdiffer
+	Dependencies map[string][]string `yaml:"dependencies,omitempty" json:"dependencies"` // dependentrequired
+	Field        string              `yaml:"field,omitempty" json:"field"`               // siblingcondition, requirewhenvalue
+	Value        string              `yaml:"value,omitempty" json:"value"`               // siblingcondition, requirewhenvalue
+	Target       string              `yaml:"target,omitempty" json:"target"`             // siblingcondition
+	Validator    *valueValidatorSpec `yaml:"validator,omitempty" json:"validator"`       // siblingcondition
+	Left         string              `yaml:"left,omitempty" json:"left"`                 // fieldcomparison, fieldsdiffer
+	Op           string              `yaml:"op,omitempty" json:"op"`                     // fieldcomparison
+	Right        string              `yaml:"right,omitempty" json:"right"`               // fieldcomparison, fieldsdiffer
+	Total        float64             `yaml:"total,omitempty" json:"total"`               // sum (with field, op)
+	Tolerance    float64             `yaml:"tolerance,omitempty" json:"tolerance"`       // sum
+	Require      []string            `yaml:"require,omitempty" json:"require"`           // requirewhenvalue (with field, value)
+
+	CaseInsensitive bool `yaml:"caseInsensitive,omitempty" json:"caseInsensitive"` // alphabeticalkeys
+}
+
+type seqValidatorSpec struct {
+	Name       string  `yaml:"name,omitempty" json:"name"`
+	Decreasing bool    `yaml:"decreasing,omitempty" json:"decreasing"` // monotonic
+	Strict     bool    `yaml:"strict,omitempty" json:"strict"`         // monotonic
+	Descending bool    `yaml:"descending,omitempty" json:"descending"` // sorted
+	Numeric    bool    `yaml:"numeric,omitempty" json:"numeric"`       // sorted
+	Field      string  `yaml:"field,omitempty" json:"field"`           // sum
+	Op         string  `yaml:"op,omitempty" json:"op"`                 // sum
+	Total      float64 `yaml:"total,omitempty" json:"total"`           // sum
+	Tolerance  float64 `yaml:"tolerance,omitempty" json:"tolerance"`   // sum
 }
 
 type conditionalSpec struct {
-	ConditionField string      `yaml:"conditionField" json:"conditionField"`
-	ConditionValue interface{} `yaml:"conditionValue" json:"conditionValue"`
-	ThenRequired   []string    `yaml:"thenRequired" json:"thenRequired"`
-	ThenForbidden  []string    `yaml:"thenForbidden" json:"thenForbidden"`
+	ConditionField   string      `yaml:"conditionField,omitempty" json:"conditionField"`
+	ConditionValue   interface{} `yaml:"conditionValue,omitempty" json:"conditionValue"`
+	ConditionPattern string      `yaml:"conditionPattern,omitempty" json:"conditionPattern"`
+	ThenRequired     []string    `yaml:"thenRequired,omitempty" json:"thenRequired"`
+	ThenForbidden    []string    `yaml:"thenForbidden,omitempty" json:"thenForbidden"`
+	ThenSchema       *schemaNode `yaml:"thenSchema,omitempty" json:"thenSchema"`
+}
+
+type keyCountSpec struct {
+	Keys []string `yaml:"keys,omitempty" json:"keys"`
+	N    int      `yaml:"count,omitempty" json:"count"`
+}
+
+// pathList is a list of file paths written as a single string or a list.
+type pathList []string
+
+func (p *pathList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = pathList{node.Value}
+		return nil
+	}
+	var paths []string
+	if err := node.Decode(&paths); err != nil {
+		return err
+	}
+	*p = paths
+	return nil
+}
+
+// schemaLoader holds state shared while converting one schema file.
+type schemaLoader struct {
+	// baseDir is the directory of the schema file; file references in the
+	// schema (e.g. allowedFile) are resolved relative to it.
+	baseDir string
+
+	// valueLists caches readValueList results by resolved path for the whole
+	// load, so a list shared by several fields or extended files is read once.
+	valueLists map[string][]string
 }
 
 // loadSchemaFromFile decodes a YAML/JSON schema file into FieldSchema.
 func loadSchemaFromFile(path string) (*v.FieldSchema, error) {
+	schema, err := loadSchemaTree(path, nil, make(map[string][]string))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSchema(schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// loadSchemaTree loads a schema file together with the files it extends. The
+// root "extends" key names one or more base files, resolved relative to the
+// file; they are merged in order and the file itself is merged over them with
+// MergeSchemas. chain holds the absolute paths of the files extending this
+// one, to detect cycles; valueLists is the value list cache shared by the load.
+// The result is not checked with checkSchema, since a base may be incomplete
+// on its own.
+func loadSchemaTree(path string, chain []string, valueLists map[string][]string) (*v.FieldSchema, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	for i, p := range chain {
+		if p == abs {
+			return nil, fmt.Errorf("extends cycle: %s", strings.Join(append(chain[i:], abs), " -> "))
+		}
+	}
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read schema: %w", err)
@@ -76,14 +235,80 @@ func loadSchemaFromFile(path string) (*v.FieldSchema, error) {
 	if err := yaml.Unmarshal(data, &root); err != nil {
 		return nil, fmt.Errorf("unmarshal schema: %w", err)
 	}
-	return convertSchemaNode(&root)
+	extends := root.Extends
+	root.Extends = nil
+	l := &schemaLoader{baseDir: filepath.Dir(path), valueLists: valueLists}
+	schema, err := l.convertSchemaNode(&root)
+	if err != nil {
+		return nil, err
+	}
+	if len(extends) == 0 {
+		return schema, nil
+	}
+
+	var base *v.FieldSchema
+	for _, ref := range extends {
+		loaded, err := loadSchemaTree(l.resolvePath(ref), append(chain[:len(chain):len(chain)], abs), valueLists)
+		if err != nil {
+			return nil, fmt.Errorf("extends %s: %w", ref, err)
+		}
+		if base, err = v.MergeSchemas(base, loaded); err != nil {
+			return nil, fmt.Errorf("extends %s: %w", ref, err)
+		}
+	}
+	if schema, err = v.MergeSchemas(base, schema); err != nil {
+		return nil, fmt.Errorf("merge over %s: %w", strings.Join(extends, ", "), err)
+	}
+	return schema, nil
+}
+
+// checkSchema runs ValidateSchema and joins its findings into one error.
+func checkSchema(schema *v.FieldSchema) error {
+	errs := v.ValidateSchema(schema)
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return fmt.Errorf("invalid schema:\n%s", strings.Join(msgs, "\n"))
+}
+
+// loadSchemaFiles loads a base schema followed by overlays and merges them in
+// order with MergeSchemas.
+func loadSchemaFiles(paths []string) (*v.FieldSchema, error) {
+	var schema *v.FieldSchema
+	for _, path := range paths {
+		loaded, err := loadSchemaFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if schema == nil {
+			schema = loaded
+			continue
+		}
+		if schema, err = v.MergeSchemas(schema, loaded); err != nil {
+			return nil, fmt.Errorf("merge %s: %w", path, err)
+		}
+	}
+	if len(paths) > 1 {
+		if err := checkSchema(schema); err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
 }
 
-func convertSchemaNode(sn *schemaNode) (*v.FieldSchema, error) {
+func (l *schemaLoader) convertSchemaNode(sn *schemaNode) (*v.FieldSchema, error) {
 	if sn == nil {
 		return nil, errors.New("schema node is nil")
 	}
 
+	if len(sn.Extends) > 0 {
+		return nil, errors.New("extends is only allowed at the schema root")
+	}
+
 	nodeType, err := parseNodeType(sn.Type)
 	if err != nil {
 		return nil, err
@@ -92,29 +317,65 @@ func convertSchemaNode(sn *schemaNode) (*v.FieldSchema, error) {
 	if err != nil {
 		return nil, err
 	}
+	stability, err := parseStability(sn.Stability)
+	if err != nil {
+		return nil, err
+	}
 
 	fs := &v.FieldSchema{
-		Type:             nodeType,
-		Required:         sn.Required,
-		Nullable:         sn.Nullable,
-		Deprecated:       sn.Deprecated,
-		Default:          sn.Default,
-		UnknownKeyPolicy: ukp,
+		Type:                nodeType,
+		Required:            sn.Required,
+		Nullable:            sn.Nullable,
+		NonEmpty:            sn.NonEmpty,
+		Deprecated:          sn.Deprecated,
+		Aliases:             sn.Aliases,
+		Default:             sn.Default,
+		CaseInsensitiveKeys: sn.CaseInsensitive,
+		NumericKeys:         sn.NumericKeys,
+		SkipValueOnKeyError: sn.SkipValueOnKeyErr,
+		UnknownKeyPolicy:    ukp,
+		Stability:           stability,
+	}
+	if d := sn.Deprecation; d != nil {
+		fs.DeprecatedInfo = &v.DeprecatedInfo{
+			Message:     d.Message,
+			Since:       d.Since,
+			RemoveIn:    d.RemoveIn,
+			Replacement: d.Replacement,
+		}
 	}
 
 	if sn.ItemSchema != nil {
-		fs.ItemSchema, err = convertSchemaNode(sn.ItemSchema)
+		fs.ItemSchema, err = l.convertSchemaNode(sn.ItemSchema)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if sn.MaxNesting != nil && *sn.MaxNesting < 1 {
+		return nil, fmt.Errorf("maxNesting must be at least 1, got %d", *sn.MaxNesting)
+	}
+	fs.MaxNesting = sn.MaxNesting
 	fs.MinItems = sn.MinItems
 	fs.MaxItems = sn.MaxItems
+	fs.ItemsNonNull = sn.ItemsNonNull
+	fs.UniqueFields = sn.UniqueFields
+
+	if len(sn.SeqValidators) > 0 {
+		vals := make([]v.SeqValidator, 0, len(sn.SeqValidators))
+		for _, spec := range sn.SeqValidators {
+			val, err := buildSeqValidator(spec)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, val)
+		}
+		fs.SeqValidators = vals
+	}
 
 	if sn.AllowedKeys != nil {
 		fs.AllowedKeys = make(map[string]*v.FieldSchema, len(sn.AllowedKeys))
 		for k, child := range sn.AllowedKeys {
-			converted, err := convertSchemaNode(child)
+			converted, err := l.convertSchemaNode(child)
 			if err != nil {
 				return nil, fmt.Errorf("allowedKeys[%s]: %w", k, err)
 			}
@@ -122,26 +383,69 @@ func convertSchemaNode(sn *schemaNode) (*v.FieldSchema, error) {
 		}
 	}
 	if sn.AdditionalProps != nil {
-		fs.AdditionalProperties, err = convertSchemaNode(sn.AdditionalProps)
+		fs.AdditionalProperties, err = l.convertSchemaNode(sn.AdditionalProps)
 		if err != nil {
 			return nil, fmt.Errorf("additionalProperties: %w", err)
 		}
 	}
+	if len(sn.AdditionalByType) > 0 {
+		fs.AdditionalPropertiesByType = make(map[v.NodeType]*v.FieldSchema, len(sn.AdditionalByType))
+		for typeName, child := range sn.AdditionalByType {
+			t, err := parseNodeType(typeName)
+			if err != nil {
+				return nil, fmt.Errorf("additionalPropertiesByType: %w", err)
+			}
+			converted, err := l.convertSchemaNode(child)
+			if err != nil {
+				return nil, fmt.Errorf("additionalPropertiesByType[%s]: %w", typeName, err)
+			}
+			fs.AdditionalPropertiesByType[t] = converted
+		}
+	}
+	if fs.RequireStyle, err = parseCollectionStyle(sn.RequireStyle); err != nil {
+		return nil, err
+	}
+	if sn.RequireStyleLevel != "" {
+		var lvl v.ErrorLevel
+		if err := lvl.UnmarshalText([]byte(sn.RequireStyleLevel)); err != nil {
+			return nil, fmt.Errorf("requireStyleLevel: %w", err)
+		}
+		fs.RequireStyleLevel = &lvl
+	}
+	if sn.AdditionalLevel != "" {
+		var lvl v.ErrorLevel
+		if err := lvl.UnmarshalText([]byte(sn.AdditionalLevel)); err != nil {
+			return nil, fmt.Errorf("additionalPropertiesLevel: %w", err)
+		}
+		fs.AdditionalPropertiesLevel = &lvl
+	}
 
 	if len(sn.AnyOf) > 0 {
 		fs.AnyOf = sn.AnyOf
 	}
+	for _, c := range sn.AtLeastNOf {
+		fs.AtLeastNOf = append(fs.AtLeastNOf, v.KeyCount{Keys: c.Keys, N: c.N})
+	}
+	for _, c := range sn.AtMostNOf {
+		fs.AtMostNOf = append(fs.AtMostNOf, v.KeyCount{Keys: c.Keys, N: c.N})
+	}
 	if len(sn.ExactlyOneOf) > 0 {
 		fs.ExactlyOneOf = sn.ExactlyOneOf
 	}
+	if len(sn.ExactlyOneGroupOf) > 0 {
+		fs.ExactlyOneGroupOf = sn.ExactlyOneGroupOf
+	}
 	if len(sn.MutuallyExclusive) > 0 {
 		fs.MutuallyExclusive = sn.MutuallyExclusive
 	}
+	if len(sn.AllOrNone) > 0 {
+		fs.AllOrNone = sn.AllOrNone
+	}
 
 	if len(sn.Validators) > 0 {
 		vals := make([]v.ValueValidator, 0, len(sn.Validators))
 		for _, spec := range sn.Validators {
-			val, err := buildValueValidator(spec)
+			val, err := l.buildValueValidator(spec)
 			if err != nil {
 				return nil, err
 			}
@@ -162,15 +466,47 @@ func convertSchemaNode(sn *schemaNode) (*v.FieldSchema, error) {
 		fs.KeyValidators = vals
 	}
 
+	if len(sn.MapValidators) > 0 {
+		vals := make([]v.MapValidator, 0, len(sn.MapValidators))
+		for _, spec := range sn.MapValidators {
+			val, err := l.buildMapValidator(spec)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, val)
+		}
+		fs.MapValidators = vals
+	}
+
 	if len(sn.Conditions) > 0 {
 		conds := make([]v.ConditionalRule, 0, len(sn.Conditions))
 		for _, c := range sn.Conditions {
-			conds = append(conds, v.ConditionalRule{
+			rule := v.ConditionalRule{
 				ConditionField: c.ConditionField,
-				ConditionValue: fmt.Sprint(c.ConditionValue),
 				ThenRequired:   c.ThenRequired,
 				ThenForbidden:  c.ThenForbidden,
-			})
+			}
+			if c.ConditionValue != nil && c.ConditionPattern != "" {
+				return nil, fmt.Errorf("condition on %q: conditionValue and conditionPattern are mutually exclusive", c.ConditionField)
+			}
+			if c.ConditionValue != nil {
+				rule.ConditionValue = fmt.Sprint(c.ConditionValue)
+			}
+			if c.ConditionPattern != "" {
+				re, err := regexp.Compile(c.ConditionPattern)
+				if err != nil {
+					return nil, fmt.Errorf("conditionPattern: %w", err)
+				}
+				rule.ConditionPattern = re
+			}
+			if c.ThenSchema != nil {
+				then, err := l.convertSchemaNode(c.ThenSchema)
+				if err != nil {
+					return nil, fmt.Errorf("thenSchema: %w", err)
+				}
+				rule.ThenSchema = then
+			}
+			conds = append(conds, rule)
 		}
 		fs.Conditions = conds
 	}
@@ -196,6 +532,8 @@ func parseNodeType(t string) (v.NodeType, error) {
 		return v.TypeMap, nil
 	case "sequence", "array":
 		return v.TypeSequence, nil
+	case "set":
+		return v.TypeSet, nil
 	default:
 		return v.TypeAny, fmt.Errorf("unknown type: %q", t)
 	}
@@ -216,10 +554,74 @@ func parseUnknownKeyPolicy(p string) (v.UnknownKeyPolicy, error) {
 	}
 }
 
-func buildValueValidator(spec valueValidatorSpec) (v.ValueValidator, error) {
+func parseStability(s string) (v.Stability, error) {
+	switch strings.ToLower(s) {
+	case "", "stable":
+		return v.StabilityStable, nil
+	case "beta":
+		return v.StabilityBeta, nil
+	case "experimental":
+		return v.StabilityExperimental, nil
+	default:
+		return v.StabilityStable, fmt.Errorf("unknown stability: %q", s)
+	}
+}
+
+func parseCollectionStyle(s string) (v.CollectionStyle, error) {
+	switch strings.ToLower(s) {
+	case "", "any":
+		return v.StyleAny, nil
+	case "block":
+		return v.StyleBlock, nil
+	case "flow":
+		return v.StyleFlow, nil
+	default:
+		return v.StyleAny, fmt.Errorf("unknown requireStyle: %q", s)
+	}
+}
+
+func (l *schemaLoader) buildValueValidator(spec valueValidatorSpec) (v.ValueValidator, error) {
+	if factory := registeredValueValidator(spec.Name); factory != nil {
+		return factory(spec)
+	}
 	switch strings.ToLower(spec.Name) {
 	case "enum":
-		return valv.EnumValidator{Allowed: spec.Allowed}, nil
+		allowed := spec.Allowed
+		if spec.AllowedFile != "" {
+			fromFile, err := l.readValueList(spec.AllowedFile)
+			if err != nil {
+				return nil, fmt.Errorf("enum validator: %w", err)
+			}
+			allowed = append(append([]string(nil), allowed...), fromFile...)
+		}
+		known := make(map[string]bool, len(allowed))
+		for _, value := range allowed {
+			known[value] = true
+		}
+		for value := range spec.Descriptions {
+			if !known[value] {
+				return nil, fmt.Errorf("enum validator: description for %q, which is not an allowed value", value)
+			}
+		}
+		return valv.EnumValidator{Allowed: allowed, Descriptions: spec.Descriptions, Message: spec.Message}, nil
+	case "notin":
+		denied := spec.Denied
+		if spec.DenyFile != "" {
+			fromFile, err := l.readValueList(spec.DenyFile)
+			if err != nil {
+				return nil, fmt.Errorf("notin validator: %w", err)
+			}
+			denied = append(append([]string(nil), denied...), fromFile...)
+		}
+		if len(denied) == 0 {
+			return nil, fmt.Errorf("notin validator: denied or denyFile is required")
+		}
+		return valv.NotInValidator{Denied: denied, Message: spec.Message}, nil
+	case "enumfrompath":
+		if _, err := v.SelectPath(&yaml.Node{}, spec.Path); err != nil || spec.Path == "" {
+			return nil, fmt.Errorf("enumfrompath validator: path is required and must be a valid selector")
+		}
+		return valv.EnumFromPathValidator{Path: spec.Path, Message: spec.Message}, nil
 	case "regex":
 		re, err := regexp.Compile(spec.Pattern)
 		if err != nil {
@@ -227,11 +629,53 @@ func buildValueValidator(spec valueValidatorSpec) (v.ValueValidator, error) {
 		}
 		return valv.RegexValidator{Pattern: re, Message: spec.Message}, nil
 	case "range":
-		return valv.RangeValidator{Min: spec.Min, Max: spec.Max}, nil
+		return valv.RangeValidator{Min: spec.Min, Max: spec.Max, Message: spec.Message}, nil
+	case "intrange":
+		minVal, err := intBound("min", spec.MinInt, spec.Min)
+		if err != nil {
+			return nil, fmt.Errorf("intrange validator: %w", err)
+		}
+		maxVal, err := intBound("max", spec.MaxInt, spec.Max)
+		if err != nil {
+			return nil, fmt.Errorf("intrange validator: %w", err)
+		}
+		return valv.IntRangeValidator{Min: minVal, Max: maxVal, Message: spec.Message}, nil
+	case "intwidth":
+		if spec.Bits < 1 || spec.Bits > 64 {
+			return nil, fmt.Errorf("intwidth validator: bits must be between 1 and 64, got %d", spec.Bits)
+		}
+		return valv.IntWidthValidator{Bits: spec.Bits, Signed: spec.Signed, Message: spec.Message}, nil
+	case "precision":
+		if spec.MaxDecimals < 0 {
+			return nil, fmt.Errorf("precision validator: maxDecimals must not be negative")
+		}
+		return valv.PrecisionValidator{MaxDecimals: spec.MaxDecimals, Message: spec.Message}, nil
+	case "percent":
+		return valv.PercentValidator{AllowSuffix: spec.AllowSuffix, Max: spec.Max}, nil
+	case "charset":
+		if spec.Chars == "" && spec.DisallowChars == "" {
+			return nil, fmt.Errorf("charset validator: set chars and/or disallowChars")
+		}
+		return valv.CharsetValidator{Allowed: spec.Chars, Disallowed: spec.DisallowChars, Message: spec.Message}, nil
+	case "anyof":
+		if len(spec.Validators) == 0 {
+			return nil, fmt.Errorf("anyof validator: validators must not be empty")
+		}
+		alts := make([]v.ValueValidator, 0, len(spec.Validators))
+		for i, altSpec := range spec.Validators {
+			alt, err := l.buildValueValidator(altSpec)
+			if err != nil {
+				return nil, fmt.Errorf("anyof validator: alternative %d: %w", i, err)
+			}
+			alts = append(alts, alt)
+		}
+		return valv.AnyOfValidator{Validators: alts, Message: spec.Message}, nil
 	case "nonempty":
 		return valv.NonEmptyValidator{}, nil
 	case "length":
-		return valv.LengthValidator{Min: spec.MinLength, Max: spec.MaxLength}, nil
+		return valv.LengthValidator{Min: spec.MinLength, Max: spec.MaxLength, Message: spec.Message}, nil
+	case "displaywidth":
+		return valv.DisplayWidthValidator{Min: spec.MinLength, Max: spec.MaxLength}, nil
 	case "url":
 		return valv.URLValidator{RequireScheme: spec.RequireScheme, AllowedSchemes: spec.AllowedSchemes}, nil
 	case "oneoftype":
@@ -244,12 +688,117 @@ func buildValueValidator(spec valueValidatorSpec) (v.ValueValidator, error) {
 			types = append(types, nt)
 		}
 		return valv.OneOfTypeValidator{Types: types}, nil
+	case "languagetag":
+		return valv.LanguageTagValidator{Message: spec.Message}, nil
+	case "ascii":
+		return valv.ASCIIValidator{AllowExtended: spec.AllowExtended, Message: spec.Message}, nil
+	case "singleline":
+		return valv.SingleLineValidator{Message: spec.Message}, nil
+	case "gotemplate":
+		if spec.RequireActions && spec.ForbidActions {
+			return nil, fmt.Errorf("gotemplate validator: requireActions and forbidActions are mutually exclusive")
+		}
+		return valv.GoTemplateValidator{Funcs: spec.Funcs, RequireActions: spec.RequireActions, ForbidActions: spec.ForbidActions, Message: spec.Message}, nil
+	case "glob":
+		return valv.GlobValidator{Message: spec.Message}, nil
+	case "jsonpointer":
+		return valv.JSONPointerValidator{Message: spec.Message}, nil
+	case "semverrange":
+		return valv.SemVerRangeValidator{Message: spec.Message}, nil
+	case "hexcolor":
+		return valv.HexColorValidator{AllowAlpha: spec.AllowAlpha, Message: spec.Message}, nil
+	case "filepath":
+		mode := valv.PathMode(strings.ToLower(spec.Mode))
+		switch mode {
+		case "", valv.PathModeAny, valv.PathModeFile, valv.PathModeDir:
+		default:
+			return nil, fmt.Errorf("filepath validator: unknown mode %q", spec.Mode)
+		}
+		var baseDir string
+		if spec.BaseDir != "" {
+			baseDir = l.resolvePath(spec.BaseDir)
+		}
+		return valv.FilePathValidator{
+			MustExist:     spec.MustExist,
+			Mode:          mode,
+			AllowAbsolute: spec.AllowAbsolute,
+			AllowRelative: spec.AllowRelative,
+			BaseDir:       baseDir,
+			Message:       spec.Message,
+		}, nil
+	case "directory":
+		return valv.DirectoryValidator{MustExist: spec.MustExist}, nil
 	default:
 		return nil, fmt.Errorf("unknown validator name: %q", spec.Name)
 	}
 }
 
+// intBound picks the exact int64 bound, falling back to the float field
+// (min/max) when it holds a whole number that float64 represents exactly.
+func intBound(name string, exact *int64, f *float64) (*int64, error) {
+	if exact != nil || f == nil {
+		return exact, nil
+	}
+	const maxExact = 1 << 53
+	if *f != math.Trunc(*f) || math.Abs(*f) > maxExact {
+		return nil, fmt.Errorf("%s must be a whole number within ±2^53 (use %sInt for larger bounds)", name, name)
+	}
+	n := int64(*f)
+	return &n, nil
+}
+
+// resolvePath resolves a file reference of the schema against its directory.
+func (l *schemaLoader) resolvePath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(l.baseDir, name)
+}
+
+// readValueList reads a list of values from a file, resolved relative to the schema file.
+// The file is either a YAML sequence of scalars or plain text with one value per line
+// (blank lines and lines starting with '#' are skipped). Lists are cached for the load.
+func (l *schemaLoader) readValueList(name string) ([]string, error) {
+	path := l.resolvePath(name)
+	if list, ok := l.valueLists[path]; ok {
+		return list, nil
+	}
+	list, err := parseValueList(path)
+	if err != nil {
+		return nil, err
+	}
+	if l.valueLists != nil {
+		l.valueLists[path] = list
+	}
+	return list, nil
+}
+
+func parseValueList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read value list: %w", err)
+	}
+
+	var list []string
+	if err := yaml.Unmarshal(data, &list); err == nil && len(list) > 0 {
+		return list, nil
+	}
+
+	list = nil
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list = append(list, line)
+	}
+	return list, nil
+}
+
 func buildKeyValidator(spec keyValidatorSpec) (v.KeyValidator, error) {
+	if factory := registeredKeyValidator(spec.Name); factory != nil {
+		return factory(spec)
+	}
 	switch strings.ToLower(spec.Name) {
 	case "regex":
 		re, err := regexp.Compile(spec.Pattern)
@@ -258,7 +807,15 @@ func buildKeyValidator(spec keyValidatorSpec) (v.KeyValidator, error) {
 		}
 		return keyv.RegexKeyValidator{Pattern: re, Message: spec.Message}, nil
 	case "forbidden":
-		return keyv.ForbiddenKeyValidator{Forbidden: spec.Forbidden}, nil
+		patterns := make([]*regexp.Regexp, 0, len(spec.Patterns))
+		for _, p := range spec.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("forbidden key validator: %w", err)
+			}
+			patterns = append(patterns, re)
+		}
+		return keyv.ForbiddenKeyValidator{Forbidden: spec.Forbidden, Patterns: patterns, Message: spec.Message}, nil
 	case "length":
 		min := spec.MinLength
 		if min == nil {
@@ -268,8 +825,89 @@ func buildKeyValidator(spec keyValidatorSpec) (v.KeyValidator, error) {
 		if max == nil {
 			max = spec.Max
 		}
-		return keyv.LengthKeyValidator{Min: min, Max: max}, nil
+		var unit keyv.LengthUnit
+		switch strings.ToLower(spec.Unit) {
+		case "", "runes":
+			unit = keyv.Runes
+		case "bytes":
+			unit = keyv.Bytes
+		default:
+			return nil, fmt.Errorf("length key validator: unknown unit %q", spec.Unit)
+		}
+		return keyv.LengthKeyValidator{Min: min, Max: max, Unit: unit}, nil
+	case "enum":
+		if len(spec.Allowed) == 0 {
+			return nil, fmt.Errorf("enum key validator: allowed must not be empty")
+		}
+		return keyv.EnumKeyValidator{Allowed: spec.Allowed, CaseInsensitive: spec.CaseInsensitive, Message: spec.Message}, nil
 	default:
 		return nil, fmt.Errorf("unknown key validator name: %q", spec.Name)
 	}
 }
+
+func (l *schemaLoader) buildMapValidator(spec mapValidatorSpec) (v.MapValidator, error) {
+	switch strings.ToLower(spec.Name) {
+	case "requiredkeys":
+		return mapv.RequiredKeysValidator{Keys: spec.Keys, Message: spec.Message}, nil
+	case "dependentrequired":
+		return mapv.DependentRequiredValidator{Dependencies: spec.Dependencies}, nil
+	case "siblingcondition":
+		if spec.Field == "" || spec.Target == "" || spec.Validator == nil {
+			return nil, fmt.Errorf("siblingcondition validator: field, target and validator are required")
+		}
+		val, err := l.buildValueValidator(*spec.Validator)
+		if err != nil {
+			return nil, fmt.Errorf("siblingcondition validator: %w", err)
+		}
+		return mapv.SiblingConditionValidator{Field: spec.Field, Value: spec.Value, Target: spec.Target, Validator: val}, nil
+	case "fieldcomparison":
+		op := mapv.CompareOp(spec.Op)
+		if spec.Left == "" || spec.Right == "" || !op.Valid() {
+			return nil, fmt.Errorf("fieldcomparison validator: left, right and op (<, <=, ==, !=, >, >=) are required")
+		}
+		return mapv.FieldComparisonValidator{Left: spec.Left, Op: op, Right: spec.Right}, nil
+	case "fieldsdiffer":
+		if spec.Left == "" || spec.Right == "" {
+			return nil, fmt.Errorf("fieldsdiffer validator: left and right are required")
+		}
+		return mapv.FieldsDifferValidator{Left: spec.Left, Right: spec.Right, Message: spec.Message}, nil
+	case "sum":
+		return buildSumValidator(spec.Field, spec.Op, spec.Total, spec.Tolerance)
+	case "alphabeticalkeys":
+		return mapv.AlphabeticalKeysValidator{CaseInsensitive: spec.CaseInsensitive}, nil
+	case "requirewhenvalue":
+		if spec.Field == "" || len(spec.Require) == 0 {
+			return nil, fmt.Errorf("requirewhenvalue validator: field and require are required")
+		}
+		return mapv.RequireWhenValueValidator{Field: spec.Field, Value: spec.Value, Require: spec.Require, Message: spec.Message}, nil
+	default:
+		return nil, fmt.Errorf("unknown map validator name: %q", spec.Name)
+	}
+}
+
+// buildSumValidator builds the sum validator shared by seqValidators and mapValidators.
+func buildSumValidator(field, op string, total, tolerance float64) (seqv.SumValidator, error) {
+	sumOp := seqv.SumOp(op)
+	switch sumOp {
+	case "", seqv.SumEqual, seqv.SumAtMost, seqv.SumAtLeast:
+	default:
+		return seqv.SumValidator{}, fmt.Errorf("sum validator: unknown op %q (use ==, <= or >=)", op)
+	}
+	if tolerance < 0 {
+		return seqv.SumValidator{}, fmt.Errorf("sum validator: tolerance must not be negative")
+	}
+	return seqv.SumValidator{Field: field, Op: sumOp, Total: total, Tolerance: tolerance}, nil
+}
+
+func buildSeqValidator(spec seqValidatorSpec) (v.SeqValidator, error) {
+	switch strings.ToLower(spec.Name) {
+	case "monotonic":
+		return seqv.MonotonicValidator{Decreasing: spec.Decreasing, Strict: spec.Strict}, nil
+	case "sorted":
+		return seqv.SortedValidator{Descending: spec.Descending, Numeric: spec.Numeric}, nil
+	case "sum":
+		return buildSumValidator(spec.Field, spec.Op, spec.Total, spec.Tolerance)
+	default:
+		return nil, fmt.Errorf("unknown sequence validator name: %q", spec.Name)
+	}
+}
@@ -3,6 +3,9 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	v "github.com/yakwilikk/go-yamlvalidator"
@@ -82,3 +85,551 @@ validators:
 		t.Fatalf("expected error for unknown validator")
 	}
 }
+
+func TestLoadSchemaFromFile_EnumAllowedFile(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmp, "lists"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "lists", "countries.txt"), []byte("# ISO codes\nUS\nDE\n\nFR\n"), 0o644); err != nil {
+		t.Fatalf("write list: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "lists", "tiers.yaml"), []byte("- free\n- pro\n"), 0o644); err != nil {
+		t.Fatalf("write list: %v", err)
+	}
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`type: map
+allowedKeys:
+  country:
+    type: string
+    validators:
+      - name: enum
+        allowedFile: lists/countries.txt
+  tier:
+    type: string
+    validators:
+      - name: enum
+        allowed: [enterprise]
+        allowedFile: lists/tiers.yaml
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+
+	validator := v.NewValidator(schema)
+	for _, doc := range []string{"country: DE\ntier: pro\n", "country: FR\ntier: enterprise\n"} {
+		if res := validator.ValidateBytes([]byte(doc)); res.HasErrors() {
+			t.Fatalf("expected %q to be valid, got %v", doc, res.Collector.Errors())
+		}
+	}
+	res := validator.ValidateBytes([]byte("country: XX\ntier: gold\n"))
+	if got := len(res.Collector.Errors()); got != 2 {
+		t.Fatalf("expected 2 enum errors, got %d: %v", got, res.Collector.Errors())
+	}
+}
+
+func TestLoadSchemaFromFile_EnumAllowedFileMissing(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`type: string
+validators:
+  - name: enum
+    allowedFile: nope.txt
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	if _, err := loadSchemaFromFile(schemaPath); err == nil {
+		t.Fatalf("expected error for missing allowedFile")
+	}
+}
+
+func TestLoadSchemaFromFile_FilePathBaseDir(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmp, "configs"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "configs", "app.yaml"), []byte("a: 1\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`type: map
+allowedKeys:
+  config:
+    type: string
+    validators:
+      - name: filepath
+        mustExist: true
+        baseDir: configs
+        message: "{value} not found"
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	ctx := v.ValidationContext{CheckFilesystem: true}
+	if res := v.NewValidator(schema).ValidateWithOptions([]byte("config: app.yaml\n"), ctx); res.HasErrors() {
+		t.Fatalf("expected app.yaml to resolve against baseDir, got %v", res.Collector.Errors())
+	}
+	errs := v.NewValidator(schema).ValidateWithOptions([]byte("config: db.yaml\n"), ctx).Collector.Errors()
+	if len(errs) != 1 || errs[0].Message != "db.yaml not found" {
+		t.Fatalf("expected custom missing-path message, got %v", errs)
+	}
+}
+
+func TestLoadSchemaFromFile_NotInDenyFile(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "banned.txt"), []byte("# images\nlatest\nnginx:1.0\n"), 0o644); err != nil {
+		t.Fatalf("write list: %v", err)
+	}
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`type: map
+allowedKeys:
+  image:
+    type: string
+    validators:
+      - name: notin
+        denyFile: banned.txt
+  sidecar:
+    type: string
+    validators:
+      - name: notin
+        denied: [busybox]
+        denyFile: banned.txt
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	validator := v.NewValidator(schema)
+	if res := validator.ValidateBytes([]byte("image: nginx:1.25\nsidecar: envoy\n")); res.HasErrors() {
+		t.Fatalf("expected valid document, got %v", res.Collector.Errors())
+	}
+	res := validator.ValidateBytes([]byte("image: latest\nsidecar: busybox\n"))
+	if got := len(res.Collector.Errors()); got != 2 {
+		t.Fatalf("expected 2 deny-list errors, got %d: %v", got, res.Collector.Errors())
+	}
+}
+
+func TestReadValueList_Cached(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "names.txt")
+	if err := os.WriteFile(path, []byte("admin\nroot\n"), 0o644); err != nil {
+		t.Fatalf("write list: %v", err)
+	}
+	l := &schemaLoader{baseDir: tmp, valueLists: make(map[string][]string)}
+	if _, err := l.readValueList("names.txt"); err != nil {
+		t.Fatalf("read list: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove list: %v", err)
+	}
+	list, err := l.readValueList("names.txt")
+	if err != nil || !reflect.DeepEqual(list, []string{"admin", "root"}) {
+		t.Fatalf("expected cached list, got %v, %v", list, err)
+	}
+}
+
+func TestLoadSchemaFromFile_MapValidators(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`type: map
+additionalProperties:
+  type: any
+mapValidators:
+  - name: requiredKeys
+    keys: [app]
+  - name: dependentRequired
+    dependencies:
+      tls: [cert]
+  - name: siblingCondition
+    field: protocol
+    value: https
+    target: port
+    validator:
+      name: enum
+      allowed: ["443"]
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	if len(schema.MapValidators) != 3 {
+		t.Fatalf("expected 3 map validators, got %d", len(schema.MapValidators))
+	}
+	res := v.NewValidator(schema).ValidateBytes([]byte("tls: true\nprotocol: https\nport: 80\n"))
+	if got := len(res.Collector.Errors()); got != 3 {
+		t.Fatalf("expected 3 errors (missing app, missing cert, port), got %d: %v", got, res.Collector.Errors())
+	}
+}
+
+func TestLoadSchemaFromFile_SeqValidators(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`type: sequence
+itemSchema:
+  type: int
+seqValidators:
+  - name: sorted
+    numeric: true
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	validator := v.NewValidator(schema)
+	if res := validator.ValidateBytes([]byte("[1, 5, 10]")); res.HasErrors() {
+		t.Fatalf("expected sorted sequence to be valid, got %v", res.Collector.Errors())
+	}
+	if res := validator.ValidateBytes([]byte("[1, 10, 5]")); len(res.Collector.Errors()) != 1 {
+		t.Fatalf("expected 1 error for unsorted sequence, got %v", res.Collector.Errors())
+	}
+}
+
+func TestLoadSchemaFromFile_InvalidDefault(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`
+type: map
+allowedKeys:
+  replicas:
+    type: int
+    default: abc
+  mode:
+    type: string
+    default: fast
+    validators:
+      - name: enum
+        allowed: [slow, safe]
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	_, err = loadSchemaFromFile(schemaPath)
+	if err == nil {
+		t.Fatal("expected error for invalid defaults")
+	}
+	for _, want := range []string{"path: replicas", "path: mode", "invalid default"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadSchemaFromFile_IntRange(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`
+type: map
+allowedKeys:
+  big:
+    type: int
+    validators:
+      - name: intrange
+        min: 1
+        maxInt: 9000000000000000000
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	res := v.NewValidator(schema).ValidateBytes([]byte("big: 9000000000000000001\n"))
+	if errs := res.Collector.Errors(); len(errs) != 1 || errs[0].Message != "value above maximum" {
+		t.Fatalf("expected maximum error, got %v", errs)
+	}
+
+	if err := os.WriteFile(schemaPath, []byte(`
+type: int
+validators:
+  - name: intrange
+    min: 1.5
+`), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	if _, err := loadSchemaFromFile(schemaPath); err == nil || !strings.Contains(err.Error(), "whole number") {
+		t.Fatalf("expected whole number error, got %v", err)
+	}
+}
+
+func TestLoadSchemaFromFile_AnyOf(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`
+type: map
+allowedKeys:
+  level:
+    type: any
+    validators:
+      - name: anyof
+        validators:
+          - name: enum
+            allowed: [low, high]
+          - name: intrange
+            min: 0
+            max: 10
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	for doc, want := range map[string]int{"level: low\n": 0, "level: 7\n": 0, "level: mid\n": 1, "level: 11\n": 1} {
+		if got := len(v.NewValidator(schema).ValidateBytes([]byte(doc)).Collector.Errors()); got != want {
+			t.Errorf("%q: got %d errors, want %d", doc, got, want)
+		}
+	}
+
+	if err := os.WriteFile(schemaPath, []byte(`
+type: string
+validators:
+  - name: anyof
+    validators:
+      - name: nosuch
+`), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	if _, err := loadSchemaFromFile(schemaPath); err == nil || !strings.Contains(err.Error(), "alternative 0") {
+		t.Fatalf("expected nested validator error, got %v", err)
+	}
+}
+
+func TestLoadSchemaFromFile_Sum(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`
+type: map
+allowedKeys:
+  split:
+    type: map
+    additionalProperties:
+      type: int
+    mapValidators:
+      - name: sum
+        total: 100
+  backends:
+    type: sequence
+    itemSchema:
+      type: map
+      additionalProperties:
+        type: any
+    seqValidators:
+      - name: sum
+        field: weight
+        op: "<="
+        total: 10
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	doc := "split: {a: 50, b: 49}\nbackends:\n  - weight: 6\n  - weight: 5\n"
+	if got := len(v.NewValidator(schema).ValidateBytes([]byte(doc)).Collector.Errors()); got != 2 {
+		t.Fatalf("expected 2 sum errors, got %d", got)
+	}
+}
+
+func TestLoadSchemaFiles_Overlay(t *testing.T) {
+	tmp := t.TempDir()
+	basePath := filepath.Join(tmp, "base.yaml")
+	overlayPath := filepath.Join(tmp, "prod.yaml")
+	if err := os.WriteFile(basePath, []byte(`
+type: map
+allowedKeys:
+  name: {type: string, required: true}
+  replicas: {type: int}
+`), 0o644); err != nil {
+		t.Fatalf("write base: %v", err)
+	}
+	if err := os.WriteFile(overlayPath, []byte(`
+allowedKeys:
+  replicas:
+    required: true
+    validators:
+      - name: range
+        min: 2
+`), 0o644); err != nil {
+		t.Fatalf("write overlay: %v", err)
+	}
+
+	schema, err := loadSchemaFiles([]string{basePath, overlayPath})
+	if err != nil {
+		t.Fatalf("load schemas: %v", err)
+	}
+	if got := len(v.NewValidator(schema).ValidateString("name: web\nreplicas: 1\n").Collector.Errors()); got != 1 {
+		t.Errorf("expected range error from overlay, got %d errors", got)
+	}
+	if got := len(v.NewValidator(schema).ValidateString("name: web\n").Collector.Errors()); got != 1 {
+		t.Errorf("expected missing replicas from overlay, got %d errors", got)
+	}
+}
+
+func TestLoadSchemaFromFile_Extends(t *testing.T) {
+	tmp := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+	write("common/base.yaml", `
+type: map
+allowedKeys:
+  name: {type: string, required: true}
+  replicas: {type: int}
+`)
+	write("common/labels.yaml", `
+allowedKeys:
+  labels: {type: map, additionalProperties: {type: string}}
+`)
+	appPath := write("app.yaml", `
+extends: [common/base.yaml, common/labels.yaml]
+allowedKeys:
+  replicas: {required: true}
+`)
+
+	schema, err := loadSchemaFromFile(appPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	validator := v.NewValidator(schema)
+	if errs := validator.ValidateString("name: web\nreplicas: 2\nlabels: {team: core}\n").Collector.Errors(); len(errs) != 0 {
+		t.Errorf("expected valid document, got %v", errs)
+	}
+	errs := validator.ValidateString("replicas: 2\nlabels: {team: 1}\nextra: x\n").Collector.All()
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Path+": "+e.Message)
+	}
+	sort.Strings(messages)
+	want := []string{`extra: unknown key "extra"`, "labels.team: type mismatch", `name: required field "name" is missing`}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings = %q, want %q", messages, want)
+	}
+
+	// A single path works too, and the extending file is checked as a whole.
+	single := write("single.yaml", "extends: common/base.yaml\nallowedKeys:\n  replicas: {default: many}\n")
+	if _, err := loadSchemaFromFile(single); err == nil || !strings.Contains(err.Error(), "invalid default") {
+		t.Errorf("expected invalid default error, got %v", err)
+	}
+
+	cyclePath := write("a.yaml", "extends: b.yaml\n")
+	write("b.yaml", "extends: a.yaml\n")
+	if _, err := loadSchemaFromFile(cyclePath); err == nil || !strings.Contains(err.Error(), "extends cycle") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+
+	nested := write("nested.yaml", "type: map\nallowedKeys:\n  x:\n    extends: common/base.yaml\n")
+	if _, err := loadSchemaFromFile(nested); err == nil || !strings.Contains(err.Error(), "only allowed at the schema root") {
+		t.Errorf("expected root-only error, got %v", err)
+	}
+}
+
+func TestLoadSchemaFromFile_EnumKeyValidator(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`
+type: map
+additionalProperties: {type: int}
+keyValidators:
+  - name: enum
+    allowed: [dev, prod]
+    caseInsensitive: true
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	result := v.NewValidator(schema).ValidateBytes([]byte("DEV: 1\nqa: 2\n"))
+	errs := result.Collector.Errors()
+	if len(errs) != 1 || errs[0].Got != "qa" {
+		t.Fatalf("expected only qa to be rejected, got %v", errs)
+	}
+
+	if err := os.WriteFile(schemaPath, []byte("keyValidators: [{name: enum}]\n"), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	if _, err := loadSchemaFromFile(schemaPath); err == nil || !strings.Contains(err.Error(), "allowed") {
+		t.Fatalf("expected error for empty allowed list, got %v", err)
+	}
+}
+
+func TestLoadSchemaFromFile_ConditionPattern(t *testing.T) {
+	tmp := t.TempDir()
+	schemaPath := filepath.Join(tmp, "schema.yaml")
+	err := os.WriteFile(schemaPath, []byte(`
+type: map
+additionalProperties: {type: string}
+conditions:
+  - conditionField: name
+    conditionPattern: '^tmp-'
+    thenRequired: [ttl]
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	schema, err := loadSchemaFromFile(schemaPath)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	errs := v.NewValidator(schema).ValidateBytes([]byte("name: tmp-build\n")).Collector.Errors()
+	if len(errs) != 1 || errs[0].Path != "ttl" {
+		t.Fatalf("expected ttl to be required, got %v", errs)
+	}
+
+	err = os.WriteFile(schemaPath, []byte(`
+conditions:
+  - conditionField: name
+    conditionValue: tmp
+    conditionPattern: '^tmp-'
+    thenRequired: [ttl]
+`), 0o644)
+	if err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	if _, err := loadSchemaFromFile(schemaPath); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected error for conditionValue with conditionPattern, got %v", err)
+	}
+}
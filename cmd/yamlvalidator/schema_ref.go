@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaCommentRe matches a leading comment naming the document's schema:
+// "# schema: path", "# $schema: path" or the yaml-language-server modeline
+// "# yaml-language-server: $schema=path".
+var schemaCommentRe = regexp.MustCompile(`^#\s*(?:\$?schema:\s*|yaml-language-server:\s*\$schema=)(\S+)`)
+
+// urlSchemeRe matches a reference with a URL scheme, e.g. "https://".
+var urlSchemeRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+
+// documentSchemaRef returns the schema a document points at, resolved against
+// dir unless it is absolute or a URL, or "" if it names none. A leading comment
+// (before any content) is checked first, then a top-level "$schema" key of the
+// first document.
+func documentSchemaRef(data []byte, dir string) string {
+	ref := schemaRefFromComment(data)
+	if ref == "" {
+		ref = schemaRefFromKey(data)
+	}
+	if ref == "" || filepath.IsAbs(ref) || urlSchemeRe.MatchString(ref) {
+		return ref
+	}
+	return filepath.Join(dir, ref)
+}
+
+func schemaRefFromComment(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line == "---" || strings.HasPrefix(line, "%"):
+			continue
+		case strings.HasPrefix(line, "#"):
+			if m := schemaCommentRe.FindStringSubmatch(line); m != nil {
+				return m[1]
+			}
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func schemaRefFromKey(data []byte) string {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil || len(doc.Content) == 0 {
+		return ""
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "$schema" && root.Content[i+1].Kind == yaml.ScalarNode {
+			return root.Content[i+1].Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+)
+
+func TestDocumentSchemaRef(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmp, "schemas"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	schemaPath := filepath.Join(tmp, "schemas", "app.yaml")
+	if err := os.WriteFile(schemaPath, []byte(`
+type: map
+allowedKeys:
+  $schema: {type: string}
+  replicas: {type: int, required: true}
+`), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	doc := []byte("$schema: schemas/app.yaml\nreplicas: many\n")
+	ref := documentSchemaRef(doc, tmp)
+	if ref != schemaPath {
+		t.Fatalf("documentSchemaRef = %q, want %q", ref, schemaPath)
+	}
+	schema, err := loadSchemaFiles([]string{ref})
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	errs := v.NewValidator(schema).ValidateBytes(doc).Collector.Errors()
+	if len(errs) != 1 || errs[0].Path != "replicas" {
+		t.Errorf("expected replicas type error, got %v", errs)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"comment", "# schema: schemas/app.yaml\nreplicas: 1\n", schemaPath},
+		{"dollar comment", "---\n# $schema: schemas/app.yaml\nreplicas: 1\n", schemaPath},
+		{"language server modeline", "# yaml-language-server: $schema=schemas/app.yaml\nreplicas: 1\n", schemaPath},
+		{"absolute path", "$schema: /etc/app.yaml\n", "/etc/app.yaml"},
+		{"URL modeline", "# yaml-language-server: $schema=https://example.com/app.json\n", "https://example.com/app.json"},
+		{"comment after content", "replicas: 1\n# schema: schemas/app.yaml\n", ""},
+		{"nested key", "spec:\n  $schema: schemas/app.yaml\n", ""},
+		{"none", "replicas: 1\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := documentSchemaRef([]byte(tt.doc), tmp); got != tt.want {
+				t.Errorf("documentSchemaRef = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package yamlvalidator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Explain Report
+// ============================================================================
+
+// FieldStatus tells whether a schema field was found in a document.
+type FieldStatus string
+
+const (
+	// FieldPresent means the key is set in the document (directly or via a merge key).
+	FieldPresent FieldStatus = "present"
+	// FieldDefaulted means the key is missing and the schema provides a Default.
+	FieldDefaulted FieldStatus = "defaulted"
+	// FieldAbsent means the key is missing and has no Default.
+	FieldAbsent FieldStatus = "absent"
+)
+
+// ExplainField describes one AllowedKeys entry of the schema.
+type ExplainField struct {
+	Name     string
+	Path     string
+	Status   FieldStatus
+	Type     NodeType    // Inferred from the value when present, otherwise the schema Type
+	Required bool        // Copied from the schema
+	Default  interface{} // Copied from the schema
+	Line     int         // Position of the value (0 unless present)
+	Column   int
+	Fields   []*ExplainField // Nested AllowedKeys of mapping fields
+}
+
+// ExplainDocument lists the top-level schema fields of one document.
+type ExplainDocument struct {
+	Index  int
+	Fields []*ExplainField
+}
+
+// ExplainReport is the result of Validator.Explain.
+type ExplainReport struct {
+	Documents []ExplainDocument
+}
+
+// Explain reports, for every AllowedKeys entry of the schema (recursively),
+// whether it is present, defaulted or absent in each document, along with the
+// resolved type. Documents without a schema (see WithSchemaSelector) have no
+// fields. It does not validate; an error is returned only when the YAML cannot
+// be decoded.
+func (v *Validator) Explain(data []byte) (*ExplainReport, error) {
+	ctx := NewValidationContext()
+	report := &ExplainReport{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for docIndex := 0; ; docIndex++ {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("document %d: %w", docIndex, err)
+		}
+
+		var root *yaml.Node
+		schema := v.schema
+		if len(doc.Content) > 0 {
+			root = doc.Content[0]
+			schema = v.documentSchema(root)
+		}
+		path := ""
+		if docIndex > 0 {
+			path = fmt.Sprintf("doc[%d]", docIndex)
+		}
+		report.Documents = append(report.Documents, ExplainDocument{
+			Index:  docIndex,
+			Fields: v.explainFields(root, schema, path, ctx, map[*FieldSchema]bool{}),
+		})
+	}
+	return report, nil
+}
+
+func (v *Validator) explainFields(node *yaml.Node, schema *FieldSchema, path string,
+	ctx *ValidationContext, seen map[*FieldSchema]bool) []*ExplainField {
+
+	if schema == nil || len(schema.AllowedKeys) == 0 || seen[schema] {
+		return nil
+	}
+	seen[schema] = true
+	defer delete(seen, schema)
+
+	if node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	values := make(map[string]*yaml.Node)
+	if node != nil {
+		for _, kv := range expandMappingWithMerges(node) {
+			values[kv.key.Value] = kv.value
+		}
+	}
+
+	keys := make([]string, 0, len(schema.AllowedKeys))
+	for key := range schema.AllowedKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]*ExplainField, 0, len(keys))
+	for _, key := range keys {
+		fieldSchema := schema.AllowedKeys[key]
+		field := &ExplainField{
+			Name:     key,
+			Path:     joinPath(path, key),
+			Status:   FieldAbsent,
+			Type:     fieldSchema.Type,
+			Required: fieldSchema.Required,
+			Default:  fieldSchema.Default,
+		}
+		value := values[key]
+		for _, alias := range fieldSchema.Aliases {
+			if value != nil {
+				break
+			}
+			value = values[alias]
+		}
+		switch {
+		case value != nil:
+			field.Status = FieldPresent
+			field.Type = v.inferType(value, ctx)
+			field.Line = value.Line
+			field.Column = value.Column
+		case fieldSchema.Default != nil:
+			field.Status = FieldDefaulted
+		}
+		field.Fields = v.explainFields(value, fieldSchema, field.Path, ctx, seen)
+		fields = append(fields, field)
+	}
+	return fields
+}
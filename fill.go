@@ -0,0 +1,178 @@
+package yamlvalidator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Default Filling
+// ============================================================================
+
+// ValidateAndFill validates data like ValidateWithOptions and returns the
+// documents with the Default of every absent optional field filled in. Defaults
+// may be scalars, lists or maps; an absent mapping field without a Default of
+// its own is created when some of its fields have defaults.
+//
+// Fields are filled in mappings that are present, reached through AllowedKeys,
+// AdditionalProperties and ItemSchema; defaults in a ConditionalRule.ThenSchema
+// are not filled. Values behind aliases and merge keys are left alone, since
+// changing them would change every place that uses the anchor.
+// The output is re-encoded by yaml.v3 with 2-space indentation; comments are
+// kept. The validation result is for the original data. An error is returned,
+// with a nil output, when the YAML cannot be decoded or a default cannot be encoded.
+func (v *Validator) ValidateAndFill(data []byte, opts ValidationContext) (*ValidationResult, []byte, error) {
+	result := v.ValidateWithOptions(data, opts)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for docIndex := 0; ; docIndex++ {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return result, nil, fmt.Errorf("document %d: %w", docIndex, err)
+		}
+		if len(doc.Content) > 0 {
+			root := doc.Content[0]
+			if err := fillDefaults(root, v.documentSchema(root), &opts, map[*FieldSchema]bool{}); err != nil {
+				return result, nil, fmt.Errorf("document %d: %w", docIndex, err)
+			}
+		}
+		if err := encoder.Encode(&doc); err != nil {
+			return result, nil, fmt.Errorf("document %d: %w", docIndex, err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return result, nil, err
+	}
+	return result, buf.Bytes(), nil
+}
+
+// fillDefaults adds the defaults of absent fields to node and its descendants.
+// ctx supplies the type inference options (CustomTags) key matching depends on.
+func fillDefaults(node *yaml.Node, schema *FieldSchema, ctx *ValidationContext, seen map[*FieldSchema]bool) error {
+	if schema == nil || node.Kind == yaml.AliasNode {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		if schema.ItemSchema == nil {
+			return nil
+		}
+		for _, item := range node.Content {
+			if err := fillDefaults(item, schema.ItemSchema, ctx, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.MappingNode:
+	default:
+		return nil
+	}
+
+	present := make(map[string]bool)
+	for _, kv := range expandMappingWithMerges(node) {
+		key, fieldSchema := lookupAllowedKey(schema, kv.key, ctx)
+		present[key] = true
+		if fieldSchema == nil {
+			fieldSchema = schema.AdditionalProperties
+		}
+		if kv.merged || fieldSchema == nil {
+			continue
+		}
+		if err := fillDefaults(kv.value, fieldSchema, ctx, seen); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(schema.AllowedKeys))
+	for key := range schema.AllowedKeys {
+		if !present[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fieldSchema := schema.AllowedKeys[key]
+		if fieldSchema.Required {
+			continue
+		}
+		value, err := defaultNode(fieldSchema, ctx, seen)
+		if err != nil {
+			return fmt.Errorf("default of %q: %w", key, err)
+		}
+		if value == nil {
+			continue
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		node.Content = append(node.Content, keyNode, value)
+	}
+	return nil
+}
+
+// defaultNode returns the value to fill in for an absent field: its Default,
+// or for a mapping field a mapping of its children's defaults. It returns nil
+// when there is nothing to fill in.
+func defaultNode(schema *FieldSchema, ctx *ValidationContext, seen map[*FieldSchema]bool) (*yaml.Node, error) {
+	if schema.Default != nil {
+		node := &yaml.Node{}
+		if err := node.Encode(schema.Default); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	if schema.Type != TypeMap || len(schema.AllowedKeys) == 0 || seen[schema] {
+		return nil, nil
+	}
+	seen[schema] = true
+	defer delete(seen, schema)
+
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if err := fillDefaults(node, schema, ctx, seen); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+	return node, nil
+}
+
+// lookupAllowedKey resolves a document key to its AllowedKeys name, honouring
+// Aliases, NumericKeys (for keys that are integers, as in validation) and
+// CaseInsensitiveKeys. The schema is nil for keys not in AllowedKeys.
+func lookupAllowedKey(schema *FieldSchema, keyNode *yaml.Node, ctx *ValidationContext) (string, *FieldSchema) {
+	key := keyNode.Value
+	if fieldSchema, ok := schema.AllowedKeys[key]; ok {
+		return key, fieldSchema
+	}
+	for allowed, fieldSchema := range schema.AllowedKeys {
+		for _, alias := range fieldSchema.Aliases {
+			if alias == key {
+				return allowed, fieldSchema
+			}
+		}
+	}
+	if schema.NumericKeys && ctx.InferType(keyNode) == TypeInt {
+		if allowed, ok := numericAllowedKey(schema, key); ok {
+			return allowed, schema.AllowedKeys[allowed]
+		}
+	}
+	if schema.CaseInsensitiveKeys {
+		for allowed, fieldSchema := range schema.AllowedKeys {
+			if strings.EqualFold(allowed, key) {
+				return allowed, fieldSchema
+			}
+		}
+	}
+	return key, nil
+}
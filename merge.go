@@ -0,0 +1,208 @@
+package yamlvalidator
+
+import "fmt"
+
+// ============================================================================
+// Schema Overlays
+// ============================================================================
+
+// MergeSchemas deep-merges an overlay into a base schema, e.g. an environment
+// overlay that requires more fields in production than the base schema does.
+// Neither input is modified; the result shares validators and unchanged
+// sub-schemas with them.
+//
+// Merge rules:
+//   - AllowedKeys, AdditionalProperties, AdditionalPropertiesByType and
+//     ItemSchema are merged recursively; keys only in one schema are kept.
+//   - Type: a TypeAny side takes the other's type; two different types are a conflict.
+//   - Flags (Required, Nullable, NonEmpty, CaseInsensitiveKeys, NumericKeys,
+//     SkipValueOnKeyError, ItemsNonNull) are set if set in either schema, so an
+//     overlay can turn them on but not off.
+//   - Other scalars (Description, Deprecated, DeprecatedInfo, Stability, Default,
+//     UnknownKeyPolicy, AdditionalPropertiesLevel, RequireStyle, RequireStyleLevel)
+//     are taken from the overlay
+//     when it sets them.
+//   - MinItems/MaxItems and MaxNesting keep the tighter bound; an empty range
+//     is a conflict.
+//   - Constraint lists (Validators, KeyValidators, MapValidators, SeqValidators,
+//     Conditions, AnyOf, AtLeastNOf, AtMostNOf, ExactlyOneGroupOf, AllOrNone)
+//     and Aliases are unioned, so both schemas' rules apply.
+//   - ExactlyOneOf, MutuallyExclusive and UniqueFields form a single group and
+//     are replaced by the overlay's when it sets them.
+//
+// Conflicts are returned as errors naming the schema path, using the notation
+// of ValidateSchema.
+func MergeSchemas(base, overlay *FieldSchema) (*FieldSchema, error) {
+	m := schemaMerger{done: make(map[[2]*FieldSchema]*FieldSchema)}
+	return m.merge(base, overlay, "")
+}
+
+type schemaMerger struct {
+	done map[[2]*FieldSchema]*FieldSchema // merged pairs, for recursive schemas
+}
+
+func (m *schemaMerger) merge(base, overlay *FieldSchema, path string) (*FieldSchema, error) {
+	if base == nil {
+		return overlay, nil
+	}
+	if overlay == nil {
+		return base, nil
+	}
+	pair := [2]*FieldSchema{base, overlay}
+	if merged, ok := m.done[pair]; ok {
+		return merged, nil
+	}
+
+	merged := *base
+	m.done[pair] = &merged
+
+	switch {
+	case overlay.Type == TypeAny || overlay.Type == base.Type:
+	case base.Type == TypeAny:
+		merged.Type = overlay.Type
+	default:
+		return nil, fmt.Errorf("%s: overlay type %s conflicts with base type %s", displaySchemaPath(path), overlay.Type, base.Type)
+	}
+
+	merged.Required = base.Required || overlay.Required
+	merged.Nullable = base.Nullable || overlay.Nullable
+	merged.NonEmpty = base.NonEmpty || overlay.NonEmpty
+	merged.CaseInsensitiveKeys = base.CaseInsensitiveKeys || overlay.CaseInsensitiveKeys
+	merged.NumericKeys = base.NumericKeys || overlay.NumericKeys
+	merged.SkipValueOnKeyError = base.SkipValueOnKeyError || overlay.SkipValueOnKeyError
+	merged.ItemsNonNull = base.ItemsNonNull || overlay.ItemsNonNull
+
+	if overlay.Description != "" {
+		merged.Description = overlay.Description
+	}
+	if overlay.Deprecated != "" {
+		merged.Deprecated = overlay.Deprecated
+	}
+	if overlay.DeprecatedInfo != nil {
+		merged.DeprecatedInfo = overlay.DeprecatedInfo
+	}
+	if overlay.Stability != StabilityStable {
+		merged.Stability = overlay.Stability
+	}
+	if overlay.Default != nil {
+		merged.Default = overlay.Default
+	}
+	if overlay.UnknownKeyPolicy != UnknownKeyInherit {
+		merged.UnknownKeyPolicy = overlay.UnknownKeyPolicy
+	}
+	if overlay.AdditionalPropertiesLevel != nil {
+		merged.AdditionalPropertiesLevel = overlay.AdditionalPropertiesLevel
+	}
+	if overlay.RequireStyle != StyleAny {
+		merged.RequireStyle = overlay.RequireStyle
+	}
+	if overlay.RequireStyleLevel != nil {
+		merged.RequireStyleLevel = overlay.RequireStyleLevel
+	}
+
+	merged.MinItems = tighterBound(base.MinItems, overlay.MinItems, func(a, b int) bool { return a > b })
+	merged.MaxItems = tighterBound(base.MaxItems, overlay.MaxItems, func(a, b int) bool { return a < b })
+	merged.MaxNesting = tighterBound(base.MaxNesting, overlay.MaxNesting, func(a, b int) bool { return a < b })
+	if merged.MinItems != nil && merged.MaxItems != nil && *merged.MinItems > *merged.MaxItems {
+		return nil, fmt.Errorf("%s: merged minItems %d exceeds maxItems %d", displaySchemaPath(path), *merged.MinItems, *merged.MaxItems)
+	}
+
+	merged.Aliases = unionStrings(base.Aliases, overlay.Aliases)
+	merged.Validators = appendCopy(base.Validators, overlay.Validators)
+	merged.KeyValidators = appendCopy(base.KeyValidators, overlay.KeyValidators)
+	merged.MapValidators = appendCopy(base.MapValidators, overlay.MapValidators)
+	merged.SeqValidators = appendCopy(base.SeqValidators, overlay.SeqValidators)
+	merged.Conditions = appendCopy(base.Conditions, overlay.Conditions)
+	merged.AnyOf = appendCopy(base.AnyOf, overlay.AnyOf)
+	merged.AtLeastNOf = appendCopy(base.AtLeastNOf, overlay.AtLeastNOf)
+	merged.AtMostNOf = appendCopy(base.AtMostNOf, overlay.AtMostNOf)
+	merged.ExactlyOneGroupOf = appendCopy(base.ExactlyOneGroupOf, overlay.ExactlyOneGroupOf)
+	merged.AllOrNone = appendCopy(base.AllOrNone, overlay.AllOrNone)
+	if len(overlay.ExactlyOneOf) > 0 {
+		merged.ExactlyOneOf = overlay.ExactlyOneOf
+	}
+	if len(overlay.MutuallyExclusive) > 0 {
+		merged.MutuallyExclusive = overlay.MutuallyExclusive
+	}
+	if len(overlay.UniqueFields) > 0 {
+		merged.UniqueFields = overlay.UniqueFields
+	}
+
+	var err error
+	if len(overlay.AllowedKeys) > 0 {
+		merged.AllowedKeys = make(map[string]*FieldSchema, len(base.AllowedKeys)+len(overlay.AllowedKeys))
+		for key, child := range base.AllowedKeys {
+			merged.AllowedKeys[key] = child
+		}
+		for key, child := range overlay.AllowedKeys {
+			if merged.AllowedKeys[key], err = m.merge(base.AllowedKeys[key], child, joinPath(path, key)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if merged.AdditionalProperties, err = m.merge(base.AdditionalProperties, overlay.AdditionalProperties, joinSelector(path, "*")); err != nil {
+		return nil, err
+	}
+	if len(overlay.AdditionalPropertiesByType) > 0 {
+		merged.AdditionalPropertiesByType = make(map[NodeType]*FieldSchema, len(base.AdditionalPropertiesByType)+len(overlay.AdditionalPropertiesByType))
+		for t, child := range base.AdditionalPropertiesByType {
+			merged.AdditionalPropertiesByType[t] = child
+		}
+		for t, child := range overlay.AdditionalPropertiesByType {
+			childPath := joinPath(path, "*("+t.String()+")")
+			if merged.AdditionalPropertiesByType[t], err = m.merge(base.AdditionalPropertiesByType[t], child, childPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if merged.ItemSchema, err = m.merge(base.ItemSchema, overlay.ItemSchema, path+"[]"); err != nil {
+		return nil, err
+	}
+
+	return &merged, nil
+}
+
+// tighterBound returns whichever bound is set, or the tighter one when both are.
+func tighterBound(base, overlay *int, tighter func(a, b int) bool) *int {
+	if base == nil {
+		return overlay
+	}
+	if overlay == nil || tighter(*base, *overlay) {
+		return base
+	}
+	return overlay
+}
+
+// appendCopy concatenates two slices without aliasing the first one's array.
+func appendCopy[T any](base, overlay []T) []T {
+	if len(overlay) == 0 {
+		return base
+	}
+	if len(base) == 0 {
+		return overlay
+	}
+	out := make([]T, 0, len(base)+len(overlay))
+	return append(append(out, base...), overlay...)
+}
+
+func unionStrings(base, overlay []string) []string {
+	seen := make(map[string]bool, len(base))
+	for _, s := range base {
+		seen[s] = true
+	}
+	var extra []string
+	for _, s := range overlay {
+		if !seen[s] {
+			seen[s] = true
+			extra = append(extra, s)
+		}
+	}
+	return appendCopy(base, extra)
+}
+
+func displaySchemaPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
@@ -0,0 +1,116 @@
+package yamlvalidator
+
+import "strings"
+
+// ============================================================================
+// Message Localization
+// ============================================================================
+
+// Message codes of built-in findings, the keys of ValidationContext.Messages.
+// Each lists the placeholders its override may use; every override may also
+// use {path}.
+const (
+	MsgTypeMismatch      = "type_mismatch"        // {got}, {expected}; also OneOfTypeValidator
+	MsgUnexpectedNull    = "unexpected_null"      // {expected}
+	MsgEmptyValue        = "empty_value"          // {expected}; also NonEmptyValidator
+	MsgRequiredMissing   = "required_missing"     // {key}; also RequiredKeysValidator, SumValidator
+	MsgUnknownKey        = "unknown_key"          // {key}; also EnumKeyValidator
+	MsgDuplicateKey      = "duplicate_key"        // {key}, {line} (of the first definition)
+	MsgMutuallyExclusive = "mutually_exclusive"   // {fields}
+	MsgRequiredWhen      = "required_when"        // {key}, {field}, {value}; also RequireWhenValueValidator
+	MsgForbiddenWhen     = "forbidden_when"       // {key}, {field}, {value}
+	MsgTooFewItems       = "too_few_items"        // {got}, {min}
+	MsgTooManyItems      = "too_many_items"       // {got}, {max}
+	MsgTooFewDocuments   = "too_few_documents"    // {got}, {min}
+	MsgTooManyDocuments  = "too_many_documents"   // {got}, {max}
+	MsgNullItem          = "null_item"            // no placeholders
+	MsgUnresolvedAlias   = "unresolved_alias"     // no placeholders
+	MsgDocumentEmpty     = "document_empty"       // {expected}, empty without a schema type
+	MsgInvalidEnumValue  = "invalid_enum_value"   // {value}; EnumValidator
+	MsgPatternMismatch   = "pattern_mismatch"     // {value}, {pattern}; RegexValidator
+	MsgNotNumeric        = "not_numeric"          // {value}; RangeValidator, IntRangeValidator, IntWidthValidator and the sequence validators
+	MsgValueBelowMinimum = "value_below_minimum"  // {value}, {min}; RangeValidator, IntRangeValidator
+	MsgValueAboveMaximum = "value_above_maximum"  // {value}, {max}; RangeValidator, IntRangeValidator
+	MsgLengthBelowMin    = "length_below_minimum" // {got}, {min}; LengthValidator
+	MsgLengthAboveMax    = "length_above_maximum" // {got}, {max}; LengthValidator
+)
+
+// Message codes of the other validators in pkg/valuevalidator.
+const (
+	MsgURLSchemeMissing    = "url_scheme_missing"     // {value}; URLValidator
+	MsgURLSchemeNotAllowed = "url_scheme_not_allowed" // {value}, {scheme}; URLValidator
+	MsgInvalidHexColor     = "invalid_hex_color"      // {value}; HexColorValidator
+	MsgPathEmpty           = "path_empty"             // no placeholders; FilePathValidator
+	MsgPathAbsolute        = "path_absolute"          // {value}; FilePathValidator
+	MsgPathRelative        = "path_relative"          // {value}; FilePathValidator
+	MsgPathNotExist        = "path_not_exist"         // {value}; FilePathValidator
+	MsgPathStatFailed      = "path_stat_failed"       // {value}, {error}; FilePathValidator
+	MsgPathNotFile         = "path_not_file"          // {value}; FilePathValidator
+	MsgPathNotDir          = "path_not_dir"           // {value}; FilePathValidator
+	MsgLineBreak           = "line_break"             // {value}, {offset}; SingleLineValidator
+	MsgCharNotAllowed      = "char_not_allowed"       // {value}, {char}, {offset}; CharsetValidator
+	MsgNonASCII            = "non_ascii"              // {value}, {char}, {offset}, {charset}; ASCIIValidator
+	MsgInvalidTemplate     = "invalid_template"       // {value}, {error}; GoTemplateValidator
+	MsgTemplateNoActions   = "template_no_actions"    // {value}; GoTemplateValidator with RequireActions
+	MsgTemplateHasActions  = "template_has_actions"   // {value}; GoTemplateValidator with ForbidActions
+	MsgInvalidGlob         = "invalid_glob"           // {value}; GlobValidator
+	MsgInvalidJSONPointer  = "invalid_json_pointer"   // {value}, {error}; JSONPointerValidator
+	MsgInvalidSemVerRange  = "invalid_semver_range"   // {value}, {error}; SemVerRangeValidator
+	MsgInvalidLanguageTag  = "invalid_language_tag"   // {value}, {error}; LanguageTagValidator
+	MsgIntOutOfWidth       = "int_out_of_width"       // {value}, {type}, {min}, {max}; IntWidthValidator
+	MsgValueDenied         = "value_denied"           // {value}; NotInValidator
+	MsgNotAKey             = "not_a_key"              // {value}, {source}; EnumFromPathValidator
+	MsgNoAlternative       = "no_alternative"         // {value}, {reasons}; AnyOfValidator
+	MsgWidthBelowMin       = "width_below_minimum"    // {got}, {min}; DisplayWidthValidator
+	MsgWidthAboveMax       = "width_above_maximum"    // {got}, {max}; DisplayWidthValidator
+	MsgPercentSuffix       = "percent_suffix"         // {value}; PercentValidator
+	MsgNotPercentage       = "not_percentage"         // {value}; PercentValidator
+	MsgPercentOutOfRange   = "percent_out_of_range"   // {value}, {max}; PercentValidator
+	MsgNotDecimal          = "not_decimal"            // {value}, {error}; PrecisionValidator
+	MsgTooManyDecimals     = "too_many_decimals"      // {value}, {got}, {max}; PrecisionValidator
+)
+
+// Message codes of the validators in pkg/keyvalidator.
+const (
+	MsgKeyForbidden       = "key_forbidden"        // {key}, {pattern} (empty for Keys); ForbiddenKeyValidator
+	MsgKeyTooShort        = "key_too_short"        // {key}, {got}, {min}; LengthKeyValidator
+	MsgKeyTooLong         = "key_too_long"         // {key}, {got}, {max}; LengthKeyValidator
+	MsgKeyPatternMismatch = "key_pattern_mismatch" // {key}, {pattern}; RegexKeyValidator
+)
+
+// Message codes of the validators in pkg/mapvalidator.
+const (
+	MsgKeyOutOfOrder     = "key_out_of_order"    // {key}, {previous}; AlphabeticalKeysValidator
+	MsgRequiredWithField = "required_with_field" // {key}, {field}; DependentRequiredValidator
+	MsgFieldComparison   = "field_comparison"    // {left}, {op}, {right}; FieldComparisonValidator
+	MsgFieldsEqual       = "fields_equal"        // {left}, {right}; FieldsDifferValidator
+	MsgEmptyWhen         = "empty_when"          // {key}, {field}, {value}; RequireWhenValueValidator
+)
+
+// Message codes of the validators in pkg/seqvalidator.
+const (
+	MsgNotMonotonic   = "not_monotonic"    // {direction}, {value}, {previous}; MonotonicValidator
+	MsgNonScalarItem  = "non_scalar_item"  // no placeholders; SortedValidator
+	MsgNotSorted      = "not_sorted"       // {order}, {value}, {previous}; SortedValidator
+	MsgSumMismatch    = "sum_mismatch"     // {got}, {expected}; SumValidator
+	MsgItemNotMapping = "item_not_mapping" // {field}; SumValidator
+)
+
+// Message returns the text of a finding with the given code: the override in
+// ctx.Messages with its {name} placeholders filled in from params, which
+// alternates names and values, or def when Messages has no entry for code.
+// Unknown placeholders are left as they are.
+func (ctx *ValidationContext) Message(code, def string, params ...string) string {
+	msg, ok := ctx.Messages[code]
+	if !ok {
+		return def
+	}
+	if !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := make([]string, 0, len(params))
+	for i := 0; i+1 < len(params); i += 2 {
+		pairs = append(pairs, "{"+params[i]+"}", params[i+1])
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
@@ -0,0 +1,40 @@
+package keyvalidator
+
+import (
+	"fmt"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// EnumKeyValidator validates that every key is one of a fixed set of names,
+// for free-form maps where AllowedKeys cannot be used (e.g. the keys are
+// validated but their values share one AdditionalProperties schema).
+type EnumKeyValidator struct {
+	Allowed         []string
+	CaseInsensitive bool   // Compare keys ignoring case (Unicode case folding)
+	Message         string // Custom error message (optional)
+}
+
+// ValidateKey implements KeyValidator.
+func (vld EnumKeyValidator) ValidateKey(key string, keyNode *yaml.Node, path string, ctx *v.ValidationContext) {
+	for _, allowed := range vld.Allowed {
+		if key == allowed || (vld.CaseInsensitive && strings.EqualFold(key, allowed)) {
+			return
+		}
+	}
+	msg := vld.Message
+	if msg == "" {
+		msg = ctx.Message(v.MsgUnknownKey, fmt.Sprintf("key %q is not allowed", key), "path", path, "key", key)
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     keyNode.Line,
+		Column:   keyNode.Column,
+		Message:  msg,
+		Got:      key,
+		Expected: fmt.Sprintf("one of %v", vld.Allowed),
+	})
+}
@@ -2,14 +2,17 @@ package keyvalidator
 
 import (
 	"fmt"
+	"regexp"
 
 	v "github.com/yakwilikk/go-yamlvalidator"
 	"gopkg.in/yaml.v3"
 )
 
 // ForbiddenKeyValidator validates that certain key names are not used.
+// A key is rejected if it equals any entry in Forbidden or matches any of Patterns.
 type ForbiddenKeyValidator struct {
 	Forbidden []string
+	Patterns  []*regexp.Regexp
 	Message   string // Custom error message (optional)
 }
 
@@ -17,19 +20,31 @@ type ForbiddenKeyValidator struct {
 func (vld ForbiddenKeyValidator) ValidateKey(key string, keyNode *yaml.Node, path string, ctx *v.ValidationContext) {
 	for _, forbidden := range vld.Forbidden {
 		if key == forbidden {
-			msg := vld.Message
-			if msg == "" {
-				msg = fmt.Sprintf("key %q is forbidden", key)
-			}
-			ctx.AddError(v.ValidationError{
-				Level:   v.LevelError,
-				Path:    path,
-				Line:    keyNode.Line,
-				Column:  keyNode.Column,
-				Message: msg,
-				Got:     key,
-			})
+			vld.report(key, keyNode, path, ctx,
+				ctx.Message(v.MsgKeyForbidden, fmt.Sprintf("key %q is forbidden", key), "path", path, "key", key, "pattern", ""))
 			return
 		}
 	}
+	for _, re := range vld.Patterns {
+		if re.MatchString(key) {
+			vld.report(key, keyNode, path, ctx,
+				ctx.Message(v.MsgKeyForbidden, fmt.Sprintf("key %q is forbidden (matches %s)", key, re.String()), "path", path, "key", key, "pattern", re.String()))
+			return
+		}
+	}
+}
+
+func (vld ForbiddenKeyValidator) report(key string, keyNode *yaml.Node, path string, ctx *v.ValidationContext, defaultMsg string) {
+	msg := vld.Message
+	if msg == "" {
+		msg = defaultMsg
+	}
+	ctx.AddError(v.ValidationError{
+		Level:   v.LevelError,
+		Path:    path,
+		Line:    keyNode.Line,
+		Column:  keyNode.Column,
+		Message: msg,
+		Got:     key,
+	})
 }
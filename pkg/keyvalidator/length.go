@@ -2,21 +2,38 @@ package keyvalidator
 
 import (
 	"fmt"
+	"strconv"
 	"unicode/utf8"
 
 	v "github.com/yakwilikk/go-yamlvalidator"
 	"gopkg.in/yaml.v3"
 )
 
+// LengthUnit selects how key length is measured.
+type LengthUnit int
+
+const (
+	// Runes counts Unicode code points (default).
+	Runes LengthUnit = iota
+	// Bytes counts UTF-8 bytes, for systems that cap key size in bytes.
+	Bytes
+)
+
 // LengthKeyValidator validates key name length.
 type LengthKeyValidator struct {
-	Min *int
-	Max *int
+	Min  *int
+	Max  *int
+	Unit LengthUnit // Runes (default) or Bytes
 }
 
 // ValidateKey implements KeyValidator.
 func (vld LengthKeyValidator) ValidateKey(key string, keyNode *yaml.Node, path string, ctx *v.ValidationContext) {
 	length := utf8.RuneCountInString(key)
+	unit := "characters"
+	if vld.Unit == Bytes {
+		length = len(key)
+		unit = "bytes"
+	}
 
 	if vld.Min != nil && length < *vld.Min {
 		ctx.AddError(v.ValidationError{
@@ -24,9 +41,9 @@ func (vld LengthKeyValidator) ValidateKey(key string, keyNode *yaml.Node, path s
 			Path:     path,
 			Line:     keyNode.Line,
 			Column:   keyNode.Column,
-			Message:  "key too short",
-			Got:      fmt.Sprintf("%d characters", length),
-			Expected: fmt.Sprintf(">= %d characters", *vld.Min),
+			Message:  ctx.Message(v.MsgKeyTooShort, "key too short", "path", path, "key", key, "got", strconv.Itoa(length), "min", strconv.Itoa(*vld.Min)),
+			Got:      fmt.Sprintf("%d %s", length, unit),
+			Expected: fmt.Sprintf(">= %d %s", *vld.Min, unit),
 		})
 	}
 
@@ -36,9 +53,9 @@ func (vld LengthKeyValidator) ValidateKey(key string, keyNode *yaml.Node, path s
 			Path:     path,
 			Line:     keyNode.Line,
 			Column:   keyNode.Column,
-			Message:  "key too long",
-			Got:      fmt.Sprintf("%d characters", length),
-			Expected: fmt.Sprintf("<= %d characters", *vld.Max),
+			Message:  ctx.Message(v.MsgKeyTooLong, "key too long", "path", path, "key", key, "got", strconv.Itoa(length), "max", strconv.Itoa(*vld.Max)),
+			Got:      fmt.Sprintf("%d %s", length, unit),
+			Expected: fmt.Sprintf("<= %d %s", *vld.Max, unit),
 		})
 	}
 }
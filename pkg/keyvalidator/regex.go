@@ -21,7 +21,7 @@ func (vld RegexKeyValidator) ValidateKey(key string, keyNode *yaml.Node, path st
 	}
 	msg := vld.Message
 	if msg == "" {
-		msg = fmt.Sprintf("key does not match pattern %s", vld.Pattern.String())
+		msg = ctx.Message(v.MsgKeyPatternMismatch, fmt.Sprintf("key does not match pattern %s", vld.Pattern.String()), "path", path, "key", key, "pattern", vld.Pattern.String())
 	}
 	ctx.AddError(v.ValidationError{
 		Level:   v.LevelError,
@@ -0,0 +1,54 @@
+package mapvalidator
+
+import (
+	"fmt"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// AlphabeticalKeysValidator validates that the keys of a mapping are written in
+// ascending order, as some teams require for review hygiene. Keys are compared
+// byte by byte, independent of locale (so "Z" sorts before "a" unless
+// CaseInsensitive is set). Merge keys (<<) are ignored. Only the first
+// out-of-order key is reported.
+type AlphabeticalKeysValidator struct {
+	CaseInsensitive bool // Compare keys by their lower-case form
+}
+
+// ValidateMap implements MapValidator.
+func (vld AlphabeticalKeysValidator) ValidateMap(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	var prev *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Value == "<<" {
+			continue
+		}
+		if prev != nil && vld.sortKey(keyNode.Value) < vld.sortKey(prev.Value) {
+			keyPath := joinPath(path, keyNode.Value)
+			msg := ctx.Message(v.MsgKeyOutOfOrder, fmt.Sprintf("key %q is out of order, it should come before %q", keyNode.Value, prev.Value),
+				"path", keyPath, "key", keyNode.Value, "previous", prev.Value)
+			ctx.AddError(v.ValidationError{
+				Level:    v.LevelError,
+				Path:     keyPath,
+				Line:     keyNode.Line,
+				Column:   keyNode.Column,
+				Message:  msg,
+				Expected: "keys in ascending order",
+			})
+			return
+		}
+		prev = keyNode
+	}
+}
+
+func (vld AlphabeticalKeysValidator) sortKey(key string) string {
+	if vld.CaseInsensitive {
+		return strings.ToLower(key)
+	}
+	return key
+}
@@ -0,0 +1,52 @@
+package mapvalidator
+
+import (
+	"fmt"
+	"sort"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// DependentRequiredValidator requires additional keys whenever a key is present.
+// Example: map[string][]string{"tls": {"cert", "key"}}
+// Means: if tls is set, cert and key must be set too.
+type DependentRequiredValidator struct {
+	Dependencies map[string][]string
+}
+
+// ValidateMap implements MapValidator.
+func (vld DependentRequiredValidator) ValidateMap(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	// Stable output order
+	triggers := make([]string, 0, len(vld.Dependencies))
+	for k := range vld.Dependencies {
+		triggers = append(triggers, k)
+	}
+	sort.Strings(triggers)
+
+	for _, trigger := range triggers {
+		triggerKey, _ := v.MappingLookup(node, trigger)
+		if triggerKey == nil {
+			continue
+		}
+		for _, dep := range vld.Dependencies[trigger] {
+			if k, _ := v.MappingLookup(node, dep); k != nil {
+				continue
+			}
+			depPath := joinPath(path, dep)
+			msg := ctx.Message(v.MsgRequiredWithField, fmt.Sprintf("field %q is required when %q is present", dep, trigger),
+				"path", depPath, "key", dep, "field", trigger)
+			ctx.AddError(v.ValidationError{
+				Level:   v.LevelError,
+				Path:    depPath,
+				Line:    triggerKey.Line,
+				Column:  triggerKey.Column,
+				Message: msg,
+			})
+		}
+	}
+}
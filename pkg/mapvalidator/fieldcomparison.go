@@ -0,0 +1,95 @@
+package mapvalidator
+
+import (
+	"fmt"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	valv "github.com/yakwilikk/go-yamlvalidator/pkg/valuevalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// CompareOp is a numeric relation used by FieldComparisonValidator.
+type CompareOp string
+
+// Supported comparison operators.
+const (
+	OpLess         CompareOp = "<"
+	OpLessEqual    CompareOp = "<="
+	OpEqual        CompareOp = "=="
+	OpNotEqual     CompareOp = "!="
+	OpGreater      CompareOp = ">"
+	OpGreaterEqual CompareOp = ">="
+)
+
+// Valid reports whether op is one of the defined operators.
+func (op CompareOp) Valid() bool {
+	switch op {
+	case OpLess, OpLessEqual, OpEqual, OpNotEqual, OpGreater, OpGreaterEqual:
+		return true
+	}
+	return false
+}
+
+func (op CompareOp) holds(left, right float64) bool {
+	switch op {
+	case OpLess:
+		return left < right
+	case OpLessEqual:
+		return left <= right
+	case OpEqual:
+		return left == right
+	case OpNotEqual:
+		return left != right
+	case OpGreater:
+		return left > right
+	case OpGreaterEqual:
+		return left >= right
+	}
+	return true
+}
+
+// FieldComparisonValidator requires two numeric sibling fields to satisfy
+// "Left Op Right", e.g. {Left: "minReplicas", Op: OpLessEqual, Right: "maxReplicas"}.
+// The error is reported at the Right field. Nothing is checked when either field
+// is missing or not numeric (leave that to the fields' own schemas).
+type FieldComparisonValidator struct {
+	Left  string
+	Op    CompareOp
+	Right string
+}
+
+// ValidateMap implements MapValidator.
+func (vld FieldComparisonValidator) ValidateMap(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	_, leftNode := v.MappingLookup(node, vld.Left)
+	_, rightNode := v.MappingLookup(node, vld.Right)
+	leftNode, rightNode = resolveAlias(leftNode), resolveAlias(rightNode)
+	if leftNode == nil || rightNode == nil {
+		return
+	}
+	left, err := valv.ParseYAMLNumber(leftNode)
+	if err != nil {
+		return
+	}
+	right, err := valv.ParseYAMLNumber(rightNode)
+	if err != nil {
+		return
+	}
+	if vld.Op.holds(left, right) {
+		return
+	}
+	rightPath := joinPath(path, vld.Right)
+	msg := ctx.Message(v.MsgFieldComparison, fmt.Sprintf("%q must be %s %q", vld.Left, vld.Op, vld.Right),
+		"path", rightPath, "left", vld.Left, "op", string(vld.Op), "right", vld.Right)
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     rightPath,
+		Line:     rightNode.Line,
+		Column:   rightNode.Column,
+		Message:  msg,
+		Got:      fmt.Sprintf("%s=%s, %s=%s", vld.Left, leftNode.Value, vld.Right, rightNode.Value),
+		Expected: fmt.Sprintf("%s %s %s", vld.Left, vld.Op, vld.Right),
+	})
+}
@@ -0,0 +1,48 @@
+package mapvalidator
+
+import (
+	"fmt"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// FieldsDifferValidator requires two scalar sibling fields to have different
+// values, e.g. {Left: "from", Right: "to"} rejects a route from a node to itself.
+// Values are compared by their text. The error is reported at the Right field.
+// Nothing is checked when either field is missing or not a scalar.
+type FieldsDifferValidator struct {
+	Left    string
+	Right   string
+	Message string // Custom error message (optional)
+}
+
+// ValidateMap implements MapValidator.
+func (vld FieldsDifferValidator) ValidateMap(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	_, leftNode := v.MappingLookup(node, vld.Left)
+	_, rightNode := v.MappingLookup(node, vld.Right)
+	leftNode, rightNode = resolveAlias(leftNode), resolveAlias(rightNode)
+	if leftNode == nil || rightNode == nil {
+		return
+	}
+	if leftNode.Kind != yaml.ScalarNode || rightNode.Kind != yaml.ScalarNode || leftNode.Value != rightNode.Value {
+		return
+	}
+	msg := vld.Message
+	if msg == "" {
+		msg = ctx.Message(v.MsgFieldsEqual, fmt.Sprintf("%q must differ from %q", vld.Right, vld.Left),
+			"path", joinPath(path, vld.Right), "left", vld.Left, "right", vld.Right)
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     joinPath(path, vld.Right),
+		Line:     rightNode.Line,
+		Column:   rightNode.Column,
+		Message:  msg,
+		Got:      rightNode.Value,
+		Expected: fmt.Sprintf("%s != %s", vld.Right, vld.Left),
+	})
+}
@@ -0,0 +1,43 @@
+package mapvalidator
+
+import (
+	"fmt"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// RequiredKeysValidator validates that a mapping contains all of the given keys.
+// Unlike FieldSchema.Required it does not need AllowedKeys, so it works for free-form maps
+// (e.g. labels that must contain "app").
+type RequiredKeysValidator struct {
+	Keys    []string
+	Message string // Custom error message (optional)
+}
+
+// ValidateMap implements MapValidator.
+func (vld RequiredKeysValidator) ValidateMap(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for _, key := range vld.Keys {
+		if k, _ := v.MappingLookup(node, key); k != nil {
+			continue
+		}
+		msg := vld.Message
+		if msg == "" {
+			msg = ctx.Message(v.MsgRequiredMissing, fmt.Sprintf("required key %q is missing", key), "path", joinPath(path, key), "key", key)
+		}
+		ctx.AddError(v.ValidationError{
+			Level:   v.LevelError,
+			Path:    joinPath(path, key),
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: msg,
+		})
+	}
+}
+
+func joinPath(base, key string) string {
+	return v.JoinPath(base, key)
+}
@@ -0,0 +1,74 @@
+package mapvalidator
+
+import (
+	"fmt"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// RequireWhenValueValidator requires fields to be present and non-empty when
+// a field has a given value.
+// Example: {Field: "auth", Value: "token", Require: []string{"token"}}
+// Means: if auth is token, token must be set to a non-empty value.
+// Unlike Conditions, a field that is present but null or empty is reported
+// too, and the messages name the triggering value.
+type RequireWhenValueValidator struct {
+	Field   string   // Trigger field
+	Value   string   // Trigger value (compared with the scalar text)
+	Require []string // Fields required when triggered
+	Message string   // Custom error message (optional)
+}
+
+// ValidateMap implements MapValidator.
+func (vld RequireWhenValueValidator) ValidateMap(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	triggerKey, trigger := v.MappingLookup(node, vld.Field)
+	trigger = resolveAlias(trigger)
+	if trigger == nil || trigger.Kind != yaml.ScalarNode || trigger.Value != vld.Value {
+		return
+	}
+
+	for _, req := range vld.Require {
+		_, value := v.MappingLookup(node, req)
+		value = resolveAlias(value)
+		switch {
+		case value == nil:
+			vld.report(joinPath(path, req), triggerKey, ctx,
+				ctx.Message(v.MsgRequiredWhen, fmt.Sprintf("field %q is required when %q is %q", req, vld.Field, vld.Value),
+					"path", joinPath(path, req), "key", req, "field", vld.Field, "value", vld.Value))
+		case isEmpty(value, ctx):
+			vld.report(joinPath(path, req), value, ctx,
+				ctx.Message(v.MsgEmptyWhen, fmt.Sprintf("field %q must not be empty when %q is %q", req, vld.Field, vld.Value),
+					"path", joinPath(path, req), "key", req, "field", vld.Field, "value", vld.Value))
+		}
+	}
+}
+
+func (vld RequireWhenValueValidator) report(path string, at *yaml.Node, ctx *v.ValidationContext, defaultMsg string) {
+	msg := vld.Message
+	if msg == "" {
+		msg = defaultMsg
+	}
+	ctx.AddError(v.ValidationError{
+		Level:   v.LevelError,
+		Path:    path,
+		Line:    at.Line,
+		Column:  at.Column,
+		Message: msg,
+	})
+}
+
+// isEmpty reports whether a value is null, an empty string or an empty collection.
+func isEmpty(node *yaml.Node, ctx *v.ValidationContext) bool {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value == "" || ctx.InferType(node) == v.TypeNull
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(node.Content) == 0
+	}
+	return false
+}
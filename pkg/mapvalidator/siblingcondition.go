@@ -0,0 +1,46 @@
+package mapvalidator
+
+import (
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// SiblingConditionValidator applies a value validator to one field only when a
+// sibling field has a given value.
+// Example: {Field: "protocol", Value: "https", Target: "port", Validator: EnumValidator{Allowed: []string{"443"}}}
+// Means: if protocol is https, port must be 443.
+// Nothing is checked when the target is absent; combine with Conditions or
+// DependentRequiredValidator to require it.
+type SiblingConditionValidator struct {
+	Field     string // Trigger field
+	Value     string // Trigger value (compared with the scalar text)
+	Target    string // Field the validator applies to
+	Validator v.ValueValidator
+}
+
+// ValidateMap implements MapValidator.
+func (vld SiblingConditionValidator) ValidateMap(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.MappingNode || vld.Validator == nil {
+		return
+	}
+
+	_, trigger := v.MappingLookup(node, vld.Field)
+	trigger = resolveAlias(trigger)
+	if trigger == nil || trigger.Kind != yaml.ScalarNode || trigger.Value != vld.Value {
+		return
+	}
+
+	_, target := v.MappingLookup(node, vld.Target)
+	target = resolveAlias(target)
+	if target == nil {
+		return
+	}
+	vld.Validator.Validate(target, joinPath(path, vld.Target), ctx)
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.AliasNode {
+		return node.Alias
+	}
+	return node
+}
@@ -0,0 +1,98 @@
+package seqvalidator
+
+import (
+	"fmt"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	valv "github.com/yakwilikk/go-yamlvalidator/pkg/valuevalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// MonotonicValidator validates that numeric sequence items are monotonically
+// increasing (or decreasing, if Decreasing is set). Equal neighbours are allowed
+// unless Strict is set. The first out-of-order item is reported.
+type MonotonicValidator struct {
+	Decreasing bool
+	Strict     bool
+}
+
+// ValidateSeq implements SeqValidator.
+func (vld MonotonicValidator) ValidateSeq(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	var prev float64
+	for i, item := range node.Content {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if item.Kind == yaml.AliasNode && item.Alias != nil {
+			item = item.Alias
+		}
+		if item.Kind != yaml.ScalarNode {
+			ctx.AddError(v.ValidationError{
+				Level:   v.LevelError,
+				Path:    itemPath,
+				Line:    item.Line,
+				Column:  item.Column,
+				Message: ctx.Message(v.MsgNotNumeric, "expected numeric value", "path", itemPath, "value", item.Value),
+			})
+			return
+		}
+		val, err := valv.ParseYAMLNumber(item)
+		if err != nil {
+			ctx.AddError(v.ValidationError{
+				Level:   v.LevelError,
+				Path:    itemPath,
+				Line:    item.Line,
+				Column:  item.Column,
+				Message: ctx.Message(v.MsgNotNumeric, "expected numeric value", "path", itemPath, "value", item.Value),
+				Got:     item.Value,
+			})
+			return
+		}
+		if i > 0 && !vld.inOrder(prev, val) {
+			msg := ctx.Message(v.MsgNotMonotonic, fmt.Sprintf("sequence is not monotonically %s", vld.direction()),
+				"path", itemPath, "direction", vld.direction(), "value", item.Value, "previous", fmt.Sprint(prev))
+			ctx.AddError(v.ValidationError{
+				Level:    v.LevelError,
+				Path:     itemPath,
+				Line:     item.Line,
+				Column:   item.Column,
+				Message:  msg,
+				Got:      fmt.Sprintf("%v after %v", val, prev),
+				Expected: fmt.Sprintf("%s %v", vld.relation(), prev),
+			})
+			return
+		}
+		prev = val
+	}
+}
+
+func (vld MonotonicValidator) inOrder(prev, cur float64) bool {
+	switch {
+	case vld.Decreasing && vld.Strict:
+		return cur < prev
+	case vld.Decreasing:
+		return cur <= prev
+	case vld.Strict:
+		return cur > prev
+	default:
+		return cur >= prev
+	}
+}
+
+func (vld MonotonicValidator) direction() string {
+	if vld.Decreasing {
+		return "decreasing"
+	}
+	return "increasing"
+}
+
+func (vld MonotonicValidator) relation() string {
+	switch {
+	case vld.Decreasing && vld.Strict:
+		return "<"
+	case vld.Decreasing:
+		return "<="
+	case vld.Strict:
+		return ">"
+	default:
+		return ">="
+	}
+}
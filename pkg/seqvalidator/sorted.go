@@ -0,0 +1,102 @@
+package seqvalidator
+
+import (
+	"fmt"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	valv "github.com/yakwilikk/go-yamlvalidator/pkg/valuevalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// SortedValidator validates that a sequence of scalars is sorted.
+// Items are compared as strings (byte-wise) unless Numeric is set.
+// Equal neighbours are allowed. The first out-of-order item is reported.
+type SortedValidator struct {
+	Descending bool
+	Numeric    bool
+}
+
+// ValidateSeq implements SeqValidator.
+func (vld SortedValidator) ValidateSeq(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	var prev *yaml.Node
+	var prevNum float64
+	for i, item := range node.Content {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if item.Kind == yaml.AliasNode && item.Alias != nil {
+			item = item.Alias
+		}
+		if item.Kind != yaml.ScalarNode {
+			ctx.AddError(v.ValidationError{
+				Level:   v.LevelError,
+				Path:    itemPath,
+				Line:    item.Line,
+				Column:  item.Column,
+				Message: ctx.Message(v.MsgNonScalarItem, "sorted sequence items must be scalars", "path", itemPath),
+			})
+			return
+		}
+
+		var cmp int
+		if vld.Numeric {
+			num, err := valv.ParseYAMLNumber(item)
+			if err != nil {
+				ctx.AddError(v.ValidationError{
+					Level:   v.LevelError,
+					Path:    itemPath,
+					Line:    item.Line,
+					Column:  item.Column,
+					Message: ctx.Message(v.MsgNotNumeric, "expected numeric value", "path", itemPath, "value", item.Value),
+					Got:     item.Value,
+				})
+				return
+			}
+			if prev != nil {
+				cmp = compareFloat(prevNum, num)
+			}
+			prevNum = num
+		} else if prev != nil {
+			cmp = compareString(prev.Value, item.Value)
+		}
+
+		if prev != nil && ((!vld.Descending && cmp > 0) || (vld.Descending && cmp < 0)) {
+			order := "ascending"
+			if vld.Descending {
+				order = "descending"
+			}
+			msg := ctx.Message(v.MsgNotSorted, fmt.Sprintf("sequence is not sorted in %s order", order),
+				"path", itemPath, "order", order, "value", item.Value, "previous", prev.Value)
+			ctx.AddError(v.ValidationError{
+				Level:   v.LevelError,
+				Path:    itemPath,
+				Line:    item.Line,
+				Column:  item.Column,
+				Message: msg,
+				Got:     fmt.Sprintf("%q after %q", item.Value, prev.Value),
+			})
+			return
+		}
+		prev = item
+	}
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareString(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
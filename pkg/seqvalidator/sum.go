@@ -0,0 +1,161 @@
+package seqvalidator
+
+import (
+	"fmt"
+	"math"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	valv "github.com/yakwilikk/go-yamlvalidator/pkg/valuevalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// SumOp is how SumValidator compares the sum with its Total.
+type SumOp string
+
+// Supported sum comparisons.
+const (
+	SumEqual   SumOp = "==" // sum == Total (default)
+	SumAtMost  SumOp = "<=" // sum <= Total
+	SumAtLeast SumOp = ">=" // sum >= Total
+)
+
+// SumValidator checks the sum of numeric entries against a total, e.g. traffic
+// weights that must add up to 100. It works on sequences (items) and on mappings
+// (values; merge keys are not expanded). With Field set, each entry must be a mapping and its Field value is
+// summed instead of the entry itself.
+//
+// Comparisons allow an absolute Tolerance to absorb float rounding. A missing or
+// non-numeric entry is reported and stops the check.
+type SumValidator struct {
+	Field     string  // Sub-field to sum (empty = the entries themselves)
+	Op        SumOp   // Comparison (empty = SumEqual)
+	Total     float64 // Expected total
+	Tolerance float64 // Allowed absolute difference
+}
+
+// ValidateSeq implements SeqValidator.
+func (vld SumValidator) ValidateSeq(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.SequenceNode {
+		return
+	}
+	entries := make([]sumEntry, len(node.Content))
+	for i, item := range node.Content {
+		entries[i] = sumEntry{path: fmt.Sprintf("%s[%d]", path, i), node: item}
+	}
+	vld.check(node, path, entries, ctx)
+}
+
+// ValidateMap implements MapValidator.
+func (vld SumValidator) ValidateMap(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	var entries []sumEntry
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if key == "<<" {
+			continue
+		}
+		entries = append(entries, sumEntry{path: v.JoinPath(path, key), node: node.Content[i+1]})
+	}
+	vld.check(node, path, entries, ctx)
+}
+
+type sumEntry struct {
+	path string
+	node *yaml.Node
+}
+
+func (vld SumValidator) check(node *yaml.Node, path string, entries []sumEntry, ctx *v.ValidationContext) {
+	sum := 0.0
+	for _, e := range entries {
+		val, ok := vld.entryValue(e, ctx)
+		if !ok {
+			return
+		}
+		sum += val
+	}
+
+	op := vld.Op
+	if op == "" {
+		op = SumEqual
+	}
+	var ok bool
+	switch op {
+	case SumAtMost:
+		ok = sum <= vld.Total+vld.Tolerance
+	case SumAtLeast:
+		ok = sum >= vld.Total-vld.Tolerance
+	default:
+		ok = math.Abs(sum-vld.Total) <= vld.Tolerance
+	}
+	if ok {
+		return
+	}
+
+	what := "values"
+	if vld.Field != "" {
+		what = fmt.Sprintf("%q values", vld.Field)
+	}
+	got, expected := fmt.Sprint(sum), fmt.Sprintf("%s %v", op, vld.Total)
+	msg := ctx.Message(v.MsgSumMismatch, fmt.Sprintf("sum of %s is %v", what, sum), "path", path, "got", got, "expected", expected)
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      got,
+		Expected: expected,
+	})
+}
+
+// entryValue returns the number contributed by one entry, reporting problems.
+func (vld SumValidator) entryValue(e sumEntry, ctx *v.ValidationContext) (float64, bool) {
+	item, itemPath := resolve(e.node), e.path
+	if vld.Field != "" {
+		if item.Kind != yaml.MappingNode {
+			ctx.AddError(v.ValidationError{
+				Level:   v.LevelError,
+				Path:    itemPath,
+				Line:    item.Line,
+				Column:  item.Column,
+				Message: ctx.Message(v.MsgItemNotMapping, fmt.Sprintf("expected mapping with field %q", vld.Field), "path", itemPath, "field", vld.Field),
+			})
+			return 0, false
+		}
+		_, valueNode := v.MappingLookup(item, vld.Field)
+		if valueNode == nil {
+			ctx.AddError(v.ValidationError{
+				Level:   v.LevelError,
+				Path:    itemPath,
+				Line:    item.Line,
+				Column:  item.Column,
+				Message: ctx.Message(v.MsgRequiredMissing, fmt.Sprintf("field %q is missing", vld.Field), "path", itemPath, "key", vld.Field),
+			})
+			return 0, false
+		}
+		item, itemPath = resolve(valueNode), v.JoinPath(itemPath, vld.Field)
+	}
+
+	val, err := valv.ParseYAMLNumber(item)
+	if item.Kind != yaml.ScalarNode || err != nil {
+		ctx.AddError(v.ValidationError{
+			Level:   v.LevelError,
+			Path:    itemPath,
+			Line:    item.Line,
+			Column:  item.Column,
+			Message: ctx.Message(v.MsgNotNumeric, "expected numeric value", "path", itemPath, "value", item.Value),
+			Got:     item.Value,
+		})
+		return 0, false
+	}
+	return val, true
+}
+
+func resolve(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return node.Alias
+	}
+	return node
+}
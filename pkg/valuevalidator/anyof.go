@@ -0,0 +1,52 @@
+package valuevalidator
+
+import (
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// AnyOfValidator passes if at least one of its validators passes, e.g. a value
+// that is either an IP address or a hostname. Each alternative runs against a
+// forked context; warnings of the first passing alternative are kept. When all
+// fail, a single error lists the first error of every alternative.
+type AnyOfValidator struct {
+	Validators []v.ValueValidator
+	Message    string // Custom error message (optional)
+}
+
+// Validate implements ValueValidator.
+func (vld AnyOfValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if len(vld.Validators) == 0 {
+		return
+	}
+
+	reasons := make([]string, 0, len(vld.Validators))
+	for _, alt := range vld.Validators {
+		sub := ctx.Fork()
+		alt.Validate(node, path, sub)
+		errs := sub.Collector().Errors()
+		if len(errs) == 0 {
+			for _, w := range sub.Collector().Warnings() {
+				ctx.AddError(w)
+			}
+			return
+		}
+		reasons = append(reasons, errs[0].Message)
+	}
+
+	msg := vld.Message
+	if msg == "" {
+		msg = ctx.Message(v.MsgNoAlternative, "value matches none of the alternatives: "+strings.Join(reasons, "; "),
+			"path", path, "value", node.Value, "reasons", strings.Join(reasons, "; "))
+	}
+	ctx.AddError(v.ValidationError{
+		Level:   v.LevelError,
+		Path:    path,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: msg,
+		Got:     node.Value,
+	})
+}
@@ -0,0 +1,49 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"strconv"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// ASCIIValidator validates that a scalar contains only ASCII characters, for
+// downstream systems that cannot handle anything else. With AllowExtended,
+// Latin-1 characters (up to U+00FF, e.g. "é") are accepted too. The first
+// offending rune is reported with its byte offset in the value.
+type ASCIIValidator struct {
+	AllowExtended bool   // Also accept Latin-1 (U+0080 to U+00FF)
+	Message       string // Custom error message (optional)
+}
+
+// Validate implements ValueValidator.
+func (vld ASCIIValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	limit, charset := rune(0x7F), "ASCII"
+	if vld.AllowExtended {
+		limit, charset = 0xFF, "Latin-1"
+	}
+	for offset, r := range node.Value {
+		if r <= limit {
+			continue
+		}
+		msg := vld.Message
+		if msg == "" {
+			msg = ctx.Message(v.MsgNonASCII, fmt.Sprintf("non-%s character %q (%U) at byte %d", charset, r, r, offset),
+				"path", path, "value", node.Value, "char", string(r), "offset", strconv.Itoa(offset), "charset", charset)
+		}
+		ctx.AddError(v.ValidationError{
+			Level:    v.LevelError,
+			Path:     path,
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  msg,
+			Got:      node.Value,
+			Expected: charset + " characters only",
+		})
+		return
+	}
+}
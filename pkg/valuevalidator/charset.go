@@ -0,0 +1,48 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// CharsetValidator restricts a scalar to a set of characters without writing a regex.
+// Every rune must be in Allowed (when non-empty) and none may be in Disallowed.
+// The first offending rune is reported with its offset (in runes) in the value.
+type CharsetValidator struct {
+	Allowed    string // Permitted runes, e.g. "abcdefghijklmnopqrstuvwxyz0123456789-" (empty = any)
+	Disallowed string // Forbidden runes, e.g. "&*!|>'\"%@`"
+	Message    string // Custom error message (optional)
+}
+
+// Validate implements ValueValidator.
+func (vld CharsetValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	offset := 0
+	for _, r := range node.Value {
+		if (vld.Allowed != "" && !strings.ContainsRune(vld.Allowed, r)) || strings.ContainsRune(vld.Disallowed, r) {
+			msg := vld.Message
+			if msg == "" {
+				msg = ctx.Message(v.MsgCharNotAllowed, fmt.Sprintf("character %q at offset %d is not allowed", r, offset),
+					"path", path, "value", node.Value, "char", string(r), "offset", strconv.Itoa(offset))
+			}
+			expected := fmt.Sprintf("characters from %q", vld.Allowed)
+			if vld.Allowed == "" {
+				expected = fmt.Sprintf("no characters from %q", vld.Disallowed)
+			}
+			ctx.AddError(v.ValidationError{
+				Level:    v.LevelError,
+				Path:     path,
+				Line:     node.Line,
+				Column:   node.Column,
+				Message:  msg,
+				Got:      node.Value,
+				Expected: expected,
+			})
+			return
+		}
+		offset++
+	}
+}
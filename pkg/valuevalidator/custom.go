@@ -2,15 +2,22 @@ package valuevalidator
 
 import (
 	"fmt"
+	"os"
 
 	v "github.com/yakwilikk/go-yamlvalidator"
 	"gopkg.in/yaml.v3"
 )
 
 // DirectoryValidator allows directory to be either a string or a map with required path and optional root.
-type DirectoryValidator struct{}
+//
+// With MustExist set, the directory path (the scalar itself, or the "path" field of the mapping)
+// must name an existing directory. The check uses os.Stat and only runs when ctx.CheckFilesystem is true.
+type DirectoryValidator struct {
+	MustExist bool
+}
 
-func (DirectoryValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+// Validate implements ValueValidator.
+func (vld DirectoryValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
 	switch node.Kind {
 	case yaml.ScalarNode:
 		// must be string
@@ -23,7 +30,9 @@ func (DirectoryValidator) Validate(node *yaml.Node, path string, ctx *v.Validati
 				Message: "directory must be a string or mapping",
 				Got:     node.Tag,
 			})
+			return
 		}
+		vld.checkExists(node, path, ctx)
 	case yaml.MappingNode:
 		requiredPath := false
 		for i := 0; i < len(node.Content); i += 2 {
@@ -35,17 +44,19 @@ func (DirectoryValidator) Validate(node *yaml.Node, path string, ctx *v.Validati
 				if valNode.Kind != yaml.ScalarNode {
 					ctx.AddError(v.ValidationError{
 						Level:   v.LevelError,
-						Path:    path + ".path",
+						Path:    v.JoinPath(path, "path"),
 						Line:    valNode.Line,
 						Column:  valNode.Column,
 						Message: "path must be a string",
 					})
+				} else {
+					vld.checkExists(valNode, v.JoinPath(path, "path"), ctx)
 				}
 			case "root":
 				if valNode.Kind != yaml.ScalarNode {
 					ctx.AddError(v.ValidationError{
 						Level:   v.LevelError,
-						Path:    path + ".root",
+						Path:    v.JoinPath(path, "root"),
 						Line:    valNode.Line,
 						Column:  valNode.Column,
 						Message: "root must be a string",
@@ -54,7 +65,7 @@ func (DirectoryValidator) Validate(node *yaml.Node, path string, ctx *v.Validati
 			default:
 				ctx.AddError(v.ValidationError{
 					Level:   v.LevelWarning,
-					Path:    path + "." + keyNode.Value,
+					Path:    v.JoinPath(path, keyNode.Value),
 					Line:    keyNode.Line,
 					Column:  keyNode.Column,
 					Message: "unknown field under directory",
@@ -65,7 +76,7 @@ func (DirectoryValidator) Validate(node *yaml.Node, path string, ctx *v.Validati
 		if !requiredPath {
 			ctx.AddError(v.ValidationError{
 				Level:   v.LevelError,
-				Path:    path + ".path",
+				Path:    v.JoinPath(path, "path"),
 				Line:    node.Line,
 				Column:  node.Column,
 				Message: "directory.path is required",
@@ -83,6 +94,35 @@ func (DirectoryValidator) Validate(node *yaml.Node, path string, ctx *v.Validati
 	}
 }
 
+func (vld DirectoryValidator) checkExists(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if !vld.MustExist || !ctx.CheckFilesystem {
+		return
+	}
+	info, err := os.Stat(node.Value)
+	if err != nil {
+		ctx.AddError(v.ValidationError{
+			Level:   v.LevelError,
+			Path:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: "directory does not exist",
+			Got:     node.Value,
+		})
+		return
+	}
+	if !info.IsDir() {
+		ctx.AddError(v.ValidationError{
+			Level:    v.LevelError,
+			Path:     path,
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  "path is not a directory",
+			Got:      node.Value,
+			Expected: "directory",
+		})
+	}
+}
+
 // PluginSourceValidator ensures exactly one plugin source field is set.
 type PluginSourceValidator struct{}
 
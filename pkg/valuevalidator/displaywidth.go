@@ -0,0 +1,155 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// DisplayWidthValidator validates the width of a string in terminal columns,
+// for values shown in fixed-width UIs. Unlike LengthValidator, which counts runes,
+// East Asian wide and fullwidth characters (CJK, Hangul, fullwidth forms, most
+// emoji) count as 2 columns, and combining marks and format characters as 0.
+//
+// The wide ranges are the W and F classes of Unicode's EastAsianWidth.txt
+// (UAX #11), merged into blocks; ambiguous-width (A) characters count as 1.
+type DisplayWidthValidator struct {
+	Min *int // Minimum width (nil = no minimum)
+	Max *int // Maximum width (nil = no maximum)
+}
+
+// Validate implements ValueValidator.
+func (vld DisplayWidthValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	width := DisplayWidth(node.Value)
+
+	if vld.Min != nil && width < *vld.Min {
+		ctx.AddError(v.ValidationError{
+			Level:    v.LevelError,
+			Path:     path,
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  ctx.Message(v.MsgWidthBelowMin, "display width below minimum", "path", path, "got", strconv.Itoa(width), "min", strconv.Itoa(*vld.Min)),
+			Got:      fmt.Sprintf("%d", width),
+			Expected: fmt.Sprintf(">= %d", *vld.Min),
+		})
+	}
+
+	if vld.Max != nil && width > *vld.Max {
+		ctx.AddError(v.ValidationError{
+			Level:    v.LevelError,
+			Path:     path,
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  ctx.Message(v.MsgWidthAboveMax, "display width above maximum", "path", path, "got", strconv.Itoa(width), "max", strconv.Itoa(*vld.Max)),
+			Got:      fmt.Sprintf("%d", width),
+			Expected: fmt.Sprintf("<= %d", *vld.Max),
+		})
+	}
+}
+
+// DisplayWidth returns the number of terminal columns s occupies.
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0 // control characters
+	case r < 0x1100:
+		if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+			return 0
+		}
+		return 1
+	case r >= 0x1160 && r <= 0x11FF:
+		return 0 // Hangul medial vowels and final consonants combine with the initial
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func isWide(r rune) bool {
+	i := sort.Search(len(wideRanges), func(i int) bool { return wideRanges[i][1] >= r })
+	return i < len(wideRanges) && wideRanges[i][0] <= r
+}
+
+// wideRanges lists the East Asian Wide (W) and Fullwidth (F) ranges, sorted.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, // Hangul Jamo initial consonants
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x23F0, 0x23F0},
+	{0x23F3, 0x23F3},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267F, 0x267F},
+	{0x2693, 0x2693},
+	{0x26A1, 0x26A1},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x26CE, 0x26CE},
+	{0x26D4, 0x26D4},
+	{0x26EA, 0x26EA},
+	{0x26F2, 0x26F3},
+	{0x26F5, 0x26F5},
+	{0x26FA, 0x26FA},
+	{0x26FD, 0x26FD},
+	{0x2705, 0x2705},
+	{0x270A, 0x270B},
+	{0x2728, 0x2728},
+	{0x274C, 0x274C},
+	{0x274E, 0x274E},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27B0, 0x27B0},
+	{0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x2E80, 0x303E},   // CJK radicals, Kangxi, ideographic description, CJK symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, Hangul compatibility Jamo, Kanbun, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK Unified Ideographs Extension A
+	{0x4E00, 0x9FFF},   // CJK Unified Ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo Extended-A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK Compatibility Ideographs
+	{0xFE10, 0xFE19},   // Vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small form variants
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x16FE0, 0x16FE4}, // Ideographic symbols
+	{0x17000, 0x18CFF}, // Tangut, Khitan
+	{0x1B000, 0x1B2FF}, // Kana supplement and extensions, Nushu
+	{0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A},
+	{0x1F200, 0x1F251}, // Enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // Miscellaneous symbols and pictographs, emoticons
+	{0x1F680, 0x1F6FF}, // Transport and map symbols
+	{0x1F7E0, 0x1F7EB},
+	{0x1F900, 0x1F9FF}, // Supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // Symbols and pictographs extended-A
+	{0x20000, 0x2FFFD}, // CJK Unified Ideographs Extensions B-F, supplement
+	{0x30000, 0x3FFFD}, // CJK Unified Ideographs Extensions G-H
+}
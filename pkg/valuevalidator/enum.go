@@ -2,15 +2,19 @@ package valuevalidator
 
 import (
 	"fmt"
+	"strings"
 
 	v "github.com/yakwilikk/go-yamlvalidator"
 	"gopkg.in/yaml.v3"
 )
 
 // EnumValidator validates that a value is one of the allowed values.
+// Descriptions, keyed by allowed value, are listed in the error's Expected,
+// e.g. "one of: v1 (stable), v1beta1 (deprecated)".
 type EnumValidator struct {
-	Allowed []string
-	Message string // Custom error message (optional)
+	Allowed      []string
+	Descriptions map[string]string // Optional descriptions of Allowed values
+	Message      string            // Custom error message (optional; {value} and {path} are filled in)
 }
 
 // Validate implements ValueValidator.
@@ -20,9 +24,9 @@ func (vld EnumValidator) Validate(node *yaml.Node, path string, ctx *v.Validatio
 			return
 		}
 	}
-	msg := vld.Message
+	msg := renderMessage(vld.Message, node, path, nil)
 	if msg == "" {
-		msg = fmt.Sprintf("invalid value %q", node.Value)
+		msg = ctx.Message(v.MsgInvalidEnumValue, fmt.Sprintf("invalid value %q", node.Value), "path", path, "value", node.Value)
 	}
 	ctx.AddError(v.ValidationError{
 		Level:    v.LevelError,
@@ -31,6 +35,20 @@ func (vld EnumValidator) Validate(node *yaml.Node, path string, ctx *v.Validatio
 		Column:   node.Column,
 		Message:  msg,
 		Got:      node.Value,
-		Expected: fmt.Sprintf("one of %v", vld.Allowed),
+		Expected: vld.expected(),
 	})
 }
+
+func (vld EnumValidator) expected() string {
+	if len(vld.Descriptions) == 0 {
+		return fmt.Sprintf("one of %v", vld.Allowed)
+	}
+	parts := make([]string, len(vld.Allowed))
+	for i, allowed := range vld.Allowed {
+		parts[i] = allowed
+		if desc := vld.Descriptions[allowed]; desc != "" {
+			parts[i] += " (" + desc + ")"
+		}
+	}
+	return "one of: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,62 @@
+package valuevalidator
+
+import (
+	"fmt"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// EnumFromPathValidator validates that a value names a key of a mapping elsewhere
+// in the same document, e.g. `activeProfile: dev` must be a key under `profiles`.
+// Path is a selector relative to the document root (see SelectPath); when it
+// matches several mappings, the keys of all of them are allowed. A value is
+// invalid when the path matches nothing.
+type EnumFromPathValidator struct {
+	Path    string
+	Message string // Custom error message (optional)
+}
+
+// Validate implements ValueValidator.
+func (vld EnumFromPathValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	root := ctx.Document()
+	if node.Kind != yaml.ScalarNode || root == nil {
+		return
+	}
+	matches, err := v.SelectPath(root, vld.Path)
+	if err != nil {
+		ctx.AddError(v.ValidationError{
+			Level:   v.LevelError,
+			Path:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var keys []string
+	for _, m := range matches {
+		if m.Value.Kind != yaml.MappingNode {
+			continue
+		}
+		if keyNode, _ := v.MappingLookup(m.Value, node.Value); keyNode != nil {
+			return
+		}
+		keys = append(keys, v.MappingKeys(m.Value)...)
+	}
+
+	msg := vld.Message
+	if msg == "" {
+		msg = ctx.Message(v.MsgNotAKey, fmt.Sprintf("%q is not a key of %s", node.Value, vld.Path), "path", path, "value", node.Value, "source", vld.Path)
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      node.Value,
+		Expected: fmt.Sprintf("one of %v", keys),
+	})
+}
@@ -0,0 +1,153 @@
+package valuevalidator
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// PathMode restricts what kind of filesystem entry a path may point to.
+type PathMode string
+
+const (
+	// PathModeAny accepts files and directories.
+	PathModeAny PathMode = "any"
+	// PathModeFile requires a regular file.
+	PathModeFile PathMode = "file"
+	// PathModeDir requires a directory.
+	PathModeDir PathMode = "dir"
+)
+
+// FilePathValidator validates a filesystem path.
+//
+// Absolute/relative checks are always applied. Existence and Mode checks touch
+// the filesystem and therefore only run when ctx.CheckFilesystem is true.
+type FilePathValidator struct {
+	MustExist     bool     // Path must exist (requires ctx.CheckFilesystem)
+	Mode          PathMode // Expected entry kind for existing paths ("" = any)
+	AllowAbsolute bool     // Accept absolute paths
+	AllowRelative bool     // Accept relative paths
+	BaseDir       string   // Base for relative paths (empty = working directory)
+	Message       string   // Custom error message (optional; {value} and {path} are filled in)
+}
+
+// Validate implements ValueValidator.
+// If neither AllowAbsolute nor AllowRelative is set, both are accepted.
+func (vld FilePathValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	val := node.Value
+	if val == "" {
+		ctx.AddError(v.ValidationError{
+			Level:   v.LevelError,
+			Path:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: vld.message(ctx, v.MsgPathEmpty, "path cannot be empty", node, path),
+		})
+		return
+	}
+
+	abs := filepath.IsAbs(val)
+	if vld.AllowAbsolute || vld.AllowRelative {
+		if abs && !vld.AllowAbsolute {
+			ctx.AddError(v.ValidationError{
+				Level:    v.LevelError,
+				Path:     path,
+				Line:     node.Line,
+				Column:   node.Column,
+				Message:  vld.message(ctx, v.MsgPathAbsolute, "absolute path not allowed", node, path),
+				Got:      val,
+				Expected: "relative path",
+			})
+			return
+		}
+		if !abs && !vld.AllowRelative {
+			ctx.AddError(v.ValidationError{
+				Level:    v.LevelError,
+				Path:     path,
+				Line:     node.Line,
+				Column:   node.Column,
+				Message:  vld.message(ctx, v.MsgPathRelative, "relative path not allowed", node, path),
+				Got:      val,
+				Expected: "absolute path",
+			})
+			return
+		}
+	}
+
+	if !ctx.CheckFilesystem {
+		return
+	}
+
+	target := val
+	if !abs && vld.BaseDir != "" {
+		target = filepath.Join(vld.BaseDir, val)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		// e.g. a permission error: the path may exist but cannot be checked.
+		ctx.AddError(v.ValidationError{
+			Level:   v.LevelError,
+			Path:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: vld.message(ctx, v.MsgPathStatFailed, err.Error(), node, path, "error", err.Error()),
+			Got:     val,
+		})
+		return
+	}
+	if err != nil {
+		if vld.MustExist {
+			ctx.AddError(v.ValidationError{
+				Level:   v.LevelError,
+				Path:    path,
+				Line:    node.Line,
+				Column:  node.Column,
+				Message: vld.message(ctx, v.MsgPathNotExist, "path does not exist", node, path),
+				Got:     val,
+			})
+		}
+		return
+	}
+
+	switch vld.Mode {
+	case PathModeFile:
+		if !info.Mode().IsRegular() {
+			ctx.AddError(v.ValidationError{
+				Level:    v.LevelError,
+				Path:     path,
+				Line:     node.Line,
+				Column:   node.Column,
+				Message:  vld.message(ctx, v.MsgPathNotFile, "path is not a regular file", node, path),
+				Got:      val,
+				Expected: "file",
+			})
+		}
+	case PathModeDir:
+		if !info.IsDir() {
+			ctx.AddError(v.ValidationError{
+				Level:    v.LevelError,
+				Path:     path,
+				Line:     node.Line,
+				Column:   node.Column,
+				Message:  vld.message(ctx, v.MsgPathNotDir, "path is not a directory", node, path),
+				Got:      val,
+				Expected: "directory",
+			})
+		}
+	}
+}
+
+// message returns the custom Message with its placeholders filled in, or else
+// the text of code in ctx.Messages (def when it has none); params are the
+// code's placeholders besides {path} and {value}.
+func (vld FilePathValidator) message(ctx *v.ValidationContext, code, def string, node *yaml.Node, path string, params ...string) string {
+	if msg := renderMessage(vld.Message, node, path, nil); msg != "" {
+		return msg
+	}
+	return ctx.Message(code, def, append([]string{"path", path, "value", node.Value}, params...)...)
+}
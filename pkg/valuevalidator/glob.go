@@ -0,0 +1,46 @@
+package valuevalidator
+
+import (
+	"path"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// GlobValidator validates that a string is a well-formed path.Match pattern,
+// e.g. "*.yaml" or "logs/[0-9]*". Malformed patterns have an unterminated or
+// empty character class ("[a-z", "[]") or a trailing backslash. Brace alternatives and
+// "**" are not special in this syntax, so they are accepted as literal text.
+type GlobValidator struct {
+	Message string // Custom error message (optional; {value} and {path} are filled in)
+}
+
+// Validate implements ValueValidator.
+func (vld GlobValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	if validGlob(node.Value) {
+		return
+	}
+	msg := renderMessage(vld.Message, node, path, nil)
+	if msg == "" {
+		msg = ctx.Message(v.MsgInvalidGlob, "invalid glob pattern", "path", path, "value", node.Value)
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      node.Value,
+		Expected: "glob pattern (*, ?, [a-z], \\ escapes)",
+	})
+}
+
+// validGlob reports whether pattern is well-formed; path.Match checks the whole
+// pattern even when the name does not match.
+func validGlob(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
@@ -0,0 +1,83 @@
+package valuevalidator
+
+import (
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// GoTemplateValidator validates that a string parses as a text/template, e.g.
+// "Hello {{ .Name }}". Only the syntax is checked; the template is not executed.
+// Functions other than the text/template builtins must be listed in Funcs
+// (e.g. "default" or "upper" for templates rendered with extra functions).
+type GoTemplateValidator struct {
+	Funcs          []string // Extra function names the template may call
+	RequireActions bool     // The value must contain at least one {{ }} action
+	ForbidActions  bool     // The value must be plain text
+	Message        string   // Custom error message (optional; {value} and {path} are filled in)
+}
+
+// Validate implements ValueValidator.
+func (vld GoTemplateValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	funcs := make(template.FuncMap, len(vld.Funcs))
+	for _, name := range vld.Funcs {
+		funcs[name] = func(...interface{}) interface{} { return nil }
+	}
+
+	var code, problem, expected, errText string
+	tmpl, err := template.New("").Funcs(funcs).Parse(node.Value)
+	switch {
+	case err != nil:
+		errText = templateError(err)
+		code, problem, expected = v.MsgInvalidTemplate, "invalid Go template: "+errText, "valid Go template"
+	case vld.RequireActions && !hasActions(tmpl):
+		code, problem, expected = v.MsgTemplateNoActions, "value must contain a template action ({{ ... }})", "Go template with actions"
+	case vld.ForbidActions && hasActions(tmpl):
+		code, problem, expected = v.MsgTemplateHasActions, "value must not contain template actions", "plain text"
+	default:
+		return
+	}
+
+	msg := renderMessage(vld.Message, node, path, nil)
+	if msg == "" {
+		msg = ctx.Message(code, problem, "path", path, "value", node.Value, "error", errText)
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      node.Value,
+		Expected: expected,
+	})
+}
+
+// templateError rewrites an unnamed template's parse error, "template: :1:
+// unclosed action", as "line 1: unclosed action" (lines count within the value).
+func templateError(err error) string {
+	rest := strings.TrimPrefix(err.Error(), "template: :")
+	if line, msg, ok := strings.Cut(rest, ": "); ok {
+		return "line " + line + ": " + msg
+	}
+	return rest
+}
+
+// hasActions reports whether a parsed template contains anything but text.
+func hasActions(tmpl *template.Template) bool {
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return false
+	}
+	for _, n := range tmpl.Tree.Root.Nodes {
+		if n.Type() != parse.NodeText {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package valuevalidator
+
+import (
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// HexColorValidator validates that a string is a hex color: #RGB or #RRGGBB,
+// and additionally #RRGGBBAA when AllowAlpha is set. Hex digits are case-insensitive.
+type HexColorValidator struct {
+	AllowAlpha bool   // Accept #RRGGBBAA
+	Message    string // Custom error message (optional)
+}
+
+// Validate implements ValueValidator.
+func (vld HexColorValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if vld.isValid(node.Value) {
+		return
+	}
+	msg := vld.Message
+	if msg == "" {
+		msg = ctx.Message(v.MsgInvalidHexColor, "invalid hex color", "path", path, "value", node.Value)
+	}
+	expected := "#RGB or #RRGGBB"
+	if vld.AllowAlpha {
+		expected = "#RGB, #RRGGBB or #RRGGBBAA"
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      node.Value,
+		Expected: expected,
+	})
+}
+
+func (vld HexColorValidator) isValid(s string) bool {
+	if len(s) < 1 || s[0] != '#' {
+		return false
+	}
+	digits := s[1:]
+	switch len(digits) {
+	case 3, 6:
+	case 8:
+		if !vld.AllowAlpha {
+			return false
+		}
+	default:
+		return false
+	}
+	for i := 0; i < len(digits); i++ {
+		c := digits[i]
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"strconv"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// IntRangeValidator validates that an integer value is within a range.
+// Unlike RangeValidator it parses and compares as int64, so bounds beyond
+// 2^53 are exact.
+type IntRangeValidator struct {
+	Min     *int64 // Minimum value (nil = no minimum)
+	Max     *int64 // Maximum value (nil = no maximum)
+	Message string // Custom message for out-of-range values (optional; {value}, {path}, {min} and {max} are filled in)
+}
+
+// Validate implements ValueValidator.
+func (vld IntRangeValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	val, err := parseYAMLInt(node.Value)
+	if err != nil {
+		ctx.AddError(v.ValidationError{
+			Level:   v.LevelError,
+			Path:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: ctx.Message(v.MsgNotNumeric, "expected integer value", "path", path, "value", node.Value),
+			Got:     node.Value,
+		})
+		return
+	}
+
+	params := map[string]string{"min": "", "max": ""}
+	if vld.Min != nil {
+		params["min"] = strconv.FormatInt(*vld.Min, 10)
+	}
+	if vld.Max != nil {
+		params["max"] = strconv.FormatInt(*vld.Max, 10)
+	}
+
+	if vld.Min != nil && val < *vld.Min {
+		msg := renderMessage(vld.Message, node, path, params)
+		if msg == "" {
+			msg = ctx.Message(v.MsgValueBelowMinimum, "value below minimum", "path", path, "value", node.Value, "min", params["min"])
+		}
+		ctx.AddError(v.ValidationError{
+			Level:    v.LevelError,
+			Path:     path,
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  msg,
+			Got:      strconv.FormatInt(val, 10),
+			Expected: fmt.Sprintf(">= %d", *vld.Min),
+			GotValue: node.Value,
+		})
+	}
+
+	if vld.Max != nil && val > *vld.Max {
+		msg := renderMessage(vld.Message, node, path, params)
+		if msg == "" {
+			msg = ctx.Message(v.MsgValueAboveMaximum, "value above maximum", "path", path, "value", node.Value, "max", params["max"])
+		}
+		ctx.AddError(v.ValidationError{
+			Level:    v.LevelError,
+			Path:     path,
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  msg,
+			Got:      strconv.FormatInt(val, 10),
+			Expected: fmt.Sprintf("<= %d", *vld.Max),
+			GotValue: node.Value,
+		})
+	}
+}
+
+// parseYAMLInt parses YAML int forms (decimal, 0x hex, 0o octal, 0b binary,
+// optional sign) as int64.
+func parseYAMLInt(val string) (int64, error) {
+	digits, base, ok := v.SplitYAMLInt(val)
+	if !ok {
+		return 0, fmt.Errorf("not an integer value")
+	}
+	return strconv.ParseInt(digits, base, 64)
+}
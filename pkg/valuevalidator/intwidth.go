@@ -0,0 +1,79 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"math/big"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// IntWidthValidator validates that an integer fits in a fixed-width integer
+// type, e.g. {Bits: 8} for uint8 (0 to 255) or {Bits: 16, Signed: true} for
+// int16 (-32768 to 32767). YAML int forms (hex, octal, binary) are accepted,
+// and values are compared exactly, so uint64 bounds work too.
+type IntWidthValidator struct {
+	Bits    int    // Width in bits (e.g. 8, 16, 32, 64)
+	Signed  bool   // Two's complement range instead of 0 to 2^Bits-1
+	Message string // Custom message for out-of-range values (optional; {value}, {path}, {min} and {max} are filled in)
+}
+
+// Validate implements ValueValidator.
+func (vld IntWidthValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	val, ok := parseYAMLBigInt(node.Value)
+	if !ok {
+		ctx.AddError(v.ValidationError{
+			Level:   v.LevelError,
+			Path:    path,
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: ctx.Message(v.MsgNotNumeric, "expected integer value", "path", path, "value", node.Value),
+			Got:     node.Value,
+		})
+		return
+	}
+
+	minVal, maxVal := vld.bounds()
+	if val.Cmp(minVal) >= 0 && val.Cmp(maxVal) <= 0 {
+		return
+	}
+	typeName := fmt.Sprintf("uint%d", vld.Bits)
+	if vld.Signed {
+		typeName = fmt.Sprintf("int%d", vld.Bits)
+	}
+	msg := renderMessage(vld.Message, node, path, map[string]string{"min": minVal.String(), "max": maxVal.String()})
+	if msg == "" {
+		msg = ctx.Message(v.MsgIntOutOfWidth, fmt.Sprintf("value %s does not fit in %s (%s to %s)", val, typeName, minVal, maxVal),
+			"path", path, "value", node.Value, "type", typeName, "min", minVal.String(), "max", maxVal.String())
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      val.String(),
+		Expected: fmt.Sprintf("%s (%s to %s)", typeName, minVal, maxVal),
+		GotValue: node.Value,
+	})
+}
+
+// bounds returns the smallest and largest value of the integer type.
+func (vld IntWidthValidator) bounds() (*big.Int, *big.Int) {
+	bits := uint(max(vld.Bits, 1))
+	if !vld.Signed {
+		maxVal := new(big.Int).Lsh(big.NewInt(1), bits)
+		return big.NewInt(0), maxVal.Sub(maxVal, big.NewInt(1))
+	}
+	half := new(big.Int).Lsh(big.NewInt(1), bits-1)
+	return new(big.Int).Neg(half), new(big.Int).Sub(half, big.NewInt(1))
+}
+
+// parseYAMLBigInt parses the YAML int forms of parseYAMLInt without a size limit.
+func parseYAMLBigInt(val string) (*big.Int, bool) {
+	digits, base, ok := v.SplitYAMLInt(val)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).SetString(digits, base)
+}
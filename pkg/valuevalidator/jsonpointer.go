@@ -0,0 +1,62 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// JSONPointerValidator validates RFC 6901 JSON Pointer syntax, e.g. "/a/b/0"
+// or "/paths/~1users~1{id}" (~1 stands for "/", ~0 for "~"). The empty string
+// points at the whole document and is valid.
+type JSONPointerValidator struct {
+	Message string // Custom error message (optional; {value} and {path} are filled in)
+}
+
+// Validate implements ValueValidator.
+func (vld JSONPointerValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	err := checkJSONPointer(node.Value)
+	if err == nil {
+		return
+	}
+	msg := renderMessage(vld.Message, node, path, nil)
+	if msg == "" {
+		msg = ctx.Message(v.MsgInvalidJSONPointer, fmt.Sprintf("invalid JSON pointer: %v", err), "path", path, "value", node.Value, "error", err.Error())
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      node.Value,
+		Expected: "JSON pointer (e.g. /a/b/0)",
+	})
+}
+
+// checkJSONPointer checks a pointer; errors name the malformed reference token.
+func checkJSONPointer(s string) error {
+	if s == "" {
+		return nil
+	}
+	if s[0] != '/' {
+		return fmt.Errorf(`must start with "/"`)
+	}
+	for _, token := range strings.Split(s[1:], "/") {
+		for i := 0; i < len(token); i++ {
+			if token[i] != '~' {
+				continue
+			}
+			if i+1 == len(token) || (token[i+1] != '0' && token[i+1] != '1') {
+				return fmt.Errorf(`token %q: "~" must be followed by 0 or 1`, token)
+			}
+			i++
+		}
+	}
+	return nil
+}
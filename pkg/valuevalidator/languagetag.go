@@ -0,0 +1,164 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// LanguageTagValidator validates that a string is a well-formed BCP 47 language tag
+// (e.g. "en", "en-US", "pt-BR", "zh-Hant-TW").
+//
+// The check is purely syntactic (RFC 5646, section 2.1): subtags are not looked up
+// in the IANA registry, and grandfathered irregular tags (e.g. "i-klingon") are rejected.
+type LanguageTagValidator struct {
+	Message string // Custom error message (optional)
+}
+
+// Validate implements ValueValidator.
+func (vld LanguageTagValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	err := parseLanguageTag(node.Value)
+	if err == nil {
+		return
+	}
+	msg := vld.Message
+	if msg == "" {
+		msg = ctx.Message(v.MsgInvalidLanguageTag, fmt.Sprintf("invalid language tag: %v", err), "path", path, "value", node.Value, "error", err.Error())
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      node.Value,
+		Expected: "BCP 47 language tag",
+	})
+}
+
+// parseLanguageTag checks the langtag / privateuse productions of RFC 5646.
+func parseLanguageTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("empty tag")
+	}
+	subtags := strings.Split(tag, "-")
+	for _, s := range subtags {
+		if s == "" {
+			return fmt.Errorf("empty subtag")
+		}
+		if !isAlnum(s) {
+			return fmt.Errorf("subtag %q contains invalid characters", s)
+		}
+	}
+
+	// privateuse only: x-whatever
+	if strings.EqualFold(subtags[0], "x") {
+		return parsePrivateUse(subtags)
+	}
+
+	i := 0
+
+	// language
+	lang := subtags[i]
+	if !isAlpha(lang) || len(lang) < 2 || len(lang) > 8 {
+		return fmt.Errorf("invalid primary language subtag %q", lang)
+	}
+	i++
+
+	// extlang: up to three 3ALPHA subtags, only after a 2-3 letter language
+	if len(lang) <= 3 {
+		for n := 0; n < 3 && i < len(subtags) && len(subtags[i]) == 3 && isAlpha(subtags[i]); n++ {
+			i++
+		}
+	}
+
+	// script
+	if i < len(subtags) && len(subtags[i]) == 4 && isAlpha(subtags[i]) {
+		i++
+	}
+
+	// region
+	if i < len(subtags) && ((len(subtags[i]) == 2 && isAlpha(subtags[i])) ||
+		(len(subtags[i]) == 3 && isDigits(subtags[i]))) {
+		i++
+	}
+
+	// variants
+	for i < len(subtags) && isVariant(subtags[i]) {
+		i++
+	}
+
+	// extensions
+	for i < len(subtags) && len(subtags[i]) == 1 && !strings.EqualFold(subtags[i], "x") {
+		singleton := subtags[i]
+		i++
+		n := 0
+		for i < len(subtags) && len(subtags[i]) >= 2 && len(subtags[i]) <= 8 {
+			i++
+			n++
+		}
+		if n == 0 {
+			return fmt.Errorf("extension %q has no subtags", singleton)
+		}
+	}
+
+	// privateuse
+	if i < len(subtags) && strings.EqualFold(subtags[i], "x") {
+		return parsePrivateUse(subtags[i:])
+	}
+
+	if i < len(subtags) {
+		return fmt.Errorf("unexpected subtag %q", subtags[i])
+	}
+	return nil
+}
+
+func parsePrivateUse(subtags []string) error {
+	if len(subtags) < 2 {
+		return fmt.Errorf("private use section has no subtags")
+	}
+	for _, s := range subtags[1:] {
+		if len(s) > 8 {
+			return fmt.Errorf("private use subtag %q is too long", s)
+		}
+	}
+	return nil
+}
+
+func isVariant(s string) bool {
+	if len(s) >= 5 && len(s) <= 8 {
+		return true
+	}
+	return len(s) == 4 && s[0] >= '0' && s[0] <= '9'
+}
+
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package valuevalidator
 
 import (
 	"fmt"
+	"strconv"
 	"unicode/utf8"
 
 	v "github.com/yakwilikk/go-yamlvalidator"
@@ -10,8 +11,9 @@ import (
 
 // LengthValidator validates the length of a string, sequence, or map.
 type LengthValidator struct {
-	Min *int // Minimum length (nil = no minimum)
-	Max *int // Maximum length (nil = no maximum)
+	Min     *int   // Minimum length (nil = no minimum)
+	Max     *int   // Maximum length (nil = no maximum)
+	Message string // Custom message for out-of-range lengths (optional; {value}, {path}, {min} and {max} are filled in)
 }
 
 // Validate implements ValueValidator.
@@ -26,25 +28,41 @@ func (vld LengthValidator) Validate(node *yaml.Node, path string, ctx *v.Validat
 		length = len(node.Content) / 2
 	}
 
+	params := map[string]string{"min": "", "max": ""}
+	if vld.Min != nil {
+		params["min"] = strconv.Itoa(*vld.Min)
+	}
+	if vld.Max != nil {
+		params["max"] = strconv.Itoa(*vld.Max)
+	}
+
 	if vld.Min != nil && length < *vld.Min {
+		msg := renderMessage(vld.Message, node, path, params)
+		if msg == "" {
+			msg = ctx.Message(v.MsgLengthBelowMin, "length below minimum", "path", path, "got", strconv.Itoa(length), "min", params["min"])
+		}
 		ctx.AddError(v.ValidationError{
 			Level:    v.LevelError,
 			Path:     path,
 			Line:     node.Line,
 			Column:   node.Column,
-			Message:  "length below minimum",
+			Message:  msg,
 			Got:      fmt.Sprintf("%d", length),
 			Expected: fmt.Sprintf(">= %d", *vld.Min),
 		})
 	}
 
 	if vld.Max != nil && length > *vld.Max {
+		msg := renderMessage(vld.Message, node, path, params)
+		if msg == "" {
+			msg = ctx.Message(v.MsgLengthAboveMax, "length above maximum", "path", path, "got", strconv.Itoa(length), "max", params["max"])
+		}
 		ctx.AddError(v.ValidationError{
 			Level:    v.LevelError,
 			Path:     path,
 			Line:     node.Line,
 			Column:   node.Column,
-			Message:  "length above maximum",
+			Message:  msg,
 			Got:      fmt.Sprintf("%d", length),
 			Expected: fmt.Sprintf("<= %d", *vld.Max),
 		})
@@ -0,0 +1,22 @@
+package valuevalidator
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// renderMessage fills the placeholders of a custom Message: {value} is the
+// node's value and {path} its path; params supplies validator-specific
+// placeholders such as {min} and {max}. Unknown placeholders are left as they
+// are, so messages without placeholders are returned unchanged.
+func renderMessage(msg string, node *yaml.Node, path string, params map[string]string) string {
+	if !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := []string{"{value}", node.Value, "{path}", path}
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
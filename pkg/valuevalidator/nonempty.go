@@ -24,7 +24,7 @@ func (NonEmptyValidator) Validate(node *yaml.Node, path string, ctx *v.Validatio
 			Path:    path,
 			Line:    node.Line,
 			Column:  node.Column,
-			Message: "value cannot be empty",
+			Message: ctx.Message(v.MsgEmptyValue, "value cannot be empty", "path", path, "expected", "non-empty value"),
 		})
 	}
 }
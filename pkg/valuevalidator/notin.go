@@ -0,0 +1,41 @@
+package valuevalidator
+
+import (
+	"fmt"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// NotInValidator validates that a value is not one of the denied values,
+// e.g. banned images or reserved names. It is the inverse of EnumValidator.
+type NotInValidator struct {
+	Denied  []string
+	Message string // Custom error message (optional; {value} and {path} are filled in)
+}
+
+// Validate implements ValueValidator.
+func (vld NotInValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	for _, denied := range vld.Denied {
+		if node.Value != denied {
+			continue
+		}
+		msg := renderMessage(vld.Message, node, path, nil)
+		if msg == "" {
+			msg = ctx.Message(v.MsgValueDenied, fmt.Sprintf("value %q is not allowed", node.Value), "path", path, "value", node.Value)
+		}
+		ctx.AddError(v.ValidationError{
+			Level:    v.LevelError,
+			Path:     path,
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  msg,
+			Got:      node.Value,
+			Expected: "a value not in the deny list",
+		})
+		return
+	}
+}
@@ -14,8 +14,7 @@ type OneOfTypeValidator struct {
 
 // Validate implements ValueValidator.
 func (vld OneOfTypeValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
-	validator := &v.Validator{}                       // reuse inferType
-	actual := validator.InferTypeForPublic(node, ctx) // helper we will expose
+	actual := ctx.InferType(node)
 
 	for _, t := range vld.Types {
 		if actual == t || (t == v.TypeFloat && actual == v.TypeInt) {
@@ -33,7 +32,7 @@ func (vld OneOfTypeValidator) Validate(node *yaml.Node, path string, ctx *v.Vali
 		Path:     path,
 		Line:     node.Line,
 		Column:   node.Column,
-		Message:  "type not allowed",
+		Message:  ctx.Message(v.MsgTypeMismatch, "type not allowed", "path", path, "got", actual.String(), "expected", fmt.Sprintf("one of %v", typeNames)),
 		Got:      actual.String(),
 		Expected: fmt.Sprintf("one of %v", typeNames),
 	})
@@ -0,0 +1,61 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// PercentValidator validates a percentage: a bare number (80, 12.5) or, with
+// AllowSuffix, a string with a trailing percent sign ("80%"). The number must be
+// within [0, Max].
+type PercentValidator struct {
+	AllowSuffix bool     // Accept "80%" in addition to bare numbers
+	Max         *float64 // Upper bound (nil = 100)
+}
+
+// Validate implements ValueValidator.
+func (vld PercentValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	maxVal := 100.0
+	if vld.Max != nil {
+		maxVal = *vld.Max
+	}
+
+	raw := strings.TrimSpace(node.Value)
+	number, hasSuffix := strings.CutSuffix(raw, "%")
+	if hasSuffix && !vld.AllowSuffix {
+		vld.report(node, path, ctx, ctx.Message(v.MsgPercentSuffix, "percent suffix is not allowed", "path", path, "value", node.Value), "number without %")
+		return
+	}
+
+	val, err := parseYAMLNumber(&yaml.Node{Value: strings.TrimSpace(number)})
+	if err != nil || math.IsNaN(val) {
+		expected := "number"
+		if vld.AllowSuffix {
+			expected = "number, optionally followed by %"
+		}
+		vld.report(node, path, ctx, ctx.Message(v.MsgNotPercentage, "expected percentage", "path", path, "value", node.Value), expected)
+		return
+	}
+
+	if val < 0 || val > maxVal {
+		vld.report(node, path, ctx, ctx.Message(v.MsgPercentOutOfRange, "percentage out of range", "path", path, "value", node.Value, "max", strconv.FormatFloat(maxVal, 'g', -1, 64)),
+			fmt.Sprintf("0..%v", maxVal))
+	}
+}
+
+func (vld PercentValidator) report(node *yaml.Node, path string, ctx *v.ValidationContext, msg, expected string) {
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      node.Value,
+		Expected: expected,
+	})
+}
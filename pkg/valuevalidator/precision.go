@@ -0,0 +1,90 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// PrecisionValidator limits the number of decimal places of a number, e.g.
+// MaxDecimals: 2 for monetary amounts.
+//
+// The raw scalar text is inspected, so float rounding never hides extra digits.
+// Trailing zeros are not counted ("1.50" has one decimal place) and scientific
+// notation is normalized ("1.25e1" is 12.5, one decimal place).
+type PrecisionValidator struct {
+	MaxDecimals int
+	Message     string // Custom error message (optional)
+}
+
+// Validate implements ValueValidator.
+func (vld PrecisionValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	decimals, err := countDecimals(node.Value)
+	if err != nil {
+		ctx.AddError(v.ValidationError{
+			Level:    v.LevelError,
+			Path:     path,
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  ctx.Message(v.MsgNotDecimal, fmt.Sprintf("expected decimal number: %v", err), "path", path, "value", node.Value, "error", err.Error()),
+			Got:      node.Value,
+			Expected: "decimal number",
+		})
+		return
+	}
+	if decimals <= vld.MaxDecimals {
+		return
+	}
+	msg := vld.Message
+	if msg == "" {
+		msg = ctx.Message(v.MsgTooManyDecimals, "too many decimal places",
+			"path", path, "value", node.Value, "got", strconv.Itoa(decimals), "max", strconv.Itoa(vld.MaxDecimals))
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      fmt.Sprintf("%s (%d decimal places)", node.Value, decimals),
+		Expected: fmt.Sprintf("at most %d decimal places", vld.MaxDecimals),
+	})
+}
+
+// countDecimals returns the number of significant digits after the decimal
+// point of a plain or scientific decimal literal.
+func countDecimals(val string) (int, error) {
+	s := strings.TrimLeft(val, "+-")
+	if len(val)-len(s) > 1 {
+		return 0, fmt.Errorf("invalid sign")
+	}
+
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return 0, fmt.Errorf("invalid exponent")
+		}
+		exp = e
+	}
+
+	intPart, frac, _ := strings.Cut(mantissa, ".")
+	if intPart == "" && frac == "" {
+		return 0, fmt.Errorf("no digits")
+	}
+	if !isDigits(intPart) || !isDigits(frac) {
+		return 0, fmt.Errorf("unexpected characters")
+	}
+
+	digits := intPart + frac
+	trailingZeros := len(digits) - len(strings.TrimRight(digits, "0"))
+	decimals := len(frac) - trailingZeros - exp
+	if decimals < 0 {
+		decimals = 0
+	}
+	return decimals, nil
+}
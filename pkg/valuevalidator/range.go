@@ -12,8 +12,9 @@ import (
 
 // RangeValidator validates that a numeric value is within a range.
 type RangeValidator struct {
-	Min *float64 // Minimum value (nil = no minimum)
-	Max *float64 // Maximum value (nil = no maximum)
+	Min     *float64 // Minimum value (nil = no minimum)
+	Max     *float64 // Maximum value (nil = no maximum)
+	Message string   // Custom message for out-of-range values (optional; {value}, {path}, {min} and {max} are filled in)
 }
 
 // Validate implements ValueValidator.
@@ -25,37 +26,61 @@ func (vld RangeValidator) Validate(node *yaml.Node, path string, ctx *v.Validati
 			Path:    path,
 			Line:    node.Line,
 			Column:  node.Column,
-			Message: "expected numeric value",
+			Message: ctx.Message(v.MsgNotNumeric, "expected numeric value", "path", path, "value", node.Value),
 			Got:     node.Value,
 		})
 		return
 	}
 
+	params := map[string]string{"min": "", "max": ""}
+	if vld.Min != nil {
+		params["min"] = fmt.Sprintf("%v", *vld.Min)
+	}
+	if vld.Max != nil {
+		params["max"] = fmt.Sprintf("%v", *vld.Max)
+	}
+
 	if vld.Min != nil && val < *vld.Min {
+		msg := renderMessage(vld.Message, node, path, params)
+		if msg == "" {
+			msg = ctx.Message(v.MsgValueBelowMinimum, "value below minimum", "path", path, "value", node.Value, "min", params["min"])
+		}
 		ctx.AddError(v.ValidationError{
 			Level:    v.LevelError,
 			Path:     path,
 			Line:     node.Line,
 			Column:   node.Column,
-			Message:  "value below minimum",
+			Message:  msg,
 			Got:      fmt.Sprintf("%v", val),
 			Expected: fmt.Sprintf(">= %v", *vld.Min),
+			GotValue: node.Value,
 		})
 	}
 
 	if vld.Max != nil && val > *vld.Max {
+		msg := renderMessage(vld.Message, node, path, params)
+		if msg == "" {
+			msg = ctx.Message(v.MsgValueAboveMaximum, "value above maximum", "path", path, "value", node.Value, "max", params["max"])
+		}
 		ctx.AddError(v.ValidationError{
 			Level:    v.LevelError,
 			Path:     path,
 			Line:     node.Line,
 			Column:   node.Column,
-			Message:  "value above maximum",
+			Message:  msg,
 			Got:      fmt.Sprintf("%v", val),
 			Expected: fmt.Sprintf("<= %v", *vld.Max),
+			GotValue: node.Value,
 		})
 	}
 }
 
+// ParseYAMLNumber parses a scalar node as a number, accepting YAML int forms
+// (hex, octal 0o, binary) and special floats (.inf, .nan).
+func ParseYAMLNumber(node *yaml.Node) (float64, error) {
+	return parseYAMLNumber(node)
+}
+
 func parseYAMLNumber(node *yaml.Node) (float64, error) {
 	val := node.Value
 	lower := strings.ToLower(val)
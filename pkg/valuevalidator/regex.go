@@ -11,7 +11,7 @@ import (
 // RegexValidator validates that a string matches a pattern.
 type RegexValidator struct {
 	Pattern *regexp.Regexp
-	Message string // Custom error message (optional)
+	Message string // Custom error message (optional; {value} and {path} are filled in)
 }
 
 // Validate implements ValueValidator.
@@ -19,9 +19,10 @@ func (vld RegexValidator) Validate(node *yaml.Node, path string, ctx *v.Validati
 	if vld.Pattern.MatchString(node.Value) {
 		return
 	}
-	msg := vld.Message
+	msg := renderMessage(vld.Message, node, path, nil)
 	if msg == "" {
-		msg = fmt.Sprintf("value does not match pattern %s", vld.Pattern.String())
+		msg = ctx.Message(v.MsgPatternMismatch, fmt.Sprintf("value does not match pattern %s", vld.Pattern.String()),
+			"path", path, "value", node.Value, "pattern", vld.Pattern.String())
 	}
 	ctx.AddError(v.ValidationError{
 		Level:   v.LevelError,
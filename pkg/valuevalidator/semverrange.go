@@ -0,0 +1,178 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// SemVerRangeValidator validates that a string is a well-formed semantic version
+// constraint in the npm range syntax, e.g. "^1.2", "~1.2.3", ">=1.2.0 <2.0.0",
+// "1.2.x", "1.0.0 - 2.0.0" or "^1.0 || ^2.0".
+//
+// Only the syntax is checked: comparators are space-separated, alternatives are
+// joined with "||", and versions may be partial, use x/X/* wildcards, carry a
+// "v" prefix and, when complete, a pre-release and build suffix. Whether the
+// range can match any version is not checked.
+type SemVerRangeValidator struct {
+	Message string // Custom error message (optional; {value} and {path} are filled in)
+}
+
+// Validate implements ValueValidator.
+func (vld SemVerRangeValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	err := parseSemVerRange(node.Value)
+	if err == nil {
+		return
+	}
+	msg := renderMessage(vld.Message, node, path, nil)
+	if msg == "" {
+		msg = ctx.Message(v.MsgInvalidSemVerRange, fmt.Sprintf("invalid semver range: %v", err), "path", path, "value", node.Value, "error", err.Error())
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  msg,
+		Got:      node.Value,
+		Expected: "semver range (e.g. ^1.2.0 or >=1.2.0 <2.0.0)",
+	})
+}
+
+// parseSemVerRange checks a range set; errors name the part that cannot be parsed.
+func parseSemVerRange(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return fmt.Errorf("empty range")
+	}
+	for _, alt := range strings.Split(s, "||") {
+		tokens := strings.Fields(alt)
+		if len(tokens) == 0 {
+			return fmt.Errorf("empty alternative in %q", s)
+		}
+		if len(tokens) == 3 && tokens[1] == "-" {
+			// Hyphen range: both ends are plain (partial) versions
+			for _, end := range []string{tokens[0], tokens[2]} {
+				if err := parsePartialVersion(end); err != nil {
+					return fmt.Errorf("cannot parse %q: %v", end, err)
+				}
+			}
+			continue
+		}
+		for i := 0; i < len(tokens); i++ {
+			comparator := tokens[i]
+			// An operator may be separated from its version: ">= 1.2.0"
+			if isSemVerOperator(comparator) && i+1 < len(tokens) {
+				i++
+				comparator += tokens[i]
+			}
+			if err := parseComparator(comparator); err != nil {
+				return fmt.Errorf("cannot parse %q: %v", comparator, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isSemVerOperator(s string) bool {
+	switch s {
+	case "<", "<=", ">", ">=", "=", "~", "^":
+		return true
+	}
+	return false
+}
+
+// parseComparator checks an optionally prefixed version: "<1.2", "~1.2.3", "^0.x".
+func parseComparator(s string) error {
+	version := strings.TrimLeft(s, "<>=~^")
+	if op := s[:len(s)-len(version)]; op != "" && !isSemVerOperator(op) {
+		return fmt.Errorf("unknown operator %q", op)
+	}
+	if version == "" {
+		return fmt.Errorf("operator without a version")
+	}
+	return parsePartialVersion(version)
+}
+
+// parsePartialVersion checks "1", "1.2", "1.x", "*" and full versions with an
+// optional pre-release and build suffix ("1.2.3-rc.1+build.5").
+func parsePartialVersion(s string) error {
+	version := strings.TrimPrefix(s, "v")
+	core, suffix := version, ""
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		core, suffix = version[:i], version[i:]
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return fmt.Errorf("more than three version numbers")
+	}
+	wildcard := false
+	for _, part := range parts {
+		switch {
+		case part == "x" || part == "X" || part == "*":
+			wildcard = true
+		case wildcard:
+			return fmt.Errorf("number %q after a wildcard", part)
+		case !isVersionNumber(part):
+			return fmt.Errorf("%q is not a version number", part)
+		}
+	}
+	if suffix == "" {
+		return nil
+	}
+	if len(parts) < 3 || wildcard {
+		return fmt.Errorf("pre-release or build suffix needs a full version")
+	}
+	pre, build := suffix, ""
+	if i := strings.Index(suffix, "+"); i >= 0 {
+		pre, build = suffix[:i], suffix[i:]
+	}
+	if pre != "" && !validIdentifiers(pre[1:], true) {
+		return fmt.Errorf("invalid pre-release %q", pre[1:])
+	}
+	if build != "" && !validIdentifiers(build[1:], false) {
+		return fmt.Errorf("invalid build metadata %q", build[1:])
+	}
+	return nil
+}
+
+// isVersionNumber reports a non-negative integer without leading zeros.
+func isVersionNumber(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validIdentifiers checks dot-separated [0-9A-Za-z-] identifiers; numeric
+// pre-release identifiers must not have leading zeros.
+func validIdentifiers(s string, preRelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		numeric := true
+		for _, r := range id {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				numeric = false
+			default:
+				return false
+			}
+		}
+		if preRelease && numeric && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
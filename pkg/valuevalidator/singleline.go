@@ -0,0 +1,69 @@
+package valuevalidator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v "github.com/yakwilikk/go-yamlvalidator"
+	"gopkg.in/yaml.v3"
+)
+
+// SingleLineValidator validates that a scalar contains no line break (\n or \r),
+// for values that end up in headers or single-line logs. Note that literal and
+// folded block scalars (| and >) keep a final line break unless written with
+// the strip indicator (|- or >-).
+//
+// For literal block scalars the error points at the value's first content
+// line, where the first line break is; for other styles, whose line breaks
+// may be folded or escaped, at the value itself.
+type SingleLineValidator struct {
+	Message string // Custom error message (optional)
+}
+
+// Validate implements ValueValidator.
+func (vld SingleLineValidator) Validate(node *yaml.Node, path string, ctx *v.ValidationContext) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	i := strings.IndexAny(node.Value, "\r\n")
+	if i < 0 {
+		return
+	}
+
+	line, column := node.Line, node.Column
+	if node.Style&yaml.LiteralStyle != 0 {
+		// The first line break ends the first content line, right after the "|" header.
+		line = node.Line + 1
+		column = contentColumn(ctx.SourceLines, line)
+	}
+	msg := vld.Message
+	if msg == "" {
+		offset := len([]rune(node.Value[:i]))
+		msg = ctx.Message(v.MsgLineBreak, fmt.Sprintf("line break at offset %d, value must be a single line", offset),
+			"path", path, "value", node.Value, "offset", strconv.Itoa(offset))
+	}
+	ctx.AddError(v.ValidationError{
+		Level:    v.LevelError,
+		Path:     path,
+		Line:     line,
+		Column:   column,
+		Message:  msg,
+		Got:      fmt.Sprintf("%q", node.Value),
+		Expected: "a single line",
+	})
+}
+
+// contentColumn returns the column where the text of a source line starts,
+// or 1 when the line is blank or not known.
+func contentColumn(lines []string, line int) int {
+	if line < 1 || line > len(lines) {
+		return 1
+	}
+	text := lines[line-1]
+	indent := len(text) - len(strings.TrimLeft(text, " "))
+	if indent == len(text) {
+		return 1
+	}
+	return indent + 1
+}
@@ -31,7 +31,7 @@ func (vld URLValidator) Validate(node *yaml.Node, path string, ctx *v.Validation
 			Path:    path,
 			Line:    node.Line,
 			Column:  node.Column,
-			Message: "URL must include scheme",
+			Message: ctx.Message(v.MsgURLSchemeMissing, "URL must include scheme", "path", path, "value", val),
 			Got:     val,
 		})
 		return
@@ -51,7 +51,7 @@ func (vld URLValidator) Validate(node *yaml.Node, path string, ctx *v.Validation
 				Path:     path,
 				Line:     node.Line,
 				Column:   node.Column,
-				Message:  "URL scheme not allowed",
+				Message:  ctx.Message(v.MsgURLSchemeNotAllowed, "URL scheme not allowed", "path", path, "value", val, "scheme", scheme),
 				Got:      scheme,
 				Expected: fmt.Sprintf("one of %v", vld.AllowedSchemes),
 			})
@@ -0,0 +1,206 @@
+package yamlvalidator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Schema Self-Validation
+// ============================================================================
+
+// ValidateSchema checks a schema for authoring mistakes and returns them as
+// errors whose Path is the schema path of the offending field (e.g. "server.port";
+// "[]" stands for sequence items, "*" for additional properties and "*(string)"
+// for AdditionalPropertiesByType entries).
+//
+// It checks that every Default is type-compatible with its field's Type and
+// passes the field's Validators, that Aliases do not clash with other keys,
+// that AtLeastNOf and AtMostNOf counts are between 1 and the number of keys
+// and name allowed keys, and that CaseInsensitiveKeys schemas have no
+// AllowedKeys differing only by case and NumericKeys schemas none spelling
+// the same integer.
+// Line and Column are always 0.
+func ValidateSchema(schema *FieldSchema) []ValidationError {
+	var errs []ValidationError
+	checkSchemaNode(schema, "", map[*FieldSchema]bool{}, &errs)
+	return errs
+}
+
+func checkSchemaNode(schema *FieldSchema, path string, seen map[*FieldSchema]bool, errs *[]ValidationError) {
+	if schema == nil || seen[schema] {
+		return
+	}
+	seen[schema] = true
+
+	if schema.Default != nil {
+		checkSchemaDefault(schema, path, errs)
+	}
+	checkKeyCounts("AtLeastNOf", schema.AtLeastNOf, schema, path, errs)
+	checkKeyCounts("AtMostNOf", schema.AtMostNOf, schema, path, errs)
+
+	keys := make([]string, 0, len(schema.AllowedKeys))
+	for key := range schema.AllowedKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	checkAliasCollisions(schema, keys, path, errs)
+	if schema.CaseInsensitiveKeys {
+		checkCaseCollisions(keys, path, errs)
+	}
+	if schema.NumericKeys {
+		checkNumericCollisions(keys, path, errs)
+	}
+	for _, key := range keys {
+		checkSchemaNode(schema.AllowedKeys[key], joinPath(path, key), seen, errs)
+	}
+	checkSchemaNode(schema.AdditionalProperties, joinSelector(path, "*"), seen, errs)
+	types := make([]NodeType, 0, len(schema.AdditionalPropertiesByType))
+	for t := range schema.AdditionalPropertiesByType {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	for _, t := range types {
+		checkSchemaNode(schema.AdditionalPropertiesByType[t], joinPath(path, "*("+t.String()+")"), seen, errs)
+	}
+	checkSchemaNode(schema.ItemSchema, path+"[]", seen, errs)
+	for _, rule := range schema.Conditions {
+		// A then-schema describes the same mapping, so it shares the path.
+		checkSchemaNode(rule.ThenSchema, path, seen, errs)
+	}
+}
+
+// checkSchemaDefault validates the default value as if it appeared in a document.
+func checkSchemaDefault(schema *FieldSchema, path string, errs *[]ValidationError) {
+	node := &yaml.Node{}
+	if err := node.Encode(schema.Default); err != nil {
+		*errs = append(*errs, ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(path),
+			Message: fmt.Sprintf("default value cannot be represented as YAML: %v", err),
+			Got:     fmt.Sprint(schema.Default),
+		})
+		return
+	}
+
+	ctx := NewValidationContext()
+	(&Validator{schema: schema}).validateNode(node, schema, path, ctx)
+	for _, err := range ctx.Collector().Errors() {
+		err.Line, err.Column = 0, 0
+		err.Message = "invalid default: " + err.Message
+		*errs = append(*errs, err)
+	}
+}
+
+// checkKeyCounts reports KeyCount rules whose N cannot be met or is trivial,
+// and rule keys the mapping cannot have.
+func checkKeyCounts(name string, rules []KeyCount, schema *FieldSchema, path string, errs *[]ValidationError) {
+	for _, rule := range rules {
+		if rule.N < 1 || rule.N > len(rule.Keys) {
+			*errs = append(*errs, ValidationError{
+				Level:   LevelError,
+				Path:    cleanPath(path),
+				Message: fmt.Sprintf("%s: N must be between 1 and %d (the number of keys), got %d", name, len(rule.Keys), rule.N),
+			})
+		}
+		for _, key := range rule.Keys {
+			if msg := unknownRuleKey(schema, key); msg != "" {
+				*errs = append(*errs, ValidationError{
+					Level:   LevelError,
+					Path:    cleanPath(path),
+					Message: fmt.Sprintf("%s: %s", name, msg),
+				})
+			}
+		}
+	}
+}
+
+// unknownRuleKey describes why a rule key can never be found in the mapping,
+// or returns "" when it can. Keys are counted under their AllowedKeys name, so
+// an alias never counts; mappings with AdditionalProperties(ByType) or
+// UnknownKeyIgnore, or without AllowedKeys, may hold any key.
+func unknownRuleKey(schema *FieldSchema, key string) string {
+	if schema.AllowedKeys[key] != nil {
+		return ""
+	}
+	for allowed, fieldSchema := range schema.AllowedKeys {
+		for _, alias := range fieldSchema.Aliases {
+			if alias == key {
+				return fmt.Sprintf("key %q is an alias of %q, name the allowed key", key, allowed)
+			}
+		}
+	}
+	if len(schema.AllowedKeys) == 0 || schema.AdditionalProperties != nil ||
+		len(schema.AdditionalPropertiesByType) > 0 || schema.UnknownKeyPolicy == UnknownKeyIgnore {
+		return ""
+	}
+	return fmt.Sprintf("key %q is not an allowed key", key)
+}
+
+// checkCaseCollisions reports allowed keys that are ambiguous under
+// case-insensitive matching. keys must be sorted.
+func checkCaseCollisions(keys []string, path string, errs *[]ValidationError) {
+	seen := make(map[string]string, len(keys))
+	for _, key := range keys {
+		folded := strings.ToLower(key)
+		if other, ok := seen[folded]; ok {
+			*errs = append(*errs, ValidationError{
+				Level:   LevelError,
+				Path:    cleanPath(path),
+				Message: fmt.Sprintf("allowed keys %q and %q differ only by case, but CaseInsensitiveKeys is set", other, key),
+			})
+			continue
+		}
+		seen[folded] = key
+	}
+}
+
+// checkNumericCollisions reports allowed keys that are the same integer under
+// NumericKeys matching. keys must be sorted.
+func checkNumericCollisions(keys []string, path string, errs *[]ValidationError) {
+	seen := make(map[string]string, len(keys))
+	for _, key := range keys {
+		n, ok := normalizeIntKey(key)
+		if !ok {
+			continue
+		}
+		if other, ok := seen[n]; ok {
+			*errs = append(*errs, ValidationError{
+				Level:   LevelError,
+				Path:    cleanPath(path),
+				Message: fmt.Sprintf("allowed keys %q and %q are the same integer, but NumericKeys is set", other, key),
+			})
+			continue
+		}
+		seen[n] = key
+	}
+}
+
+// checkAliasCollisions reports aliases that are also allowed keys or aliases of
+// another field. keys must be the sorted AllowedKeys names.
+func checkAliasCollisions(schema *FieldSchema, keys []string, path string, errs *[]ValidationError) {
+	owner := make(map[string]string)
+	for _, key := range keys {
+		for _, alias := range schema.AllowedKeys[key].Aliases {
+			switch other, ok := owner[alias]; {
+			case schema.AllowedKeys[alias] != nil:
+				*errs = append(*errs, ValidationError{
+					Level:   LevelError,
+					Path:    cleanPath(joinPath(path, key)),
+					Message: fmt.Sprintf("alias %q is also an allowed key", alias),
+				})
+			case ok:
+				*errs = append(*errs, ValidationError{
+					Level:   LevelError,
+					Path:    cleanPath(joinPath(path, key)),
+					Message: fmt.Sprintf("alias %q is already an alias of %q", alias, other),
+				})
+			default:
+				owner[alias] = key
+			}
+		}
+	}
+}
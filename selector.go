@@ -0,0 +1,244 @@
+package yamlvalidator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Path Selectors
+// ============================================================================
+
+// PathMatch is a node found by SelectPath.
+type PathMatch struct {
+	Path  string     // Concrete path of the node, with wildcards resolved
+	Key   *yaml.Node // Mapping key node (nil for sequence items and the root)
+	Value *yaml.Node // The node itself (aliases resolved)
+}
+
+// pathSelector is a selector parsed once by a ValidatorOption.
+type pathSelector struct {
+	text  string
+	steps []pathStep
+}
+
+// parseSelectors parses selectors for an option, recording the first error in
+// v.err and dropping invalid selectors.
+func (v *Validator) parseSelectors(selectors []string) []pathSelector {
+	parsed := make([]pathSelector, 0, len(selectors))
+	for _, selector := range selectors {
+		steps, err := parseSelector(selector)
+		if err != nil {
+			if v.err == nil {
+				v.err = err
+			}
+			continue
+		}
+		parsed = append(parsed, pathSelector{text: selector, steps: steps})
+	}
+	return parsed
+}
+
+// pathStep is one segment of a parsed selector.
+type pathStep struct {
+	key     string // Mapping key, "*" for any key (when !isIndex)
+	quoted  bool   // Key written as ["key"]; "*" is then a literal key
+	index   int    // Sequence index, -1 for any item (when isIndex)
+	isIndex bool
+}
+
+// JoinPath appends a mapping key to a path in the notation of error paths and
+// selectors, e.g. "spec" and "image" give "spec.image". Keys that dotted
+// notation would misread (empty, "*", or containing '.', '[', ']' or '"') are
+// written as a quoted index: "metadata" and "my.key" give metadata["my.key"].
+// Validators that report paths below the node they validate should use it.
+func JoinPath(base, key string) string {
+	return joinPath(base, key)
+}
+
+// SelectPath returns the nodes below root matching selector, in document order.
+//
+// Selectors use the same syntax as error paths: dotted mapping keys and [N]
+// sequence indexes, e.g. "spec.containers[0].image". A key containing dots or
+// brackets is written as a quoted index, as JoinPath does: metadata["my.key"]
+// (Go string syntax inside the brackets). "*" matches any mapping key and
+// "[*]" any sequence item; ["*"] is a key named "*". Keys inherited via merge
+// keys are found too. An empty selector matches root.
+func SelectPath(root *yaml.Node, selector string) ([]PathMatch, error) {
+	steps, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return selectSteps(root, steps, ""), nil
+}
+
+func parseSelector(selector string) ([]pathStep, error) {
+	var steps []pathStep
+	s := selector
+	for s != "" {
+		end := strings.IndexAny(s, ".[")
+		if end < 0 {
+			end = len(s)
+		}
+		key := s[:end]
+		s = s[end:]
+		if key != "" {
+			steps = append(steps, pathStep{key: key})
+		} else if len(steps) > 0 || strings.HasPrefix(s, ".") {
+			return nil, fmt.Errorf("invalid selector %q: empty key", selector)
+		}
+
+		for strings.HasPrefix(s, "[") {
+			if strings.HasPrefix(s, `["`) {
+				quoted, err := strconv.QuotedPrefix(s[1:])
+				if err != nil || !strings.HasPrefix(s[1+len(quoted):], "]") {
+					return nil, fmt.Errorf("invalid selector %q: bad quoted key", selector)
+				}
+				key, _ := strconv.Unquote(quoted)
+				steps = append(steps, pathStep{key: key, quoted: true})
+				s = s[len(quoted)+2:]
+				continue
+			}
+			closing := strings.IndexByte(s, ']')
+			if closing < 0 {
+				return nil, fmt.Errorf("invalid selector %q: missing ]", selector)
+			}
+			idx := s[1:closing]
+			s = s[closing+1:]
+			if idx == "*" {
+				steps = append(steps, pathStep{index: -1, isIndex: true})
+				continue
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid selector %q: bad index %q", selector, idx)
+			}
+			steps = append(steps, pathStep{index: n, isIndex: true})
+		}
+
+		if s == "" {
+			break
+		}
+		if s[0] != '.' || len(s) == 1 {
+			return nil, fmt.Errorf("invalid selector %q: empty key", selector)
+		}
+		s = s[1:]
+	}
+	return steps, nil
+}
+
+func selectSteps(root *yaml.Node, steps []pathStep, path string) []PathMatch {
+	current := []PathMatch{{Path: path, Value: resolveAlias(root)}}
+	for _, step := range steps {
+		var next []PathMatch
+		for _, m := range current {
+			next = append(next, selectStep(m, step)...)
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+func selectStep(m PathMatch, step pathStep) []PathMatch {
+	node := m.Value
+	if node == nil {
+		return nil
+	}
+	var out []PathMatch
+	switch {
+	case step.isIndex && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			if step.index == -1 || step.index == i {
+				out = append(out, PathMatch{
+					Path:  fmt.Sprintf("%s[%d]", m.Path, i),
+					Value: resolveAlias(item),
+				})
+			}
+		}
+	case !step.isIndex && node.Kind == yaml.MappingNode:
+		for _, kv := range expandMappingWithMerges(node) {
+			if (step.key == "*" && !step.quoted) || step.key == kv.key.Value {
+				out = append(out, PathMatch{
+					Path:  joinPath(m.Path, kv.key.Value),
+					Key:   kv.key,
+					Value: resolveAlias(kv.value),
+				})
+			}
+		}
+	}
+	return out
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.AliasNode {
+		return node.Alias
+	}
+	return node
+}
+
+// checkRequiredPaths reports every RequirePaths selector that matches nothing
+// in the document. The error points at the deepest part of the path that exists.
+func (v *Validator) checkRequiredPaths(root *yaml.Node, path string, ctx *ValidationContext) {
+	for _, selector := range v.requirePaths {
+		if ctx.IsStopped() {
+			return
+		}
+		steps := selector.steps
+		if len(selectSteps(root, steps, path)) > 0 {
+			continue
+		}
+
+		at := root
+		for n := len(steps) - 1; n > 0; n-- {
+			if matches := selectSteps(root, steps[:n], path); len(matches) > 0 {
+				at = matches[0].Value
+				break
+			}
+		}
+		ctx.AddError(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(joinSelector(path, selector.text)),
+			Line:    at.Line,
+			Column:  at.Column,
+			Message: "required path is missing",
+		})
+	}
+}
+
+// checkForbiddenPaths reports every node matched by a ForbidPaths selector,
+// at its key when it has one.
+func (v *Validator) checkForbiddenPaths(root *yaml.Node, path string, ctx *ValidationContext) {
+	for _, selector := range v.forbidPaths {
+		if ctx.IsStopped() {
+			return
+		}
+		for _, m := range selectSteps(root, selector.steps, path) {
+			at := m.Value
+			if m.Key != nil {
+				at = m.Key
+			}
+			ctx.AddError(ValidationError{
+				Level:   LevelError,
+				Path:    cleanPath(m.Path),
+				Line:    at.Line,
+				Column:  at.Column,
+				Message: "path is forbidden",
+				Got:     v.describeNode(m.Value),
+			})
+		}
+	}
+}
+
+// joinSelector prefixes a selector with a document path.
+func joinSelector(base, selector string) string {
+	if base == "" || strings.HasPrefix(selector, "[") {
+		return base + selector
+	}
+	return base + "." + selector
+}
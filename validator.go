@@ -8,10 +8,14 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math/big"
+	"os"
+	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
@@ -38,15 +42,65 @@ func (l ErrorLevel) String() string {
 	return "ERROR"
 }
 
+// MarshalText encodes the level as "error" or "warning" (used by encoding/json).
+func (l ErrorLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(l.String())), nil
+}
+
+// UnmarshalText decodes a level written by MarshalText (case-insensitive).
+func (l *ErrorLevel) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "error":
+		*l = LevelError
+	case "warning":
+		*l = LevelWarning
+	default:
+		return fmt.Errorf("unknown error level %q", text)
+	}
+	return nil
+}
+
 // ValidationError represents a single validation issue.
 type ValidationError struct {
-	Level    ErrorLevel
-	Path     string // Path to the problematic node, e.g., "spec.containers[0].image"
-	Line     int    // 1-based line number (0 if unknown)
-	Column   int    // 1-based column number (0 if unknown)
-	Message  string
-	Got      string // Actual value/type description
-	Expected string // Expected value/type description
+	Level    ErrorLevel `json:"level"`
+	File     string     `json:"file,omitempty"` // Source file (empty for in-memory validation)
+	Path     string     `json:"path"`           // Path to the problematic node, e.g., "spec.containers[0].image"
+	Line     int        `json:"line"`           // 1-based line number (0 if unknown)
+	Column   int        `json:"column"`         // 1-based column number (0 if unknown)
+	Message  string     `json:"message"`
+	Got      string     `json:"got,omitempty"`      // Actual value/type description
+	Expected string     `json:"expected,omitempty"` // Expected value/type description
+
+	// GotType, ExpectedType and GotValue are structured forms of Got/Expected
+	// for programmatic use. They are set on type mismatches (all three; GotValue
+	// only for scalars) and on range errors of the built-in validators (GotValue).
+	// TypeAny means not set.
+	GotType      NodeType `json:"gotType,omitempty"`
+	ExpectedType NodeType `json:"expectedType,omitempty"`
+	GotValue     string   `json:"gotValue,omitempty"` // Raw scalar value
+
+	// EndLine and EndColumn mark the end of the node for errors about a whole
+	// mapping or sequence; EndColumn is just past its last character. Both are 0
+	// for scalars and other errors, where Line/Column suffice.
+	EndLine   int `json:"endLine,omitempty"`
+	EndColumn int `json:"endColumn,omitempty"`
+
+	// Offset is the 0-based byte offset of Line/Column in the source, for tools
+	// that address text by offset. It is only set by ValidationResult.FillOffsets;
+	// an error at the very start of the input has offset 0 and no JSON field.
+	Offset int `json:"offset,omitempty"`
+
+	// Deprecation is set on warnings for fields with FieldSchema.DeprecatedInfo.
+	Deprecation *DeprecatedInfo `json:"deprecation,omitempty"`
+
+	// Cause is the underlying error, e.g. the yaml.v3 decode error of a parse
+	// failure (nil otherwise). It is returned by Unwrap for errors.Is/As.
+	Cause error `json:"-"`
+}
+
+// Unwrap returns Cause.
+func (e ValidationError) Unwrap() error {
+	return e.Cause
 }
 
 func (e ValidationError) Error() string {
@@ -58,12 +112,17 @@ func (e ValidationError) Error() string {
 	}
 
 	var pos string
-	if e.Line > 0 {
-		if e.Column > 0 {
-			pos = fmt.Sprintf("line %d:%d: ", e.Line, e.Column)
-		} else {
-			pos = fmt.Sprintf("line %d: ", e.Line)
-		}
+	switch {
+	case e.File != "" && e.Line > 0 && e.Column > 0:
+		pos = fmt.Sprintf("%s:%d:%d: ", e.File, e.Line, e.Column)
+	case e.File != "" && e.Line > 0:
+		pos = fmt.Sprintf("%s:%d: ", e.File, e.Line)
+	case e.File != "":
+		pos = e.File + ": "
+	case e.Line > 0 && e.Column > 0:
+		pos = fmt.Sprintf("line %d:%d: ", e.Line, e.Column)
+	case e.Line > 0:
+		pos = fmt.Sprintf("line %d: ", e.Line)
 	}
 
 	return fmt.Sprintf("[%s] %s%s%s (path: %s)", e.Level, pos, e.Message, details, e.Path)
@@ -89,6 +148,50 @@ func (c *ErrorCollector) Add(err ValidationError) {
 	}
 }
 
+// collectorMark records the collector size so later additions can be found.
+type collectorMark struct {
+	errors, warnings int
+}
+
+func (c *ErrorCollector) mark() collectorMark {
+	return collectorMark{errors: len(c.errors), warnings: len(c.warnings)}
+}
+
+// rewriteSince applies fn to every error and warning added after m.
+func (c *ErrorCollector) rewriteSince(m collectorMark, fn func(*ValidationError)) {
+	for i := m.errors; i < len(c.errors); i++ {
+		fn(&c.errors[i])
+	}
+	for i := m.warnings; i < len(c.warnings); i++ {
+		fn(&c.warnings[i])
+	}
+}
+
+// dropRepeatsSince removes findings added after m that repeat one added before
+// it at the same position with the same message.
+func (c *ErrorCollector) dropRepeatsSince(m collectorMark) {
+	c.errors = dropRepeats(c.errors, m.errors)
+	c.warnings = dropRepeats(c.warnings, m.warnings)
+}
+
+func dropRepeats(list []ValidationError, from int) []ValidationError {
+	type finding struct {
+		path, message string
+		line, column  int
+	}
+	seen := make(map[finding]bool, from)
+	for _, err := range list[:from] {
+		seen[finding{err.Path, err.Message, err.Line, err.Column}] = true
+	}
+	out := list[:from]
+	for _, err := range list[from:] {
+		if !seen[finding{err.Path, err.Message, err.Line, err.Column}] {
+			out = append(out, err)
+		}
+	}
+	return out
+}
+
 // HasErrors returns true if there are any errors (not warnings).
 func (c *ErrorCollector) HasErrors() bool {
 	return len(c.errors) > 0
@@ -112,6 +215,17 @@ func (c *ErrorCollector) All() []ValidationError {
 	return result
 }
 
+// AllSorted returns errors and warnings interleaved by position: by File, then
+// line and column, with errors before warnings at the same position, the
+// order ValidationResult.SortByPosition uses. All is unchanged.
+func (c *ErrorCollector) AllSorted() []ValidationError {
+	all := c.All()
+	sort.SliceStable(all, func(i, j int) bool {
+		return positionLess(all[i].File, all[i], all[j].File, all[j])
+	})
+	return all
+}
+
 // ============================================================================
 // Validation Context
 // ============================================================================
@@ -135,11 +249,152 @@ type ValidationContext struct {
 	// By default, only YAML 1.2 booleans (true/false) are recognized.
 	YAML11Booleans bool
 
+	// CheckFilesystem allows validators to consult the local filesystem
+	// (e.g. to check that a referenced file exists). When false, such
+	// validators only perform syntactic checks.
+	CheckFilesystem bool
+
+	// AllowInterpolation skips type and value validation for scalars that contain
+	// a deploy-time placeholder such as ${PORT}.
+	AllowInterpolation bool
+
+	// InterpolationPattern detects placeholders when AllowInterpolation is set.
+	// nil uses the default pattern, which matches ${NAME} anywhere in the value.
+	InterpolationPattern *regexp.Regexp
+
+	// WarnInterpolation emits a warning for every skipped interpolated scalar.
+	WarnInterpolation bool
+
+	// WarnAmbiguousUnquoted warns when a string field holds an unquoted scalar
+	// that YAML 1.1 would read as a boolean, null or number (e.g. country: NO).
+	WarnAmbiguousUnquoted bool
+
+	// WarnRedundantDefaults warns when a present field's value equals its
+	// schema Default, so the field could be omitted. Values are compared as
+	// decoded YAML, with numbers compared by value (port: 8080.0 matches a
+	// Default of 8080).
+	WarnRedundantDefaults bool
+
+	// BestEffortMultiDoc continues with the next document (after the next ---
+	// separator) when a document in a multi-document stream fails to parse.
+	// By default validation stops at the first decode error.
+	BestEffortMultiDoc bool
+
+	// BestEffort still validates the children of a mapping or sequence whose
+	// type check failed, as long as the schema describes children of that
+	// kind (AllowedKeys, AdditionalProperties or AdditionalPropertiesByType for
+	// a mapping, ItemSchema for a sequence), e.g. a mapping whose CustomTags
+	// tag resolves to another type. The type error is still reported; the
+	// value's Validators are not run. By default nothing below a type error is
+	// validated.
+	BestEffort bool
+
+	// RequireNonEmpty reports an error when the input has no content: an empty
+	// or whitespace/comment-only file, or only empty documents ("---").
+	// Empty documents are then skipped instead of validated as null, so trailing
+	// or doubled separators next to real documents stay harmless.
+	// A root schema with Required: true implies this option.
+	RequireNonEmpty bool
+
+	// MinDocuments and MaxDocuments bound the number of non-empty documents in
+	// a multi-document stream (0 = no bound). Empty documents, such as the one
+	// after a trailing "---", are not counted. Not checked after a decode error.
+	MinDocuments int
+	MaxDocuments int
+
+	// RequireFinalNewline warns when non-empty input does not end with a
+	// newline, as editors and POSIX tools expect.
+	RequireFinalNewline bool
+
+	// ForbidBOM reports an error when the input starts with a byte order mark
+	// (UTF-8, or UTF-16 which yaml.v3 converts silently), for files read by
+	// tools that do not strip it.
+	ForbidBOM bool
+
+	// DuplicateKeyPolicy controls reporting of keys that appear more than once
+	// in the same mapping (merge keys excluded). Default: DuplicateKeyWarn.
+	DuplicateKeyPolicy DuplicateKeyPolicy
+
+	// ForbidAliases rejects anchors and aliases, e.g. to rule out "billion
+	// laughs" documents: every anchor and alias is an error, and aliases,
+	// including those under merge keys (<<), are not resolved, so the values
+	// they point to are not validated at the alias.
+	ForbidAliases bool
+
+	// MaxAliasExpansions limits how many aliases (including merge-key aliases)
+	// are followed per document, and MaxNodes how many nodes are visited per
+	// document, counting every visit through an alias. They guard against
+	// "billion laughs" documents whose aliases expand exponentially. When a limit
+	// is exceeded, an error is reported and the rest of the document is not
+	// validated. 0 means no limit.
+	MaxAliasExpansions int
+	MaxNodes           int
+
+	// AllowExperimental opts into beta and experimental fields: using them
+	// is not reported. See FieldSchema.Stability.
+	AllowExperimental bool
+
+	// StrictStability turns the warning for an experimental field into an
+	// error unless AllowExperimental is set. Beta fields still only warn.
+	StrictStability bool
+
+	// OneErrorPerPath keeps only the first finding for each path once validation
+	// is done (errors take precedence over warnings). See ValidationResult.KeepFirstPerPath.
+	OneErrorPerPath bool
+
+	// Messages overrides the English text of built-in findings, including those
+	// of the validators in pkg/, e.g. to translate them, keyed by message code
+	// (see MsgTypeMismatch and the other Msg constants). An override may use the
+	// placeholders listed with its code.
+	// Codes without an entry keep the default text, and a validator's own
+	// Message takes precedence.
+	Messages map[string]string
+
 	// SourceLines contains the original YAML lines for error formatting.
 	SourceLines []string
 
+	// CustomTags maps local tags (e.g. "!Port") to the type they stand for.
+	// Scalars with other local tags are not guessed from their value; their type
+	// is unknown and accepted by every schema type (see WarnUnknownTags).
+	CustomTags map[string]NodeType
+
+	// WarnUnknownTags warns when a typed field holds a scalar with a local tag
+	// that is not in CustomTags.
+	WarnUnknownTags bool
+
+	// Trace, when set, receives one line per validation step: each node visited
+	// with its inferred type and the schema type applied, and how each mapping key
+	// was resolved (known key, additional property, unknown key and its level).
+	// Lines have the form "line:col path: message". Nil disables tracing.
+	Trace io.Writer
+
+	// Values carries caller-supplied data for custom validators (e.g. a list of
+	// allowed image registries), read with Value or ContextValue.
+	// Validation only reads the map, and forks share it, so it must not be
+	// modified while a validation using it is running; values that validators
+	// may mutate must do their own locking.
+	Values map[string]interface{}
+
 	collector *ErrorCollector
 	stopped   bool
+	file      string
+	budget    *documentBudget // per-document counts for MaxAliasExpansions/MaxNodes
+	document  *yaml.Node      // root node of the document being validated
+
+	thenActive map[thenVisit]bool // ThenSchema validations in progress, to end cycles
+}
+
+// thenVisit is the validation of a mapping against a ConditionalRule.ThenSchema.
+type thenVisit struct {
+	node   *yaml.Node
+	schema *FieldSchema
+}
+
+// documentBudget counts the work done on one document. Forks share it.
+type documentBudget struct {
+	aliases  int
+	nodes    int
+	exceeded bool
 }
 
 // NewValidationContext creates a new ValidationContext with default settings.
@@ -154,12 +409,121 @@ func (ctx *ValidationContext) AddError(err ValidationError) {
 	if ctx.stopped {
 		return
 	}
+	if err.File == "" {
+		err.File = ctx.file
+	}
 	ctx.collector.Add(err)
 	if ctx.StopOnFirst && err.Level == LevelError {
 		ctx.stopped = true
 	}
 }
 
+func (ctx *ValidationContext) isInterpolated(value string) bool {
+	re := ctx.InterpolationPattern
+	if re == nil {
+		re = defaultInterpolationRe
+	}
+	return re.MatchString(value)
+}
+
+// Fork returns a copy of the context with the same options and document state
+// but its own empty collector, e.g. to try a validator without reporting its errors.
+func (ctx *ValidationContext) Fork() *ValidationContext {
+	fork := *ctx
+	fork.collector = NewErrorCollector()
+	fork.stopped = false
+	return &fork
+}
+
+// Document returns the root node of the document being validated, for
+// validators that look up other parts of it (see SelectPath). It is nil
+// outside document validation, e.g. when ValidateSchema checks a Default.
+func (ctx *ValidationContext) Document() *yaml.Node {
+	return ctx.document
+}
+
+// InferType returns the type of node as validation sees it, honouring the
+// context's StrictTypes and YAML11Booleans settings. Aliases are resolved.
+func (ctx *ValidationContext) InferType(node *yaml.Node) NodeType {
+	return (&Validator{}).inferType(node, ctx)
+}
+
+// spendBudget counts a node visit against MaxNodes. It returns false once a
+// limit of the document has been exceeded.
+func (ctx *ValidationContext) spendBudget(node *yaml.Node, path string) bool {
+	b := ctx.budget
+	if b == nil {
+		return true
+	}
+	if b.exceeded {
+		return false
+	}
+	b.nodes++
+	if ctx.MaxNodes > 0 && b.nodes > ctx.MaxNodes {
+		b.exceeded = true
+		ctx.AddError(ValidationError{
+			Level:    LevelError,
+			Path:     cleanPath(path),
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  "node limit exceeded, document not validated further",
+			Expected: fmt.Sprintf("<= %d nodes", ctx.MaxNodes),
+		})
+		return false
+	}
+	return true
+}
+
+// spendAlias counts following alias against MaxAliasExpansions.
+func (ctx *ValidationContext) spendAlias(alias *yaml.Node, path string) bool {
+	b := ctx.budget
+	if b == nil {
+		return true
+	}
+	if b.exceeded {
+		return false
+	}
+	b.aliases++
+	if ctx.MaxAliasExpansions > 0 && b.aliases > ctx.MaxAliasExpansions {
+		b.exceeded = true
+		ctx.AddError(ValidationError{
+			Level:    LevelError,
+			Path:     cleanPath(path),
+			Line:     alias.Line,
+			Column:   alias.Column,
+			Message:  "alias expansion limit exceeded, document not validated further",
+			Expected: fmt.Sprintf("<= %d alias expansions", ctx.MaxAliasExpansions),
+		})
+		return false
+	}
+	return true
+}
+
+// tracef writes one trace line for node when Trace is set.
+func (ctx *ValidationContext) tracef(node *yaml.Node, path, format string, args ...interface{}) {
+	if ctx.Trace == nil {
+		return
+	}
+	path = cleanPath(path)
+	if path == "" {
+		path = "(root)"
+	}
+	fmt.Fprintf(ctx.Trace, "%d:%d %s: %s\n", node.Line, node.Column, path, fmt.Sprintf(format, args...))
+}
+
+// Value returns the entry of Values stored under key.
+func (ctx *ValidationContext) Value(key string) (interface{}, bool) {
+	val, ok := ctx.Values[key]
+	return val, ok
+}
+
+// ContextValue returns the entry of ctx.Values stored under key as a T.
+// The boolean is false when the key is missing or holds a different type.
+func ContextValue[T any](ctx *ValidationContext, key string) (T, bool) {
+	val, ok := ctx.Values[key].(T)
+	return val, ok
+}
+
 // IsStopped returns true if validation has been stopped.
 func (ctx *ValidationContext) IsStopped() bool {
 	return ctx.stopped
@@ -194,6 +558,9 @@ const (
 	TypeMap
 	// TypeSequence represents sequence/array nodes.
 	TypeSequence
+	// TypeSet represents YAML sets (!!set): mappings whose values are all null.
+	// A TypeMap schema also accepts sets.
+	TypeSet
 )
 
 func (t NodeType) String() string {
@@ -214,11 +581,29 @@ func (t NodeType) String() string {
 		return "map"
 	case TypeSequence:
 		return "sequence"
+	case TypeSet:
+		return "set"
 	default:
 		return "unknown"
 	}
 }
 
+// MarshalText encodes the type by its String name (used by encoding/json).
+func (t NodeType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes a type written by MarshalText.
+func (t *NodeType) UnmarshalText(text []byte) error {
+	for candidate := TypeAny; candidate <= TypeSet; candidate++ {
+		if candidate.String() == string(text) {
+			*t = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown node type %q", text)
+}
+
 // ============================================================================
 // Unknown Key Policy
 // ============================================================================
@@ -242,6 +627,92 @@ const (
 	UnknownKeyIgnore
 )
 
+// ============================================================================
+// Stability
+// ============================================================================
+
+// Stability marks how settled a field is. Using a non-stable field is reported
+// unless ValidationContext.AllowExperimental is set.
+type Stability int
+
+const (
+	// StabilityStable is the default; nothing is reported.
+	StabilityStable Stability = iota
+
+	// StabilityBeta fields produce a warning.
+	StabilityBeta
+
+	// StabilityExperimental fields produce a warning, or an error when
+	// ValidationContext.StrictStability is set.
+	StabilityExperimental
+)
+
+// String returns the stability name.
+func (s Stability) String() string {
+	switch s {
+	case StabilityStable:
+		return "stable"
+	case StabilityBeta:
+		return "beta"
+	case StabilityExperimental:
+		return "experimental"
+	default:
+		return fmt.Sprintf("Stability(%d)", int(s))
+	}
+}
+
+// ============================================================================
+// Collection Style
+// ============================================================================
+
+// CollectionStyle is the YAML syntax of a mapping or sequence: block (one entry
+// per line) or flow ({a: 1} and [1, 2]).
+type CollectionStyle int
+
+const (
+	// StyleAny accepts either style (default).
+	StyleAny CollectionStyle = iota
+
+	// StyleBlock requires block style.
+	StyleBlock
+
+	// StyleFlow requires flow style.
+	StyleFlow
+)
+
+// String returns the style name.
+func (s CollectionStyle) String() string {
+	switch s {
+	case StyleAny:
+		return "any"
+	case StyleBlock:
+		return "block"
+	case StyleFlow:
+		return "flow"
+	default:
+		return fmt.Sprintf("CollectionStyle(%d)", int(s))
+	}
+}
+
+// ============================================================================
+// Duplicate Key Policy
+// ============================================================================
+
+// DuplicateKeyPolicy determines how a key repeated within one mapping is reported.
+// yaml.v3 keeps the last value, which silently hides the earlier one.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeyWarn reports repeated keys as warnings (default).
+	DuplicateKeyWarn DuplicateKeyPolicy = iota
+
+	// DuplicateKeyError reports repeated keys as errors.
+	DuplicateKeyError
+
+	// DuplicateKeyIgnore does not report repeated keys.
+	DuplicateKeyIgnore
+)
+
 // ============================================================================
 // Validators Interfaces
 // ============================================================================
@@ -256,27 +727,100 @@ type KeyValidator interface {
 	ValidateKey(key string, keyNode *yaml.Node, path string, ctx *ValidationContext)
 }
 
+// SeqValidator validates a sequence as a whole (e.g. ordering of items).
+// It is called once per sequence node.
+type SeqValidator interface {
+	ValidateSeq(node *yaml.Node, path string, ctx *ValidationContext)
+}
+
+// MapValidator validates a mapping as a whole (e.g. which keys are present).
+// It is called once per mapping node.
+type MapValidator interface {
+	ValidateMap(node *yaml.Node, path string, ctx *ValidationContext)
+}
+
 // ============================================================================
 // Conditional Rules
 // ============================================================================
 
 // ConditionalRule defines conditional validation logic.
-// When ConditionField equals ConditionValue, additional requirements apply.
+// When ConditionField equals ConditionValue (or matches ConditionPattern),
+// additional requirements apply.
 type ConditionalRule struct {
 	// ConditionField is the field to check.
 	ConditionField string
 	// ConditionValue is the expected value (scalar comparison).
 	ConditionValue string
+	// ConditionPattern, when set, is matched against the field's scalar value
+	// instead, e.g. `:latest$` for image tags; ConditionValue is then ignored.
+	ConditionPattern *regexp.Regexp
 	// ThenRequired lists fields that become required when condition is met.
 	ThenRequired []string
 	// ThenForbidden lists fields that are forbidden when condition is met.
 	ThenForbidden []string
+	// ThenSchema, when set, additionally validates the whole mapping holding
+	// ConditionField when the condition is met, e.g. to require url and method
+	// when type is http. Its own UnknownKeyPolicy applies to keys it does not
+	// list, so it usually sets UnknownKeyIgnore and leaves them to the outer schema.
+	// A finding with the same path, message, line and column as one reported
+	// earlier (say, a duplicate key both schemas see) is dropped, and a ThenSchema
+	// already being applied to the same mapping is not applied again, so cycles
+	// end. ThenSchema is validate-only: ValidateAndFill does not fill its defaults.
+	ThenSchema *FieldSchema
+}
+
+// KeyCount is a list of fields and a count of them, for AtLeastNOf and AtMostNOf.
+type KeyCount struct {
+	// Keys are the fields counted.
+	Keys []string
+	// N is the count, between 1 and len(Keys).
+	N int
 }
 
 // ============================================================================
 // Field Schema
 // ============================================================================
 
+// DeprecatedInfo describes a deprecated field. All fields are optional.
+type DeprecatedInfo struct {
+	Message     string `json:"message,omitempty"`     // Free-form note appended to the warning
+	Since       string `json:"since,omitempty"`       // Version that deprecated the field, e.g. "v1.2"
+	RemoveIn    string `json:"removeIn,omitempty"`    // Version that will remove the field
+	Replacement string `json:"replacement,omitempty"` // Field or setting to use instead
+}
+
+// String formats the deprecation warning message.
+func (d DeprecatedInfo) String() string {
+	var sb strings.Builder
+	sb.WriteString("deprecated")
+	if d.Since != "" {
+		sb.WriteString(" since " + d.Since)
+	}
+	if d.RemoveIn != "" {
+		sb.WriteString(", removed in " + d.RemoveIn)
+	}
+	if d.Replacement != "" {
+		sb.WriteString(", use " + d.Replacement + " instead")
+	}
+	if d.Message != "" {
+		sb.WriteString(": " + d.Message)
+	}
+	return sb.String()
+}
+
+// deprecation returns the deprecation warning of a field, with its structured
+// info when set, or "" when the field is not deprecated.
+func deprecation(schema *FieldSchema) (string, *DeprecatedInfo) {
+	if schema.DeprecatedInfo != nil {
+		info := *schema.DeprecatedInfo
+		return info.String(), &info
+	}
+	if schema.Deprecated == "true" {
+		return "this field is deprecated", nil
+	}
+	return schema.Deprecated, nil
+}
+
 // FieldSchema defines the validation rules for a field.
 type FieldSchema struct {
 	// Type is the expected node type.
@@ -292,10 +836,47 @@ type FieldSchema struct {
 	// Use "true" for a generic message.
 	Deprecated string
 
+	// DeprecatedInfo is a structured alternative to Deprecated; when set it
+	// takes precedence and the warning reads e.g. "deprecated since v1.2,
+	// removed in v2.0, use newField instead".
+	DeprecatedInfo *DeprecatedInfo
+
+	// Stability marks beta and experimental fields (default: StabilityStable).
+	Stability Stability
+
+	// NonEmpty rejects an empty string, sequence or mapping ("", [], {}).
+	// A null value is still allowed when Nullable is set.
+	NonEmpty bool
+
+	// MaxNesting limits how deeply mappings and sequences may nest in the value
+	// (nil = no limit): the value itself is level 1 and every collection inside a
+	// collection adds a level; scalars do not count. Values merged with << count
+	// at the level of the mapping they are merged into. The first collection
+	// beyond the limit is reported. This is a rule about the document; the
+	// validator's own resource guards are MaxNodes and MaxAliasExpansions.
+	MaxNesting *int
+
+	// RequireStyle requires a mapping or sequence value to be written in block
+	// or flow style (default: StyleAny). Empty collections are exempt, as they
+	// can only be written in flow style. Scalars are not checked.
+	RequireStyle CollectionStyle
+
+	// RequireStyleLevel is the level of a RequireStyle mismatch
+	// (nil = LevelWarning).
+	RequireStyleLevel *ErrorLevel
+
+	// Aliases are alternative keys accepted for this field in the parent mapping,
+	// e.g. the old name during a rename. Using an alias emits a deprecation warning,
+	// which also repeats the field's own deprecation if it has one; paths,
+	// Required and inter-field rules use the AllowedKeys name.
+	Aliases []string
+
 	// Description is a human-readable field description.
 	Description string
 
 	// Default is the default value. If set and field is missing, a warning is emitted.
+	// Defaults inside an absent optional mapping field are reported too, with the
+	// path they would have (e.g. "server.tls.enabled"), anchored at the parent mapping.
 	Default interface{}
 
 	// ─────────────────────────────────────────────────────────────────────────
@@ -316,31 +897,82 @@ type FieldSchema struct {
 	//   UnknownKeyPolicy: UnknownKeyIgnore,
 	AllowedKeys map[string]*FieldSchema
 
+	// CaseInsensitiveKeys matches keys to AllowedKeys ignoring case
+	// (e.g. "apiversion" is accepted for "apiVersion"), with a warning when the
+	// spelling differs. Paths and inter-field rules use the AllowedKeys spelling.
+	// AllowedKeys must not contain keys that differ only by case (see ValidateSchema).
+	CaseInsensitiveKeys bool
+
+	// NumericKeys matches integer keys to AllowedKeys by value, so 80, +80 and
+	// 0x50 all select AllowedKeys["80"]. Only keys that resolve to integers are
+	// normalized; a quoted "0x50" is a string key. Paths use the AllowedKeys
+	// spelling. AllowedKeys must not contain two spellings of the same integer.
+	NumericKeys bool
+
 	// AdditionalProperties is the schema for keys not in AllowedKeys.
 	// If not nil: unknown keys are allowed and validated against this schema.
 	// If nil: unknown keys are handled by UnknownKeyPolicy.
 	AdditionalProperties *FieldSchema
 
+	// AdditionalPropertiesByType routes the value of a key not in AllowedKeys to
+	// the schema for its inferred type, e.g. strings and ints of a free-form map
+	// get different rules. Ints fall back to a TypeFloat entry and sets to TypeMap;
+	// values of other types fall back to AdditionalProperties.
+	AdditionalPropertiesByType map[NodeType]*FieldSchema
+
+	// AdditionalPropertiesLevel, when set, is the level of errors found while
+	// validating a value against AdditionalProperties or AdditionalPropertiesByType,
+	// e.g. LevelWarning for unknown-but-typed values that should not fail
+	// validation. Nil keeps LevelError. Warnings are not promoted.
+	AdditionalPropertiesLevel *ErrorLevel
+
 	// UnknownKeyPolicy determines handling of keys not in AllowedKeys
-	// when AdditionalProperties is nil.
+	// when AdditionalProperties and AdditionalPropertiesByType give no schema.
 	UnknownKeyPolicy UnknownKeyPolicy
 
 	// KeyValidators validate key names (applied to ALL keys).
 	KeyValidators []KeyValidator
 
+	// SkipValueOnKeyError skips validating the value of a key that failed one of
+	// KeyValidators with an error, so an invalid key is reported once instead of
+	// together with (likely noisy) errors about its value.
+	SkipValueOnKeyError bool
+
+	// MapValidators validate the mapping as a whole.
+	// They run after all keys have been validated and after the required/default
+	// checks and inter-field logic (AnyOf, ExactlyOneOf, MutuallyExclusive, Conditions),
+	// but before Validators. They are skipped if the node is not a mapping.
+	MapValidators []MapValidator
+
 	// ─────────────────────────────────────────────────────────────────────────
 	// Sequence-specific fields
 	// ─────────────────────────────────────────────────────────────────────────
 
-	// ItemSchema is the schema for sequence items.
+	// ItemSchema is the schema for sequence items (set members for TypeSet).
 	ItemSchema *FieldSchema
 
-	// MinItems is the minimum number of items (nil = no limit).
+	// MinItems is the minimum number of items or set members (nil = no limit).
 	MinItems *int
 
-	// MaxItems is the maximum number of items (nil = no limit).
+	// MaxItems is the maximum number of items or set members (nil = no limit).
 	MaxItems *int
 
+	// ItemsNonNull rejects null sequence items (e.g. a stray "- null" or "-"
+	// left by templating), whatever ItemSchema.Nullable says. Null items are
+	// then not validated against ItemSchema.
+	ItemsNonNull bool
+
+	// UniqueFields makes mapping items of a sequence unique by the composite key
+	// of these sub-fields, e.g. {"host", "port"}. A duplicate is reported at its
+	// second occurrence. Values are compared by their scalar text (collections by
+	// their YAML encoding). Items that are not mappings, or lack one of the
+	// sub-fields or have it null, are not checked.
+	UniqueFields []string
+
+	// SeqValidators validate the sequence as a whole.
+	// They run after MinItems/MaxItems and item validation, but before Validators.
+	SeqValidators []SeqValidator
+
 	// ─────────────────────────────────────────────────────────────────────────
 	// Value validators
 	// ─────────────────────────────────────────────────────────────────────────
@@ -357,16 +989,36 @@ type FieldSchema struct {
 	// Means: either configFile, OR both host AND port.
 	AnyOf [][]string
 
+	// AtLeastNOf requires, for each rule, at least N of its Keys to be present.
+	// Example: []KeyCount{{Keys: []string{"email", "phone", "address"}, N: 2}}
+	// Means: at least two ways to reach the user.
+	AtLeastNOf []KeyCount
+
+	// AtMostNOf allows, for each rule, at most N of its Keys to be present.
+	// Example: []KeyCount{{Keys: []string{"token", "password", "certificate"}, N: 2}}
+	// Means: no more than two auth methods at once.
+	AtMostNOf []KeyCount
+
 	// ExactlyOneOf requires exactly one field from the list.
 	// Example: []string{"inline", "file", "url"}
 	// Means: exactly one of inline/file/url must be present.
 	ExactlyOneOf []string
 
+	// ExactlyOneGroupOf requires exactly one field group to be fully present.
+	// Example: [][]string{{"file"}, {"host", "port"}}
+	// Means: either file, OR both host AND port, but not both alternatives.
+	ExactlyOneGroupOf [][]string
+
 	// MutuallyExclusive allows at most one field from the list (zero is OK).
 	// Example: []string{"debug", "quiet"}
 	// Means: debug and quiet cannot both be present.
 	MutuallyExclusive []string
 
+	// AllOrNone requires each group to be either fully present or fully absent.
+	// Example: [][]string{{"accessKey", "secretKey"}}
+	// Means: accessKey and secretKey must be set together.
+	AllOrNone [][]string
+
 	// Conditions define conditional validation rules.
 	Conditions []ConditionalRule
 }
@@ -381,6 +1033,41 @@ type ValidationResult struct {
 	Collector *ErrorCollector
 	// SourceLines contains the original YAML lines.
 	SourceLines []string
+	// Files holds per-file source lines for results built by MergeResults,
+	// keyed by the File of their errors. Nil for single-input results.
+	Files map[string][]string
+}
+
+// MergeResults combines per-file results into one. Each error's File is set to
+// its file name (unless it already names its File, as with ValidateFile) and the
+// source lines of every file are kept in Files, so FormatAll renders snippets
+// from the right file. Nil results are skipped.
+func MergeResults(results map[string]*ValidationResult) *ValidationResult {
+	names := make([]string, 0, len(results))
+	for name, r := range results {
+		if r != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	merged := &ValidationResult{
+		Collector: NewErrorCollector(),
+		Files:     make(map[string][]string, len(names)),
+	}
+	for _, name := range names {
+		r := results[name]
+		merged.Files[name] = r.SourceLines
+		for _, err := range r.Collector.All() {
+			if err.File == "" {
+				err.File = name
+			} else if _, ok := merged.Files[err.File]; !ok {
+				merged.Files[err.File] = r.SourceLines
+			}
+			merged.Collector.Add(err)
+		}
+	}
+	return merged
 }
 
 // HasErrors returns true if there are any errors.
@@ -388,26 +1075,76 @@ func (r *ValidationResult) HasErrors() bool {
 	return r.Collector.HasErrors()
 }
 
-// SortByPosition sorts errors by position in the file.
+// SortByPosition sorts errors by position in the file
+// (by file first for merged results).
 func (r *ValidationResult) SortByPosition() {
-	all := r.Collector.All()
-	sort.Slice(all, func(i, j int) bool {
-		if all[i].Line != all[j].Line {
-			return all[i].Line < all[j].Line
-		}
-		if all[i].Column != all[j].Column {
-			return all[i].Column < all[j].Column
-		}
-		// Errors before warnings when at same position
-		return all[i].Level > all[j].Level
-	})
-
+	all := r.sortedAllByPosition()
 	r.Collector = NewErrorCollector()
 	for _, err := range all {
 		r.Collector.Add(err)
 	}
 }
 
+// KeepFirstPerPath drops all but the first finding for each distinct Path,
+// collapsing cascades such as a type error followed by several validator errors.
+// Errors are considered before warnings.
+func (r *ValidationResult) KeepFirstPerPath() {
+	seen := make(map[string]bool)
+	collector := NewErrorCollector()
+	for _, err := range r.Collector.All() {
+		if seen[err.Path] {
+			continue
+		}
+		seen[err.Path] = true
+		collector.Add(err)
+	}
+	r.Collector = collector
+}
+
+// FilterByPathPrefix returns a new result with only the findings at prefix or
+// below it, e.g. "spec.template" keeps "spec.template" and
+// "spec.template.spec.containers[0]" but not "spec.templates". Paths are
+// matched as reported, so findings in later documents need their "doc[N]."
+// prefix. Source lines are kept for formatting; r is not modified.
+func (r *ValidationResult) FilterByPathPrefix(prefix string) *ValidationResult {
+	filtered := &ValidationResult{
+		Collector:   NewErrorCollector(),
+		SourceLines: r.SourceLines,
+		Files:       r.Files,
+	}
+	for _, err := range r.Collector.All() {
+		if hasPathPrefix(err.Path, prefix) {
+			filtered.Collector.Add(err)
+		}
+	}
+	return filtered
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	rest := path[len(prefix):]
+	return rest == "" || rest[0] == '.' || rest[0] == '[' || strings.HasSuffix(prefix, ".")
+}
+
+// FillOffsets sets Offset on every error with a known position from the source
+// lines of its file. See ByteOffset.
+func (r *ValidationResult) FillOffsets() {
+	collector := NewErrorCollector()
+	for _, err := range r.Collector.All() {
+		_, lines := r.sourceOf(err)
+		if off := ByteOffset(lines, err.Line, err.Column); off > 0 {
+			err.Offset = off
+		}
+		collector.Add(err)
+	}
+	r.Collector = collector
+}
+
 // FormatAll formats all errors with source context.
 func (r *ValidationResult) FormatAll(sortByPos bool) string {
 	var sb strings.Builder
@@ -419,7 +1156,8 @@ func (r *ValidationResult) FormatAll(sortByPos bool) string {
 	}
 
 	for _, err := range items {
-		sb.WriteString(FormatErrorWithSource(err, r.SourceLines))
+		_, lines := r.sourceOf(err)
+		sb.WriteString(FormatErrorWithSource(err, lines))
 		sb.WriteString("\n")
 	}
 	return sb.String()
@@ -427,78 +1165,263 @@ func (r *ValidationResult) FormatAll(sortByPos bool) string {
 
 func (r *ValidationResult) sortedAllByPosition() []ValidationError {
 	all := r.Collector.All()
-	sort.Slice(all, func(i, j int) bool {
-		if all[i].Line != all[j].Line {
-			return all[i].Line < all[j].Line
-		}
-		if all[i].Column != all[j].Column {
-			return all[i].Column < all[j].Column
-		}
-		// Errors before warnings when at same position
-		return all[i].Level > all[j].Level
+	sort.SliceStable(all, func(i, j int) bool {
+		fi, _ := r.sourceOf(all[i])
+		fj, _ := r.sourceOf(all[j])
+		return positionLess(fi, all[i], fj, all[j])
 	})
 	return all
 }
 
+// positionLess orders findings by file, line and column, with errors before
+// warnings at the same position.
+func positionLess(fileA string, a ValidationError, fileB string, b ValidationError) bool {
+	if fileA != fileB {
+		return fileA < fileB
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	if a.Column != b.Column {
+		return a.Column < b.Column
+	}
+	return a.Level > b.Level
+}
+
+// sourceOf returns the file an error belongs to and that file's source lines.
+func (r *ValidationResult) sourceOf(err ValidationError) (string, []string) {
+	if lines, ok := r.Files[err.File]; ok && err.File != "" {
+		return err.File, lines
+	}
+	return err.File, r.SourceLines
+}
+
 // ============================================================================
 // Validator
 // ============================================================================
 
 // Validator performs YAML validation against a schema.
 type Validator struct {
-	schema *FieldSchema
+	schema       *FieldSchema
+	selector     SchemaSelector
+	requirePaths []pathSelector
+	forbidPaths  []pathSelector
+	err          error // first invalid option, see Err
+}
+
+// SchemaSelector picks the schema for one document from its root node, e.g. by
+// its "kind" field. Returning nil reports the document as unrecognized.
+type SchemaSelector func(root *yaml.Node) *FieldSchema
+
+// ValidatorOption configures a Validator created by NewValidator.
+type ValidatorOption func(*Validator)
+
+// WithSchemaSelector chooses the schema per document instead of using the
+// schema passed to NewValidator (which may then be nil). Empty documents are
+// skipped without calling the selector.
+func WithSchemaSelector(selector SchemaSelector) ValidatorOption {
+	return func(v *Validator) {
+		v.selector = selector
+	}
+}
+
+// WithRequirePaths asserts that each selector (see SelectPath) matches at least
+// one node in every validated document; missing paths are reported as errors.
+// This avoids threading Required through deeply nested schemas.
+// An invalid selector is skipped and reported by Validator.Err.
+func WithRequirePaths(selectors ...string) ValidatorOption {
+	return func(v *Validator) {
+		v.requirePaths = append(v.requirePaths, v.parseSelectors(selectors)...)
+	}
+}
+
+// WithForbidPaths reports every node matched by a selector (see SelectPath)
+// as an error, e.g. "spec.hostNetwork" or "spec.containers[*].securityContext.privileged".
+// Useful for policy checks layered on an existing schema. An invalid selector
+// is skipped and reported by Validator.Err.
+func WithForbidPaths(selectors ...string) ValidatorOption {
+	return func(v *Validator) {
+		v.forbidPaths = append(v.forbidPaths, v.parseSelectors(selectors)...)
+	}
 }
 
 // NewValidator creates a new Validator with the given schema.
-func NewValidator(schema *FieldSchema) *Validator {
-	return &Validator{schema: schema}
+func NewValidator(schema *FieldSchema, opts ...ValidatorOption) *Validator {
+	v := &Validator{schema: schema}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Err returns the first error in the options passed to NewValidator, such as an
+// invalid selector, or nil. Options with errors are skipped during validation.
+func (v *Validator) Err() error {
+	return v.err
+}
+
+// documentSchema returns the schema for a document root, or nil if the
+// selector does not recognize it.
+func (v *Validator) documentSchema(root *yaml.Node) *FieldSchema {
+	if v.selector == nil {
+		return v.schema
+	}
+	if isEmptyDocument(root) {
+		return nil
+	}
+	return v.selector(root)
 }
 
 // ValidateBytes validates YAML data and returns the result.
 // Supports multi-document YAML (separated by ---).
 func (v *Validator) ValidateBytes(data []byte) *ValidationResult {
-	ctx := NewValidationContext()
-	ctx.SourceLines = splitLines(data)
-	v.validateWithContext(bytes.NewReader(data), ctx)
-	return &ValidationResult{
-		Collector:   ctx.Collector(),
-		SourceLines: ctx.SourceLines,
-	}
+	return v.run(data, NewValidationContext())
 }
 
 // ValidateWithOptions validates YAML data with custom options.
 func (v *Validator) ValidateWithOptions(data []byte, opts ValidationContext) *ValidationResult {
 	ctx := &opts
 	ctx.collector = NewErrorCollector()
+	return v.run(data, ctx)
+}
+
+// ValidateString is ValidateBytes for string input.
+func (v *Validator) ValidateString(s string) *ValidationResult {
+	return v.ValidateBytes([]byte(s))
+}
+
+// ValidateStringWithOptions is ValidateWithOptions for string input.
+func (v *Validator) ValidateStringWithOptions(s string, opts ValidationContext) *ValidationResult {
+	return v.ValidateWithOptions([]byte(s), opts)
+}
+
+// ValidateFile reads and validates the YAML file at path with the given options.
+// Every reported error carries path in its File field.
+func (v *Validator) ValidateFile(path string, opts ValidationContext) (*ValidationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ctx := &opts
+	ctx.collector = NewErrorCollector()
+	ctx.file = path
+	return v.run(data, ctx), nil
+}
+
+func (v *Validator) run(data []byte, ctx *ValidationContext) *ValidationResult {
 	ctx.SourceLines = splitLines(data)
-	v.validateWithContext(bytes.NewReader(data), ctx)
-	return &ValidationResult{
+	checkRawBytes(data, ctx)
+	v.validateWithContext(data, ctx)
+	result := &ValidationResult{
 		Collector:   ctx.Collector(),
 		SourceLines: ctx.SourceLines,
 	}
+	if ctx.OneErrorPerPath {
+		result.KeepFirstPerPath()
+	}
+	return result
 }
 
-func (v *Validator) validateWithContext(r io.Reader, ctx *ValidationContext) {
-	decoder := yaml.NewDecoder(r)
-	docIndex := 0
+// byteOrderMarks are the byte order marks reported by ForbidBOM.
+var byteOrderMarks = []struct {
+	name string
+	mark []byte
+}{
+	{"UTF-8", []byte{0xEF, 0xBB, 0xBF}},
+	{"UTF-16BE", []byte{0xFE, 0xFF}},
+	{"UTF-16LE", []byte{0xFF, 0xFE}},
+}
 
-	for {
-		var root yaml.Node
-		err := decoder.Decode(&root)
-		if err == io.EOF {
-			break
+// checkRawBytes applies the checks of the raw input that do not need decoding:
+// RequireFinalNewline and ForbidBOM.
+func checkRawBytes(data []byte, ctx *ValidationContext) {
+	if ctx.ForbidBOM {
+		for _, bom := range byteOrderMarks {
+			if bytes.HasPrefix(data, bom.mark) {
+				ctx.AddError(ValidationError{
+					Level:   LevelError,
+					Line:    1,
+					Column:  1,
+					Message: fmt.Sprintf("file starts with a %s byte order mark", bom.name),
+					Got:     fmt.Sprintf("% X", bom.mark),
+				})
+				break
+			}
 		}
-		if err != nil {
-			ctx.AddError(parseYAMLError(err, docIndex))
-			return
+	}
+	if ctx.RequireFinalNewline && len(data) > 0 && data[len(data)-1] != '\n' {
+		text := bytes.TrimPrefix(data, byteOrderMarks[0].mark) // a UTF-8 BOM takes no column
+		last := text[bytes.LastIndexByte(text, '\n')+1:]
+		ctx.AddError(ValidationError{
+			Level:   LevelWarning,
+			Line:    bytes.Count(data, []byte{'\n'}) + 1,
+			Column:  utf8.RuneCount(last) + 1,
+			Message: "file does not end with a newline",
+		})
+	}
+}
+
+func (v *Validator) validateWithContext(data []byte, ctx *ValidationContext) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	docIndex := 0
+	resumedAt := -1 // line index of the separator decoding last restarted at
+
+	expected := TypeAny
+	if v.schema != nil && v.selector == nil {
+		expected = v.schema.Type
+	}
+	requireContent := (ctx.RequireNonEmpty || (v.schema != nil && v.schema.Required)) && expected != TypeNull
+	sawContent, parseFailed := false, false
+	var emptyDoc *yaml.Node
+	docCount := 0
+	var firstExtra *yaml.Node
+	firstExtraIndex := 0
+
+	for {
+		var root yaml.Node
+		err := decoder.Decode(&root)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			parseErr := parseYAMLError(err, docIndex, ctx.SourceLines)
+			ctx.AddError(parseErr)
+			parseFailed = true
+			if !ctx.BestEffortMultiDoc || ctx.IsStopped() {
+				return
+			}
+			// yaml.v3 cannot continue after a syntax error, so restart decoding
+			// at the next document separator.
+			resumed, at, ok := resyncAfterLine(ctx.SourceLines, parseErr.Line, resumedAt)
+			if !ok {
+				return
+			}
+			resumedAt = at
+			decoder = yaml.NewDecoder(bytes.NewReader(resumed))
+			docIndex++
+			continue
+		}
+
+		if root.Kind == yaml.DocumentNode && len(root.Content) > 0 && !isEmptyDocument(root.Content[0]) {
+			docCount++
+			if ctx.MaxDocuments > 0 && docCount == ctx.MaxDocuments+1 {
+				firstExtra, firstExtraIndex = root.Content[0], docIndex
+			}
 		}
 
 		if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
-			prefix := ""
-			if docIndex > 0 {
-				prefix = fmt.Sprintf("doc[%d]", docIndex)
+			if requireContent && isEmptyDocument(root.Content[0]) {
+				if emptyDoc == nil {
+					emptyDoc = root.Content[0]
+				}
+			} else {
+				sawContent = true
+				prefix := ""
+				if docIndex > 0 {
+					prefix = fmt.Sprintf("doc[%d]", docIndex)
+				}
+				v.validateDocument(root.Content[0], prefix, ctx)
 			}
-			v.validateNode(root.Content[0], v.schema, prefix, ctx)
 		}
 
 		docIndex++
@@ -506,9 +1429,115 @@ func (v *Validator) validateWithContext(r io.Reader, ctx *ValidationContext) {
 			break
 		}
 	}
+
+	if !parseFailed {
+		v.checkDocumentCount(docCount, firstExtra, firstExtraIndex, ctx)
+	}
+
+	if requireContent && !sawContent && !parseFailed {
+		err := ValidationError{
+			Level:   LevelError,
+			Message: ctx.Message(MsgDocumentEmpty, "document is empty", "path", "", "expected", ""),
+		}
+		if expected != TypeAny {
+			err.Message = ctx.Message(MsgDocumentEmpty, fmt.Sprintf("document is empty, expected %s", expected),
+				"path", "", "expected", expected.String())
+			err.Expected = expected.String()
+		}
+		if emptyDoc != nil {
+			err.Line, err.Column = emptyDoc.Line, emptyDoc.Column
+		}
+		ctx.AddError(err)
+	}
+}
+
+// validateDocument validates one document root against the schema chosen for it.
+func (v *Validator) validateDocument(root *yaml.Node, path string, ctx *ValidationContext) {
+	if v.selector != nil && isEmptyDocument(root) {
+		return
+	}
+	schema := v.documentSchema(root)
+	if schema == nil {
+		ctx.AddError(withSpan(ValidationError{
+			Level:   LevelError,
+			Path:    path,
+			Line:    root.Line,
+			Column:  root.Column,
+			Message: "unrecognized document: no schema matches it",
+			Got:     v.describeNode(root),
+		}, root, ctx))
+		return
+	}
+	if ctx.ForbidAliases {
+		v.checkNoAliases(root, path, ctx)
+	}
+	ctx.budget = &documentBudget{}
+	ctx.document = root
+	v.validateNode(root, schema, path, ctx)
+	v.checkRequiredPaths(root, path, ctx)
+	v.checkForbiddenPaths(root, path, ctx)
+}
+
+// checkDocumentCount enforces MinDocuments/MaxDocuments. Too many documents are
+// reported at the first document over the limit.
+func (v *Validator) checkDocumentCount(count int, firstExtra *yaml.Node, extraIndex int, ctx *ValidationContext) {
+	if ctx.MaxDocuments > 0 && count > ctx.MaxDocuments {
+		path := fmt.Sprintf("doc[%d]", extraIndex)
+		ctx.AddError(ValidationError{
+			Level:    LevelError,
+			Path:     path,
+			Line:     firstExtra.Line,
+			Column:   firstExtra.Column,
+			Message:  ctx.Message(MsgTooManyDocuments, "too many documents", "path", path, "got", strconv.Itoa(count), "max", strconv.Itoa(ctx.MaxDocuments)),
+			Got:      strconv.Itoa(count),
+			Expected: fmt.Sprintf("at most %d", ctx.MaxDocuments),
+		})
+	}
+	if ctx.MinDocuments > 0 && count < ctx.MinDocuments {
+		ctx.AddError(ValidationError{
+			Level:    LevelError,
+			Message:  ctx.Message(MsgTooFewDocuments, "too few documents", "path", "", "got", strconv.Itoa(count), "min", strconv.Itoa(ctx.MinDocuments)),
+			Got:      strconv.Itoa(count),
+			Expected: fmt.Sprintf("at least %d", ctx.MinDocuments),
+		})
+	}
+}
+
+// isEmptyDocument reports whether a document root is the implicit null of a
+// document with no content (as opposed to an explicit "null" or "~").
+func isEmptyDocument(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null" && node.Value == "" && node.Style == 0
+}
+
+// resyncAfterLine returns the source starting at the first document separator
+// (---) after the 1-based line errLine that also lies after the 0-based line
+// index resumedAt, where decoding last restarted (-1 for none), and that
+// separator's index. Requiring progress matters because an error in a flow
+// collection opened on a "---" line is reported at that separator itself.
+// Preceding lines are replaced by empty lines so that positions reported by
+// the decoder stay unchanged.
+func resyncAfterLine(lines []string, errLine, resumedAt int) ([]byte, int, bool) {
+	if errLine <= 0 {
+		return nil, 0, false
+	}
+	for i := max(errLine, resumedAt+1); i < len(lines); i++ {
+		if isDocumentSeparator(lines[i]) {
+			var buf bytes.Buffer
+			buf.WriteString(strings.Repeat("\n", i))
+			buf.WriteString(strings.Join(lines[i:], "\n"))
+			buf.WriteString("\n")
+			return buf.Bytes(), i, true
+		}
+	}
+	return nil, 0, false
+}
+
+func isDocumentSeparator(line string) bool {
+	return line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---\t")
 }
 
 // InferTypeForPublic exposes internal type inference for external validators.
+// Validators that already have a context can call ctx.InferType instead.
 func (v *Validator) InferTypeForPublic(node *yaml.Node, ctx *ValidationContext) NodeType {
 	return v.inferType(node, ctx)
 }
@@ -521,10 +1550,19 @@ func (v *Validator) validateNode(node *yaml.Node, schema *FieldSchema, path stri
 	if schema == nil || ctx.IsStopped() {
 		return
 	}
+	if !ctx.spendBudget(node, path) {
+		return
+	}
 
 	// Resolve aliases
 	if node.Kind == yaml.AliasNode {
+		if ctx.ForbidAliases {
+			return // reported by checkNoAliases
+		}
 		if node.Alias != nil {
+			if !ctx.spendAlias(node, path) {
+				return
+			}
 			node = node.Alias
 		} else {
 			ctx.AddError(ValidationError{
@@ -532,49 +1570,143 @@ func (v *Validator) validateNode(node *yaml.Node, schema *FieldSchema, path stri
 				Path:    cleanPath(path),
 				Line:    node.Line,
 				Column:  node.Column,
-				Message: "unresolved alias",
+				Message: ctx.Message(MsgUnresolvedAlias, "unresolved alias", "path", cleanPath(path)),
 			})
 			return
 		}
 	}
 
+	if ctx.Trace != nil {
+		ctx.tracef(node, path, "%s value, schema type %s", v.inferType(node, ctx), schema.Type)
+	}
+
 	// Check deprecated
-	if schema.Deprecated != "" {
-		msg := schema.Deprecated
-		if msg == "true" {
-			msg = "this field is deprecated"
+	if msg, info := deprecation(schema); msg != "" {
+		ctx.AddError(ValidationError{
+			Level:       LevelWarning,
+			Path:        cleanPath(path),
+			Line:        node.Line,
+			Column:      node.Column,
+			Message:     msg,
+			Deprecation: info,
+		})
+	}
+
+	if schema.Stability != StabilityStable && !ctx.AllowExperimental {
+		level := LevelWarning
+		if schema.Stability == StabilityExperimental && ctx.StrictStability {
+			level = LevelError
 		}
 		ctx.AddError(ValidationError{
-			Level:   LevelWarning,
+			Level:   level,
 			Path:    cleanPath(path),
 			Line:    node.Line,
 			Column:  node.Column,
-			Message: msg,
+			Message: fmt.Sprintf("field is %s and may change without notice", schema.Stability),
 		})
 	}
 
+	// Interpolated scalars are resolved at deploy time; their final type is unknown
+	if ctx.AllowInterpolation && node.Kind == yaml.ScalarNode && ctx.isInterpolated(node.Value) {
+		if ctx.WarnInterpolation {
+			ctx.AddError(ValidationError{
+				Level:   LevelWarning,
+				Path:    cleanPath(path),
+				Line:    node.Line,
+				Column:  node.Column,
+				Message: "interpolated value not validated",
+				Got:     node.Value,
+			})
+		}
+		ctx.tracef(node, path, "interpolated, skipped")
+		return
+	}
+
+	if ctx.WarnAmbiguousUnquoted {
+		v.checkAmbiguousUnquoted(node, schema, path, ctx)
+	}
+
 	// Type check
 	if !v.checkTypeWithSchema(node, schema, path, ctx) {
+		if ctx.BestEffort && describesChildren(node, schema) {
+			ctx.tracef(node, path, "type check failed, validating children (best effort)")
+			v.validateChildren(node, schema, path, ctx)
+			return
+		}
+		ctx.tracef(node, path, "type check failed, children not validated")
 		return
 	}
 
+	if schema.NonEmpty && v.isEmptyValue(node, ctx) {
+		ctx.AddError(withSpan(ValidationError{
+			Level:    LevelError,
+			Path:     cleanPath(path),
+			Line:     node.Line,
+			Column:   node.Column,
+			Message:  ctx.Message(MsgEmptyValue, "value cannot be empty", "path", cleanPath(path), "expected", "non-empty "+v.inferType(node, ctx).String()),
+			Expected: "non-empty " + v.inferType(node, ctx).String(),
+			Got:      "empty",
+		}, node, ctx))
+	}
+
+	if schema.MaxNesting != nil {
+		v.checkNesting(node, *schema.MaxNesting, path, ctx)
+	}
+
+	if schema.RequireStyle != StyleAny {
+		v.checkStyle(node, schema, path, ctx)
+	}
+
 	// Structure validation
+	v.validateChildren(node, schema, path, ctx)
+
+	// Custom validators
+	for _, validator := range schema.Validators {
+		if ctx.IsStopped() {
+			return
+		}
+		validator.Validate(node, cleanPath(path), ctx)
+	}
+}
+
+// validateChildren validates the structure of a mapping or sequence.
+func (v *Validator) validateChildren(node *yaml.Node, schema *FieldSchema, path string, ctx *ValidationContext) {
 	switch node.Kind {
 	case yaml.MappingNode:
-		v.validateMapping(node, schema, path, ctx)
+		if schema.Type == TypeSet {
+			v.validateSet(node, schema, path, ctx)
+		} else {
+			v.validateMapping(node, schema, path, ctx)
+		}
 	case yaml.SequenceNode:
 		v.validateSequence(node, schema, path, ctx)
 	case yaml.ScalarNode:
 		// Scalars are validated via ValueValidators
 	}
+}
 
-	// Custom validators
-	for _, validator := range schema.Validators {
-		if ctx.IsStopped() {
-			return
-		}
-		validator.Validate(node, cleanPath(path), ctx)
+// describesChildren reports whether schema has sub-schemas for the children
+// of node, for ValidationContext.BestEffort.
+func describesChildren(node *yaml.Node, schema *FieldSchema) bool {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return len(schema.AllowedKeys) > 0 || schema.AdditionalProperties != nil || len(schema.AdditionalPropertiesByType) > 0
+	case yaml.SequenceNode:
+		return schema.ItemSchema != nil
+	}
+	return false
+}
+
+// isEmptyValue reports an empty string, sequence or mapping. Null is not empty;
+// it is governed by Nullable.
+func (v *Validator) isEmptyValue(node *yaml.Node, ctx *ValidationContext) bool {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value == "" && v.inferType(node, ctx) != TypeNull
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(node.Content) == 0
 	}
+	return false
 }
 
 func (v *Validator) checkTypeWithSchema(node *yaml.Node, schema *FieldSchema, path string, ctx *ValidationContext) bool {
@@ -599,9 +1731,13 @@ func (v *Validator) checkTypeWithSchema(node *yaml.Node, schema *FieldSchema, pa
 			Path:     cleanPath(path),
 			Line:     node.Line,
 			Column:   node.Column,
-			Message:  "unexpected null value",
+			Message:  ctx.Message(MsgUnexpectedNull, "unexpected null value", "path", cleanPath(path), "expected", expected.String()),
 			Expected: expected.String(),
 			Got:      "null",
+
+			GotType:      TypeNull,
+			ExpectedType: expected,
+			GotValue:     node.Value,
 		})
 		return false
 	}
@@ -610,26 +1746,94 @@ func (v *Validator) checkTypeWithSchema(node *yaml.Node, schema *FieldSchema, pa
 		return true
 	}
 
+	// Scalars with an unknown local tag cannot be type-checked
+	if actual == TypeAny {
+		if ctx.WarnUnknownTags {
+			ctx.AddError(ValidationError{
+				Level:    LevelWarning,
+				Path:     cleanPath(path),
+				Line:     node.Line,
+				Column:   node.Column,
+				Message:  fmt.Sprintf("unknown tag %q, type not checked", node.Tag),
+				Expected: expected.String(),
+				Got:      node.Tag,
+			})
+		}
+		return true
+	}
+
 	// Float accepts int
 	if expected == TypeFloat && actual == TypeInt {
 		return true
 	}
 
-	ctx.AddError(ValidationError{
+	// Sets are mappings; an untagged mapping can be a set if its values are null
+	if (expected == TypeMap && actual == TypeSet) || (expected == TypeSet && actual == TypeMap) {
+		return true
+	}
+
+	var gotValue string
+	if node.Kind == yaml.ScalarNode {
+		gotValue = node.Value
+	}
+	ctx.AddError(withSpan(ValidationError{
 		Level:    LevelError,
 		Path:     cleanPath(path),
 		Line:     node.Line,
 		Column:   node.Column,
-		Message:  "type mismatch",
+		Message:  ctx.Message(MsgTypeMismatch, "type mismatch", "path", cleanPath(path), "got", v.describeNode(node), "expected", expected.String()),
 		Expected: expected.String(),
 		Got:      v.describeNode(node),
-	})
+
+		GotType:      actual,
+		ExpectedType: expected,
+		GotValue:     gotValue,
+	}, node, ctx))
 	return false
 }
 
+// checkAmbiguousUnquoted warns about plain scalars in string fields that a
+// YAML 1.1 parser would resolve to another type.
+func (v *Validator) checkAmbiguousUnquoted(node *yaml.Node, schema *FieldSchema, path string, ctx *ValidationContext) {
+	if schema.Type != TypeString || node.Kind != yaml.ScalarNode || node.Style != 0 {
+		return
+	}
+	kind := yaml11ResolvedKind(node.Value)
+	if kind == "" {
+		return
+	}
+	ctx.AddError(ValidationError{
+		Level:   LevelWarning,
+		Path:    cleanPath(path),
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("unquoted value %q is read as %s by YAML 1.1 parsers; quote it to keep it a string", node.Value, kind),
+	})
+}
+
+// yaml11ResolvedKind reports which non-string type a plain scalar resolves to
+// under the YAML 1.1 type repository, or "" if it stays a string.
+func yaml11ResolvedKind(val string) string {
+	switch {
+	case yaml11BoolRe.MatchString(val):
+		return "a boolean"
+	case yaml11NullRe.MatchString(val):
+		return "null"
+	case yaml11IntRe.MatchString(val), val != "." && yaml11FloatRe.MatchString(val):
+		return "a number"
+	}
+	return ""
+}
+
 func (v *Validator) inferType(node *yaml.Node, ctx *ValidationContext) NodeType {
+	if t, ok := ctx.CustomTags[node.Tag]; ok && node.Kind != yaml.AliasNode {
+		return t
+	}
 	switch node.Kind {
 	case yaml.MappingNode:
+		if node.Tag == "!!set" {
+			return TypeSet
+		}
 		return TypeMap
 	case yaml.SequenceNode:
 		return TypeSequence
@@ -645,8 +1849,18 @@ func (v *Validator) inferType(node *yaml.Node, ctx *ValidationContext) NodeType
 	}
 }
 
+// isCustomTag reports a local or application tag such as !Ref, as opposed to
+// the standard !!-tags and the non-specific "!".
+func isCustomTag(tag string) bool {
+	return tag != "" && tag != "!" && !strings.HasPrefix(tag, "!!")
+}
+
 func (v *Validator) inferScalarType(node *yaml.Node, ctx *ValidationContext) NodeType {
-	// Step 1: By tags (yaml.v3 has already parsed)
+	// Step 1: By tags (yaml.v3 has already parsed; CustomTags are handled by inferType)
+	if isCustomTag(node.Tag) {
+		// The application decides what a local tag means; don't guess
+		return TypeAny
+	}
 	switch node.Tag {
 	case "!!str":
 		if ctx.YAML11Booleans {
@@ -793,53 +2007,51 @@ func (v *Validator) describeNode(node *yaml.Node) string {
 // Mapping Validation
 // ============================================================================
 
+// mergeSuffix is appended to findings for keys a mapping inherits via "<<".
+const mergeSuffix = " (inherited via merge)"
+
 func (v *Validator) validateMapping(node *yaml.Node, schema *FieldSchema, path string, ctx *ValidationContext) {
 	foundKeys := make(map[string]*yaml.Node)
 	keyNodes := make(map[string]*yaml.Node)
 
+	v.checkDuplicateKeys(node, path, ctx)
+
+	if !ctx.ForbidAliases && !v.spendMergeAliases(node, path, ctx) {
+		return
+	}
 	pairs := expandMappingWithMerges(node)
+	if ctx.ForbidAliases {
+		pairs = withoutAliasedPairs(pairs)
+	}
 
 	for _, kv := range pairs {
 		if ctx.IsStopped() {
 			return
 		}
 
-		keyNode := kv.key
-		valueNode := kv.value
-		key := keyNode.Value
-		fieldPath := joinPath(path, key)
-
-		foundKeys[key] = valueNode
-		keyNodes[key] = keyNode
-
-		// Key validators (for all keys)
-		for _, kv := range schema.KeyValidators {
-			kv.ValidateKey(key, keyNode, cleanPath(fieldPath), ctx)
-		}
-
-		// Known key?
-		if fieldSchema, ok := schema.AllowedKeys[key]; ok {
-			v.validateNode(valueNode, fieldSchema, fieldPath, ctx)
-			continue
-		}
-
-		// Unknown key handling
-		if schema.AdditionalProperties != nil {
-			// Validate value against AdditionalProperties schema
-			v.validateNode(valueNode, schema.AdditionalProperties, fieldPath, ctx)
+		key := v.canonicalKey(schema, kv.key, path, ctx)
+		if prev, ok := keyNodes[key]; ok && prev.Value != kv.key.Value {
+			ctx.AddError(ValidationError{
+				Level:   LevelError,
+				Path:    cleanPath(joinPath(path, key)),
+				Line:    kv.key.Line,
+				Column:  kv.key.Column,
+				Message: fmt.Sprintf("keys %q and %q both set field %q", prev.Value, kv.key.Value, key),
+			})
 			continue
 		}
-
-		// Report unknown key based on policy
-		level, report := v.resolveUnknownKeyLevel(schema.UnknownKeyPolicy, ctx)
-		if report {
-			ctx.AddError(ValidationError{
-				Level:   level,
-				Path:    cleanPath(fieldPath),
-				Line:    keyNode.Line,
-				Column:  keyNode.Column,
-				Message: fmt.Sprintf("unknown key %q", key),
-				Got:     v.describeNode(valueNode),
+		foundKeys[key] = kv.value
+		keyNodes[key] = kv.key
+
+		mark := ctx.collector.mark()
+		v.validateMappingPair(key, kv.key, kv.value, schema, joinPath(path, key), ctx)
+		if kv.merged {
+			// Merged values are reported at the anchor; say why the key applies here,
+			// once, even when the value also inherited keys through nested merges.
+			ctx.collector.rewriteSince(mark, func(err *ValidationError) {
+				if !strings.HasSuffix(err.Message, mergeSuffix) {
+					err.Message += mergeSuffix
+				}
 			})
 		}
 	}
@@ -848,14 +2060,49 @@ func (v *Validator) validateMapping(node *yaml.Node, schema *FieldSchema, path s
 	v.checkRequiredFields(node, schema, path, foundKeys, ctx)
 	v.checkDefaults(node, schema, path, foundKeys, ctx)
 	v.checkAnyOf(node, schema, path, foundKeys, ctx)
+	v.checkAtLeastNOf(node, schema, path, foundKeys, ctx)
+	v.checkAtMostNOf(schema, path, foundKeys, keyNodes, ctx)
 	v.checkExactlyOneOf(node, schema, path, foundKeys, keyNodes, ctx)
+	v.checkExactlyOneGroupOf(node, schema, path, foundKeys, keyNodes, ctx)
 	v.checkMutuallyExclusive(node, schema, path, foundKeys, keyNodes, ctx)
+	v.checkAllOrNone(schema, path, foundKeys, keyNodes, ctx)
 	v.checkConditions(node, schema, path, foundKeys, keyNodes, ctx)
+
+	for _, mv := range schema.MapValidators {
+		if ctx.IsStopped() {
+			return
+		}
+		mv.ValidateMap(node, cleanPath(path), ctx)
+	}
+}
+
+// MappingLookup returns the key and value nodes for key in a mapping node,
+// honoring merge keys (<<). Both are nil if the key is absent or node is not a mapping.
+func MappingLookup(node *yaml.Node, key string) (keyNode, valueNode *yaml.Node) {
+	for _, kv := range expandMappingWithMerges(node) {
+		if kv.key.Value == key {
+			return kv.key, kv.value
+		}
+	}
+	return nil, nil
+}
+
+// MappingKeys returns the keys of a mapping node, including those introduced by
+// merge keys (<<), in the order MappingLookup sees them. It returns nil if node
+// is not a mapping.
+func MappingKeys(node *yaml.Node) []string {
+	var keys []string
+	for _, kv := range expandMappingWithMerges(node) {
+		keys = append(keys, kv.key.Value)
+	}
+	return keys
 }
 
 type kvPair struct {
-	key   *yaml.Node
-	value *yaml.Node
+	key      *yaml.Node
+	value    *yaml.Node
+	merged   bool // introduced by a merge key (<<) rather than written in the mapping
+	viaAlias bool // merged from an aliased mapping (<<: *anchor)
 }
 
 // expandMappingWithMerges expands YAML merge keys (<<) into concrete key/value pairs.
@@ -883,7 +2130,11 @@ func extractMergePairs(val *yaml.Node) []kvPair {
 	switch val.Kind {
 	case yaml.AliasNode:
 		if val.Alias != nil {
-			return extractMergePairs(val.Alias)
+			pairs := extractMergePairs(val.Alias)
+			for i := range pairs {
+				pairs[i].viaAlias = true
+			}
+			return pairs
 		}
 	case yaml.MappingNode:
 		return mappingToPairs(val)
@@ -900,11 +2151,80 @@ func extractMergePairs(val *yaml.Node) []kvPair {
 func mappingToPairs(m *yaml.Node) []kvPair {
 	var out []kvPair
 	for i := 0; i < len(m.Content); i += 2 {
-		out = append(out, kvPair{key: m.Content[i], value: m.Content[i+1]})
+		out = append(out, kvPair{key: m.Content[i], value: m.Content[i+1], merged: true})
 	}
 	return out
 }
 
+// spendMergeAliases counts the aliases followed by the merge keys of a mapping
+// against MaxAliasExpansions.
+func (v *Validator) spendMergeAliases(node *yaml.Node, path string, ctx *ValidationContext) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "<<" {
+			continue
+		}
+		merge := node.Content[i+1]
+		refs := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			refs = merge.Content
+		}
+		for _, ref := range refs {
+			if ref.Kind == yaml.AliasNode && !ctx.spendAlias(ref, joinPath(path, "<<")) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func withoutAliasedPairs(pairs []kvPair) []kvPair {
+	out := pairs[:0:0]
+	for _, kv := range pairs {
+		if !kv.viaAlias {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// checkNoAliases reports every anchor and alias in the document (ForbidAliases).
+// Aliases are not followed.
+func (v *Validator) checkNoAliases(node *yaml.Node, path string, ctx *ValidationContext) {
+	if node.Anchor != "" {
+		ctx.AddError(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(path),
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: fmt.Sprintf("anchor &%s is not allowed", node.Anchor),
+		})
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		ctx.AddError(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(path),
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: fmt.Sprintf("alias *%s is not allowed", node.Value),
+		})
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			v.checkNoAliases(child, path, ctx)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			v.checkNoAliases(item, fmt.Sprintf("%s[%d]", path, i), ctx)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			v.checkNoAliases(key, path, ctx)
+			v.checkNoAliases(value, joinPath(path, key.Value), ctx)
+		}
+	}
+}
+
 // dedupePairsKeepLast keeps the last occurrence of each key to model merge override and explicit override.
 func dedupePairsKeepLast(pairs []kvPair) []kvPair {
 	seen := make(map[string]int)
@@ -920,35 +2240,277 @@ func dedupePairsKeepLast(pairs []kvPair) []kvPair {
 	return out
 }
 
-func (v *Validator) resolveUnknownKeyLevel(policy UnknownKeyPolicy, ctx *ValidationContext) (ErrorLevel, bool) {
-	switch policy {
-	case UnknownKeyError:
-		return LevelError, true
-	case UnknownKeyWarn:
-		return LevelWarning, true
-	case UnknownKeyIgnore:
-		return 0, false
-	case UnknownKeyInherit:
-		fallthrough
+// checkDuplicateKeys reports explicit keys that occur more than once in the raw
+// mapping, at the repeated occurrence.
+func (v *Validator) checkDuplicateKeys(node *yaml.Node, path string, ctx *ValidationContext) {
+	var level ErrorLevel
+	switch ctx.DuplicateKeyPolicy {
+	case DuplicateKeyIgnore:
+		return
+	case DuplicateKeyError:
+		level = LevelError
 	default:
-		if ctx.StrictKeys {
-			return LevelError, true
+		level = LevelWarning
+	}
+
+	first := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Value == "<<" {
+			continue
 		}
-		return LevelWarning, true
+		prev, ok := first[keyNode.Value]
+		if !ok {
+			first[keyNode.Value] = keyNode
+			continue
+		}
+		msg := ctx.Message(MsgDuplicateKey,
+			fmt.Sprintf("duplicate key %q (first defined at line %d); the last value wins", keyNode.Value, prev.Line),
+			"path", cleanPath(joinPath(path, keyNode.Value)), "key", keyNode.Value, "line", strconv.Itoa(prev.Line))
+		ctx.AddError(ValidationError{
+			Level:   level,
+			Path:    cleanPath(joinPath(path, keyNode.Value)),
+			Line:    keyNode.Line,
+			Column:  keyNode.Column,
+			Message: msg,
+		})
 	}
 }
 
-func (v *Validator) checkRequiredFields(node *yaml.Node, schema *FieldSchema, path string,
-	foundKeys map[string]*yaml.Node, ctx *ValidationContext) {
+// canonicalKey returns the AllowedKeys name for a written key, resolving field
+// Aliases and, with CaseInsensitiveKeys, case differences (both warn).
+func (v *Validator) canonicalKey(schema *FieldSchema, keyNode *yaml.Node, path string, ctx *ValidationContext) string {
+	key := keyNode.Value
+	if _, ok := schema.AllowedKeys[key]; ok {
+		return key
+	}
+	for allowed, fieldSchema := range schema.AllowedKeys {
+		for _, alias := range fieldSchema.Aliases {
+			if alias == key {
+				// The field's own deprecation is repeated here so that it survives
+				// when only one finding per path is kept.
+				msg := fmt.Sprintf("key %q is a deprecated alias, use %q", key, allowed)
+				depMsg, info := deprecation(fieldSchema)
+				if depMsg != "" {
+					msg += fmt.Sprintf("; %q: %s", allowed, depMsg)
+				}
+				ctx.AddError(ValidationError{
+					Level:       LevelWarning,
+					Path:        cleanPath(joinPath(path, allowed)),
+					Line:        keyNode.Line,
+					Column:      keyNode.Column,
+					Message:     msg,
+					Got:         key,
+					Expected:    allowed,
+					Deprecation: info,
+				})
+				return allowed
+			}
+		}
+	}
+	if schema.NumericKeys && ctx.InferType(keyNode) == TypeInt {
+		if allowed, ok := numericAllowedKey(schema, key); ok {
+			return allowed
+		}
+	}
+	if !schema.CaseInsensitiveKeys {
+		return key
+	}
+	for allowed := range schema.AllowedKeys {
+		if strings.EqualFold(allowed, key) {
+			ctx.AddError(ValidationError{
+				Level:    LevelWarning,
+				Path:     cleanPath(joinPath(path, allowed)),
+				Line:     keyNode.Line,
+				Column:   keyNode.Column,
+				Message:  fmt.Sprintf("key %q differs in case from %q", key, allowed),
+				Got:      key,
+				Expected: allowed,
+			})
+			return allowed
+		}
+	}
+	return key
+}
+
+// numericAllowedKey finds the AllowedKeys entry with the same integer value as key.
+func numericAllowedKey(schema *FieldSchema, key string) (string, bool) {
+	want, ok := normalizeIntKey(key)
+	if !ok {
+		return "", false
+	}
+	for allowed := range schema.AllowedKeys {
+		if n, ok := normalizeIntKey(allowed); ok && n == want {
+			return allowed, true
+		}
+	}
+	return "", false
+}
+
+// normalizeIntKey returns the decimal form of a YAML 1.2 integer
+// ("+80", "0x50", "0o120", "0b1010000" all give "80").
+func normalizeIntKey(s string) (string, bool) {
+	digits, base, ok := SplitYAMLInt(s)
+	if !ok {
+		return "", false
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return "", false
+	}
+	return n.String(), true
+}
+
+// SplitYAMLInt splits a YAML 1.2 integer (decimal, 0x hex, 0o octal or 0b
+// binary, with an optional sign) into its signed digits and base: "-0x1F"
+// gives "-1F" and 16. The digits are not checked; ok is false when s has no
+// digits after the sign and prefix. Validators that parse integers use it so
+// that they accept the same forms as NumericKeys.
+func SplitYAMLInt(s string) (digits string, base int, ok bool) {
+	sign := ""
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		sign, s = s[:1], s[1:]
+	}
+	base = 10
+	switch {
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		base, s = 16, s[2:]
+	case strings.HasPrefix(s, "0o") || strings.HasPrefix(s, "0O"):
+		base, s = 8, s[2:]
+	case strings.HasPrefix(s, "0b") || strings.HasPrefix(s, "0B"):
+		base, s = 2, s[2:]
+	}
+	if s == "" || strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		return "", 0, false
+	}
+	return sign + s, base, true
+}
+
+// validateMappingPair validates one key/value pair of a mapping: key validators,
+// then the value against AllowedKeys or AdditionalProperties, or the unknown key policy.
+// key is the schema spelling of keyNode.Value (see canonicalKey).
+func (v *Validator) validateMappingPair(key string, keyNode, valueNode *yaml.Node, schema *FieldSchema, fieldPath string, ctx *ValidationContext) {
+	// Key validators (for all keys)
+	mark := ctx.collector.mark()
+	for _, kv := range schema.KeyValidators {
+		kv.ValidateKey(keyNode.Value, keyNode, cleanPath(fieldPath), ctx)
+	}
+	if schema.SkipValueOnKeyError && len(ctx.collector.errors) > mark.errors {
+		ctx.tracef(keyNode, fieldPath, "invalid key, value not validated")
+		return
+	}
+
+	// Known key?
+	if fieldSchema, ok := schema.AllowedKeys[key]; ok {
+		if keyNode.Value != key {
+			ctx.tracef(keyNode, fieldPath, "known key (written as %q)", keyNode.Value)
+		} else {
+			ctx.tracef(keyNode, fieldPath, "known key")
+		}
+		v.validateNode(valueNode, fieldSchema, fieldPath, ctx)
+		return
+	}
+
+	// Unknown key handling
+	if typed, t := v.additionalSchemaByType(valueNode, schema, ctx); typed != nil {
+		ctx.tracef(keyNode, fieldPath, "additional property (%s)", t)
+		v.validateAdditionalProperty(valueNode, typed, schema.AdditionalPropertiesLevel, fieldPath, ctx)
+		return
+	}
+	if schema.AdditionalProperties != nil {
+		// Validate value against AdditionalProperties schema
+		ctx.tracef(keyNode, fieldPath, "additional property")
+		v.validateAdditionalProperty(valueNode, schema.AdditionalProperties, schema.AdditionalPropertiesLevel, fieldPath, ctx)
+		return
+	}
+
+	// Report unknown key based on policy
+	level, report := v.resolveUnknownKeyLevel(schema.UnknownKeyPolicy, ctx)
+	if !report {
+		ctx.tracef(keyNode, fieldPath, "unknown key, ignored")
+		return
+	}
+	ctx.tracef(keyNode, fieldPath, "unknown key, reported as %s", strings.ToLower(level.String()))
+	ctx.AddError(ValidationError{
+		Level:   level,
+		Path:    cleanPath(fieldPath),
+		Line:    keyNode.Line,
+		Column:  keyNode.Column,
+		Message: ctx.Message(MsgUnknownKey, fmt.Sprintf("unknown key %q", key), "path", cleanPath(fieldPath), "key", key),
+		Got:     v.describeNode(valueNode),
+	})
+}
+
+// validateAdditionalProperty validates the value of an additional property,
+// reporting its errors at level when one is set.
+func (v *Validator) validateAdditionalProperty(valueNode *yaml.Node, schema *FieldSchema, level *ErrorLevel, fieldPath string, ctx *ValidationContext) {
+	if level == nil || *level == LevelError {
+		v.validateNode(valueNode, schema, fieldPath, ctx)
+		return
+	}
+	// Validate on a fork so demoted errors do not trigger StopOnFirst.
+	fork := ctx.Fork()
+	fork.StopOnFirst = false
+	v.validateNode(valueNode, schema, fieldPath, fork)
+	for _, err := range fork.collector.All() {
+		if err.Level == LevelError {
+			err.Level = *level
+		}
+		ctx.AddError(err)
+	}
+}
+
+// additionalSchemaByType returns the AdditionalPropertiesByType schema for the
+// value's inferred type, and the type it was found under.
+func (v *Validator) additionalSchemaByType(valueNode *yaml.Node, schema *FieldSchema, ctx *ValidationContext) (*FieldSchema, NodeType) {
+	if len(schema.AdditionalPropertiesByType) == 0 {
+		return nil, TypeAny
+	}
+	t := v.inferType(valueNode, ctx)
+	if typed := schema.AdditionalPropertiesByType[t]; typed != nil {
+		return typed, t
+	}
+	switch t {
+	case TypeInt:
+		t = TypeFloat
+	case TypeSet:
+		t = TypeMap
+	default:
+		return nil, TypeAny
+	}
+	return schema.AdditionalPropertiesByType[t], t
+}
 
+func (v *Validator) resolveUnknownKeyLevel(policy UnknownKeyPolicy, ctx *ValidationContext) (ErrorLevel, bool) {
+	switch policy {
+	case UnknownKeyError:
+		return LevelError, true
+	case UnknownKeyWarn:
+		return LevelWarning, true
+	case UnknownKeyIgnore:
+		return 0, false
+	case UnknownKeyInherit:
+		fallthrough
+	default:
+		if ctx.StrictKeys {
+			return LevelError, true
+		}
+		return LevelWarning, true
+	}
+}
+
+func (v *Validator) checkRequiredFields(node *yaml.Node, schema *FieldSchema, path string,
+	foundKeys map[string]*yaml.Node, ctx *ValidationContext) {
+
+	line, col := missingKeyPosition(node)
 	for key, fieldSchema := range schema.AllowedKeys {
 		if fieldSchema.Required && foundKeys[key] == nil {
 			ctx.AddError(ValidationError{
 				Level:   LevelError,
 				Path:    cleanPath(joinPath(path, key)),
-				Line:    node.Line,
-				Column:  node.Column,
-				Message: fmt.Sprintf("required field %q is missing", key),
+				Line:    line,
+				Column:  col,
+				Message: ctx.Message(MsgRequiredMissing, fmt.Sprintf("required field %q is missing", key), "path", cleanPath(joinPath(path, key)), "key", key),
 			})
 		}
 	}
@@ -958,15 +2520,96 @@ func (v *Validator) checkDefaults(node *yaml.Node, schema *FieldSchema, path str
 	foundKeys map[string]*yaml.Node, ctx *ValidationContext) {
 
 	for key, fieldSchema := range schema.AllowedKeys {
-		if fieldSchema.Default != nil && foundKeys[key] == nil && !fieldSchema.Required {
-			ctx.AddError(ValidationError{
-				Level:   LevelWarning,
-				Path:    cleanPath(joinPath(path, key)),
-				Line:    node.Line,
-				Column:  node.Column,
-				Message: fmt.Sprintf("field %q not set, will use default: %v", key, fieldSchema.Default),
-			})
+		if value := foundKeys[key]; value != nil {
+			if ctx.WarnRedundantDefaults && fieldSchema.Default != nil && equalsDefault(value, fieldSchema.Default) {
+				ctx.AddError(ValidationError{
+					Level:   LevelWarning,
+					Path:    cleanPath(joinPath(path, key)),
+					Line:    value.Line,
+					Column:  value.Column,
+					Message: fmt.Sprintf("field %q is set to its default value %v and can be omitted", key, fieldSchema.Default),
+					Got:     v.describeNode(value),
+				})
+			}
+			continue
 		}
+		if fieldSchema.Required {
+			continue
+		}
+		v.checkAbsentDefaults(node, fieldSchema, key, cleanPath(joinPath(path, key)), ctx,
+			map[*FieldSchema]bool{schema: true})
+	}
+}
+
+// equalsDefault reports whether node decodes to the same value as def, which
+// is first round-tripped through YAML so both sides have the same Go types.
+func equalsDefault(node *yaml.Node, def interface{}) bool {
+	var got, want interface{}
+	if err := node.Decode(&got); err != nil {
+		return false
+	}
+	var encoded yaml.Node
+	if err := encoded.Encode(def); err != nil {
+		return false
+	}
+	if err := encoded.Decode(&want); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(normalizeNumbers(got), normalizeNumbers(want))
+}
+
+// normalizeNumbers converts the integers in a decoded value to float64, so
+// 8080 and 8080.0 compare equal.
+func normalizeNumbers(value interface{}) interface{} {
+	switch val := value.(type) {
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case uint64:
+		return float64(val)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = normalizeNumbers(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = normalizeNumbers(item)
+		}
+		return out
+	}
+	return value
+}
+
+// checkAbsentDefaults reports the default of a missing field or, for a missing
+// mapping field without its own default, the defaults of its children.
+func (v *Validator) checkAbsentDefaults(anchor *yaml.Node, schema *FieldSchema, key, path string,
+	ctx *ValidationContext, seen map[*FieldSchema]bool) {
+
+	if schema.Default != nil {
+		ctx.AddError(ValidationError{
+			Level:   LevelWarning,
+			Path:    path,
+			Line:    anchor.Line,
+			Column:  anchor.Column,
+			Message: fmt.Sprintf("field %q not set, will use default: %v", key, schema.Default),
+		})
+		return
+	}
+	if schema.Type != TypeMap || seen[schema] {
+		return
+	}
+	seen[schema] = true
+	defer delete(seen, schema)
+
+	for childKey, childSchema := range schema.AllowedKeys {
+		if childSchema.Required {
+			continue
+		}
+		v.checkAbsentDefaults(anchor, childSchema, childKey, joinPath(path, childKey), ctx, seen)
 	}
 }
 
@@ -978,35 +2621,134 @@ func (v *Validator) checkAnyOf(node *yaml.Node, schema *FieldSchema, path string
 	}
 
 	for _, group := range schema.AnyOf {
-		allPresent := true
-		for _, key := range group {
-			if foundKeys[key] == nil {
-				allPresent = false
-				break
-			}
-		}
-		if allPresent {
+		if groupPresent(group, foundKeys) {
 			return // At least one group is fully present
 		}
 	}
 
 	// No group is fully present
-	var groupStrs []string
-	for _, g := range schema.AnyOf {
+	ctx.AddError(withSpan(ValidationError{
+		Level:   LevelError,
+		Path:    cleanPath(path),
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf("at least one of %s is required", formatGroups(schema.AnyOf, " or ")),
+	}, node, ctx))
+}
+
+func (v *Validator) checkExactlyOneGroupOf(node *yaml.Node, schema *FieldSchema, path string,
+	foundKeys map[string]*yaml.Node, keyNodes map[string]*yaml.Node, ctx *ValidationContext) {
+
+	if len(schema.ExactlyOneGroupOf) == 0 {
+		return
+	}
+
+	var found [][]string
+	for _, group := range schema.ExactlyOneGroupOf {
+		if len(group) > 0 && groupPresent(group, foundKeys) {
+			found = append(found, group)
+		}
+	}
+
+	if len(found) == 0 {
+		ctx.AddError(withSpan(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(path),
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: fmt.Sprintf("exactly one of %s is required, none found", formatGroups(schema.ExactlyOneGroupOf, " or ")),
+		}, node, ctx))
+	} else if len(found) > 1 {
+		msg := fmt.Sprintf("exactly one of %s is required, found: %s",
+			formatGroups(schema.ExactlyOneGroupOf, " or "), formatGroups(found, ", "))
+		ctx.AddError(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(path),
+			Line:    keyNodes[found[1][0]].Line,
+			Column:  keyNodes[found[1][0]].Column,
+			Message: msg,
+		})
+	}
+}
+
+// groupPresent reports whether every key of the group is present.
+func groupPresent(group []string, foundKeys map[string]*yaml.Node) bool {
+	for _, key := range group {
+		if foundKeys[key] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// formatGroups renders field groups as `"a" or ("b" and "c")`.
+func formatGroups(groups [][]string, sep string) string {
+	groupStrs := make([]string, 0, len(groups))
+	for _, g := range groups {
 		if len(g) == 1 {
 			groupStrs = append(groupStrs, fmt.Sprintf("%q", g[0]))
 		} else {
 			groupStrs = append(groupStrs, fmt.Sprintf("(%s)", strings.Join(quoteAll(g), " and ")))
 		}
 	}
+	return strings.Join(groupStrs, sep)
+}
 
-	ctx.AddError(ValidationError{
-		Level:   LevelError,
-		Path:    cleanPath(path),
-		Line:    node.Line,
-		Column:  node.Column,
-		Message: fmt.Sprintf("at least one of %s is required", strings.Join(groupStrs, " or ")),
-	})
+func (v *Validator) checkAtLeastNOf(node *yaml.Node, schema *FieldSchema, path string,
+	foundKeys map[string]*yaml.Node, ctx *ValidationContext) {
+
+	for _, rule := range schema.AtLeastNOf {
+		found := []string{}
+		for _, key := range rule.Keys {
+			if foundKeys[key] != nil {
+				found = append(found, key)
+			}
+		}
+		if len(found) >= rule.N {
+			continue
+		}
+		ctx.AddError(withSpan(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(path),
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: fmt.Sprintf("at least %d of %v are required, found: %v", rule.N, rule.Keys, found),
+		}, node, ctx))
+	}
+}
+
+func (v *Validator) checkAtMostNOf(schema *FieldSchema, path string,
+	foundKeys map[string]*yaml.Node, keyNodes map[string]*yaml.Node, ctx *ValidationContext) {
+
+	for _, rule := range schema.AtMostNOf {
+		if rule.N < 0 {
+			continue // rejected by ValidateSchema
+		}
+		var found []string
+		for _, key := range rule.Keys {
+			if foundKeys[key] != nil {
+				found = append(found, key)
+			}
+		}
+		if len(found) <= rule.N {
+			continue
+		}
+		// Report at the first key past the cap in document order.
+		sort.Slice(found, func(i, j int) bool {
+			a, b := keyNodes[found[i]], keyNodes[found[j]]
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+		ctx.AddError(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(path),
+			Line:    keyNodes[found[rule.N]].Line,
+			Column:  keyNodes[found[rule.N]].Column,
+			Message: fmt.Sprintf("at most %d of %v may be set, found: %v", rule.N, rule.Keys, found),
+		})
+	}
 }
 
 func (v *Validator) checkExactlyOneOf(node *yaml.Node, schema *FieldSchema, path string,
@@ -1024,13 +2766,13 @@ func (v *Validator) checkExactlyOneOf(node *yaml.Node, schema *FieldSchema, path
 	}
 
 	if len(found) == 0 {
-		ctx.AddError(ValidationError{
+		ctx.AddError(withSpan(ValidationError{
 			Level:   LevelError,
 			Path:    cleanPath(path),
 			Line:    node.Line,
 			Column:  node.Column,
 			Message: fmt.Sprintf("exactly one of %v is required, none found", schema.ExactlyOneOf),
-		})
+		}, node, ctx))
 	} else if len(found) > 1 {
 		ctx.AddError(ValidationError{
 			Level:   LevelError,
@@ -1062,7 +2804,32 @@ func (v *Validator) checkMutuallyExclusive(node *yaml.Node, schema *FieldSchema,
 			Path:    cleanPath(path),
 			Line:    keyNodes[found[1]].Line,
 			Column:  keyNodes[found[1]].Column,
-			Message: fmt.Sprintf("fields %v are mutually exclusive", found),
+			Message: ctx.Message(MsgMutuallyExclusive, fmt.Sprintf("fields %v are mutually exclusive", found), "path", cleanPath(path), "fields", strings.Join(found, ", ")),
+		})
+	}
+}
+
+func (v *Validator) checkAllOrNone(schema *FieldSchema, path string,
+	foundKeys map[string]*yaml.Node, keyNodes map[string]*yaml.Node, ctx *ValidationContext) {
+
+	for _, group := range schema.AllOrNone {
+		var found, missing []string
+		for _, key := range group {
+			if foundKeys[key] != nil {
+				found = append(found, key)
+			} else {
+				missing = append(missing, key)
+			}
+		}
+		if len(found) == 0 || len(missing) == 0 {
+			continue
+		}
+		ctx.AddError(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(path),
+			Line:    keyNodes[found[0]].Line,
+			Column:  keyNodes[found[0]].Column,
+			Message: fmt.Sprintf("fields %v must be set together or not at all, found: %v, missing: %v", group, found, missing),
 		})
 	}
 }
@@ -1081,9 +2848,13 @@ func (v *Validator) checkConditions(node *yaml.Node, schema *FieldSchema, path s
 			continue
 		}
 
-		if condNode.Value != rule.ConditionValue {
+		if !rule.holds(condNode.Value) {
 			continue
 		}
+		condition := fmt.Sprintf("%s=%q", rule.ConditionField, rule.ConditionValue)
+		if rule.ConditionPattern != nil {
+			condition = fmt.Sprintf("%s matches %q", rule.ConditionField, rule.ConditionPattern.String())
+		}
 
 		// ThenRequired
 		for _, reqKey := range rule.ThenRequired {
@@ -1093,8 +2864,10 @@ func (v *Validator) checkConditions(node *yaml.Node, schema *FieldSchema, path s
 					Path:   cleanPath(joinPath(path, reqKey)),
 					Line:   condNode.Line,
 					Column: condNode.Column,
-					Message: fmt.Sprintf("field %q is required when %s=%q",
-						reqKey, rule.ConditionField, rule.ConditionValue),
+					Message: ctx.Message(MsgRequiredWhen,
+						fmt.Sprintf("field %q is required when %s", reqKey, condition),
+						"path", cleanPath(joinPath(path, reqKey)), "key", reqKey,
+						"field", rule.ConditionField, "value", condNode.Value),
 				})
 			}
 		}
@@ -1107,11 +2880,85 @@ func (v *Validator) checkConditions(node *yaml.Node, schema *FieldSchema, path s
 					Path:   cleanPath(joinPath(path, forbKey)),
 					Line:   keyNode.Line,
 					Column: keyNode.Column,
-					Message: fmt.Sprintf("field %q is forbidden when %s=%q",
-						forbKey, rule.ConditionField, rule.ConditionValue),
+					Message: ctx.Message(MsgForbiddenWhen,
+						fmt.Sprintf("field %q is forbidden when %s", forbKey, condition),
+						"path", cleanPath(joinPath(path, forbKey)), "key", forbKey,
+						"field", rule.ConditionField, "value", condNode.Value),
 				})
 			}
 		}
+
+		// ThenSchema: the mapping is validated a second time, so drop findings
+		// about the document itself (e.g. duplicate keys) already reported. A
+		// then-schema that leads back to itself on the same mapping (directly or
+		// through other conditions) is not applied again, which would never end.
+		visit := thenVisit{node, rule.ThenSchema}
+		if rule.ThenSchema != nil && !ctx.thenActive[visit] {
+			if ctx.thenActive == nil {
+				ctx.thenActive = make(map[thenVisit]bool)
+			}
+			ctx.thenActive[visit] = true
+			mark := ctx.collector.mark()
+			v.validateNode(node, rule.ThenSchema, path, ctx)
+			ctx.collector.dropRepeatsSince(mark)
+			delete(ctx.thenActive, visit)
+		}
+	}
+}
+
+// holds reports whether the condition field's scalar value triggers the rule.
+func (rule ConditionalRule) holds(value string) bool {
+	if rule.ConditionPattern != nil {
+		return rule.ConditionPattern.MatchString(value)
+	}
+	return value == rule.ConditionValue
+}
+
+// ============================================================================
+// Set Validation
+// ============================================================================
+
+// validateSet checks a TypeSet mapping: every member has a null value and
+// appears once, the member count is within MinItems/MaxItems, and each member
+// (the key) matches ItemSchema.
+func (v *Validator) validateSet(node *yaml.Node, schema *FieldSchema, path string, ctx *ValidationContext) {
+	v.checkItemCount(node, len(node.Content)/2, schema, path, ctx)
+
+	seen := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if ctx.IsStopped() {
+			return
+		}
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		memberPath := joinPath(path, keyNode.Value)
+
+		if prev, ok := seen[keyNode.Value]; ok {
+			ctx.AddError(ValidationError{
+				Level:   LevelError,
+				Path:    cleanPath(memberPath),
+				Line:    keyNode.Line,
+				Column:  keyNode.Column,
+				Message: fmt.Sprintf("duplicate set member %q (first defined at line %d)", keyNode.Value, prev.Line),
+			})
+			continue
+		}
+		seen[keyNode.Value] = keyNode
+
+		if v.inferType(valueNode, ctx) != TypeNull {
+			ctx.AddError(ValidationError{
+				Level:    LevelError,
+				Path:     cleanPath(memberPath),
+				Line:     valueNode.Line,
+				Column:   valueNode.Column,
+				Message:  "set members cannot have values",
+				Expected: "null",
+				Got:      v.describeNode(valueNode),
+			})
+		}
+
+		if schema.ItemSchema != nil {
+			v.validateNode(keyNode, schema.ItemSchema, memberPath, ctx)
+		}
 	}
 }
 
@@ -1120,42 +2967,219 @@ func (v *Validator) checkConditions(node *yaml.Node, schema *FieldSchema, path s
 // ============================================================================
 
 func (v *Validator) validateSequence(node *yaml.Node, schema *FieldSchema, path string, ctx *ValidationContext) {
-	length := len(node.Content)
+	v.checkItemCount(node, len(node.Content), schema, path, ctx)
 
-	if schema.MinItems != nil && length < *schema.MinItems {
+	if schema.ItemSchema != nil || schema.ItemsNonNull {
+		for i, item := range node.Content {
+			if ctx.IsStopped() {
+				return
+			}
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if schema.ItemsNonNull && v.inferType(item, ctx) == TypeNull {
+				ctx.AddError(ValidationError{
+					Level:   LevelError,
+					Path:    cleanPath(itemPath),
+					Line:    item.Line,
+					Column:  item.Column,
+					Message: ctx.Message(MsgNullItem, "null item not allowed", "path", cleanPath(itemPath)),
+					Got:     "null",
+				})
+				continue
+			}
+			v.validateNode(item, schema.ItemSchema, itemPath, ctx)
+		}
+	}
+
+	if len(schema.UniqueFields) > 0 {
+		v.checkUniqueFields(node, schema.UniqueFields, path, ctx)
+	}
+
+	for _, sv := range schema.SeqValidators {
+		if ctx.IsStopped() {
+			return
+		}
+		sv.ValidateSeq(node, cleanPath(path), ctx)
+	}
+}
+
+// checkUniqueFields reports items whose UniqueFields values repeat an earlier item's.
+func (v *Validator) checkUniqueFields(node *yaml.Node, fields []string, path string, ctx *ValidationContext) {
+	firstLine := make(map[string]int)
+	for i, item := range node.Content {
+		item = resolveAlias(item)
+		values, ok := v.compositeKey(item, fields, ctx)
+		if !ok {
+			continue
+		}
+		key := strings.Join(values, "\x00")
+		line, seen := firstLine[key]
+		if !seen {
+			firstLine[key] = item.Line
+			continue
+		}
+
+		name, value := fields[0], values[0]
+		if len(fields) > 1 {
+			name = "(" + strings.Join(fields, ", ") + ")"
+			value = "(" + strings.Join(values, ", ") + ")"
+		}
 		ctx.AddError(ValidationError{
+			Level:   LevelError,
+			Path:    cleanPath(fmt.Sprintf("%s[%d]", path, i)),
+			Line:    item.Line,
+			Column:  item.Column,
+			Message: fmt.Sprintf("duplicate %s %s (first defined at line %d)", name, value, line),
+		})
+	}
+}
+
+// compositeKey returns the quoted values of fields in a mapping item, or false
+// if the item is not a mapping or a field is missing or null.
+func (v *Validator) compositeKey(item *yaml.Node, fields []string, ctx *ValidationContext) ([]string, bool) {
+	if item.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	values := make([]string, len(fields))
+	for j, field := range fields {
+		_, value := MappingLookup(item, field)
+		value = resolveAlias(value)
+		if value == nil || v.inferType(value, ctx) == TypeNull {
+			return nil, false
+		}
+		if value.Kind == yaml.ScalarNode {
+			values[j] = strconv.Quote(value.Value)
+			continue
+		}
+		encoded, err := yaml.Marshal(value)
+		if err != nil {
+			return nil, false
+		}
+		values[j] = strings.TrimSpace(string(encoded))
+	}
+	return values, true
+}
+
+// checkStyle reports a non-empty mapping or sequence not written in
+// schema.RequireStyle.
+func (v *Validator) checkStyle(node *yaml.Node, schema *FieldSchema, path string, ctx *ValidationContext) {
+	if (node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode) || len(node.Content) == 0 {
+		return
+	}
+	got := StyleBlock
+	if node.Style&yaml.FlowStyle != 0 {
+		got = StyleFlow
+	}
+	if got == schema.RequireStyle {
+		return
+	}
+	level := LevelWarning
+	if schema.RequireStyleLevel != nil {
+		level = *schema.RequireStyleLevel
+	}
+	kind := "mapping"
+	if node.Kind == yaml.SequenceNode {
+		kind = "sequence"
+	}
+	ctx.AddError(ValidationError{
+		Level:    level,
+		Path:     cleanPath(path),
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  fmt.Sprintf("%s must be written in %s style", kind, schema.RequireStyle),
+		Got:      got.String(),
+		Expected: schema.RequireStyle.String(),
+	})
+}
+
+// checkNesting reports the first collection in node nested deeper than maxDepth.
+func (v *Validator) checkNesting(node *yaml.Node, maxDepth int, path string, ctx *ValidationContext) {
+	deep, deepPath, depth := findTooDeep(node, 1, maxDepth, path, map[*yaml.Node]bool{})
+	if deep == nil {
+		return
+	}
+	ctx.AddError(withSpan(ValidationError{
+		Level:    LevelError,
+		Path:     cleanPath(deepPath),
+		Line:     deep.Line,
+		Column:   deep.Column,
+		Message:  fmt.Sprintf("nested %d levels deep, at most %d allowed", depth, maxDepth),
+		Expected: fmt.Sprintf("at most %d levels", maxDepth),
+		Got:      fmt.Sprintf("%d levels", depth),
+	}, deep, ctx))
+}
+
+// findTooDeep returns the first collection, in document order, whose depth
+// exceeds maxDepth, with its path and depth. Every step but a merge goes one
+// level deeper, so the walk ends even on alias cycles; active holds the
+// collections being walked to end merge cycles.
+func findTooDeep(node *yaml.Node, depth, maxDepth int, path string, active map[*yaml.Node]bool) (*yaml.Node, string, int) {
+	node = resolveAlias(node)
+	if node == nil || (node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode) {
+		return nil, "", 0
+	}
+	if depth > maxDepth {
+		return node, path, depth
+	}
+	active[node] = true
+	defer delete(active, node)
+
+	if node.Kind == yaml.SequenceNode {
+		for i, item := range node.Content {
+			if deep, deepPath, d := findTooDeep(item, depth+1, maxDepth, fmt.Sprintf("%s[%d]", path, i), active); deep != nil {
+				return deep, deepPath, d
+			}
+		}
+		return nil, "", 0
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			// Merged mappings add their keys to this mapping, at this depth.
+			sources := []*yaml.Node{value}
+			if resolved := resolveAlias(value); resolved != nil && resolved.Kind == yaml.SequenceNode {
+				sources = resolved.Content
+			}
+			for _, source := range sources {
+				if active[resolveAlias(source)] {
+					continue
+				}
+				if deep, deepPath, d := findTooDeep(source, depth, maxDepth, path, active); deep != nil {
+					return deep, deepPath, d
+				}
+			}
+			continue
+		}
+		if deep, deepPath, d := findTooDeep(value, depth+1, maxDepth, joinPath(path, key.Value), active); deep != nil {
+			return deep, deepPath, d
+		}
+	}
+	return nil, "", 0
+}
+
+// checkItemCount enforces MinItems/MaxItems for a sequence or set of length items.
+func (v *Validator) checkItemCount(node *yaml.Node, length int, schema *FieldSchema, path string, ctx *ValidationContext) {
+	if schema.MinItems != nil && length < *schema.MinItems {
+		ctx.AddError(withSpan(ValidationError{
 			Level:    LevelError,
 			Path:     cleanPath(path),
 			Line:     node.Line,
 			Column:   node.Column,
-			Message:  "too few items",
+			Message:  ctx.Message(MsgTooFewItems, "too few items", "path", cleanPath(path), "got", strconv.Itoa(length), "min", strconv.Itoa(*schema.MinItems)),
 			Expected: fmt.Sprintf("at least %d", *schema.MinItems),
 			Got:      fmt.Sprintf("%d", length),
-		})
+		}, node, ctx))
 	}
 
 	if schema.MaxItems != nil && length > *schema.MaxItems {
-		ctx.AddError(ValidationError{
+		ctx.AddError(withSpan(ValidationError{
 			Level:    LevelError,
 			Path:     cleanPath(path),
 			Line:     node.Line,
 			Column:   node.Column,
-			Message:  "too many items",
+			Message:  ctx.Message(MsgTooManyItems, "too many items", "path", cleanPath(path), "got", strconv.Itoa(length), "max", strconv.Itoa(*schema.MaxItems)),
 			Expected: fmt.Sprintf("at most %d", *schema.MaxItems),
 			Got:      fmt.Sprintf("%d", length),
-		})
-	}
-
-	if schema.ItemSchema == nil {
-		return
-	}
-
-	for i, item := range node.Content {
-		if ctx.IsStopped() {
-			return
-		}
-		itemPath := fmt.Sprintf("%s[%d]", path, i)
-		v.validateNode(item, schema.ItemSchema, itemPath, ctx)
+		}, node, ctx))
 	}
 }
 
@@ -1164,16 +3188,129 @@ func (v *Validator) validateSequence(node *yaml.Node, schema *FieldSchema, path
 // ============================================================================
 
 func joinPath(base, key string) string {
+	if needsQuotedKey(key) {
+		return base + "[" + strconv.Quote(key) + "]"
+	}
 	if base == "" {
 		return key
 	}
 	return base + "." + key
 }
 
+// needsQuotedKey reports whether a key must be written as ["key"] in a path
+// because dotted notation, or a selector, would misread it.
+func needsQuotedKey(key string) bool {
+	return key == "" || key == "*" || strings.ContainsAny(key, `.[]"`)
+}
+
 func cleanPath(path string) string {
 	return strings.TrimPrefix(path, ".")
 }
 
+// missingKeyPosition returns where a missing key would be added to a mapping:
+// the last line of a block mapping's content, at the indentation of its keys.
+// Flow and empty mappings fall back to the mapping's own position.
+func missingKeyPosition(node *yaml.Node) (line, col int) {
+	if node.Kind != yaml.MappingNode || node.Style&yaml.FlowStyle != 0 || len(node.Content) == 0 {
+		return node.Line, node.Column
+	}
+	return nodeEndLine(node), node.Content[0].Column
+}
+
+// nodeEndLine returns the last source line occupied by node.
+func nodeEndLine(node *yaml.Node) int {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode, yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return node.Line
+		}
+		return nodeEndLine(node.Content[len(node.Content)-1])
+	case yaml.ScalarNode:
+		if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			// Block scalar content starts on the line after the indicator
+			return node.Line + strings.Count(strings.TrimRight(node.Value, "\n"), "\n") + 1
+		}
+		return node.Line
+	default:
+		return node.Line
+	}
+}
+
+// withSpan sets EndLine/EndColumn of err when node is a mapping or sequence.
+func withSpan(err ValidationError, node *yaml.Node, ctx *ValidationContext) ValidationError {
+	if node.Line > 0 && (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) {
+		err.EndLine, err.EndColumn = nodeEnd(node, ctx.SourceLines)
+	}
+	return err
+}
+
+// nodeEnd returns the position just past the last character of node (1-based
+// line and column). Source lines locate closing quotes and flow brackets; without
+// them the end is estimated from the values.
+func nodeEnd(node *yaml.Node, lines []string) (line, col int) {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		if node.Style&yaml.FlowStyle == 0 && len(node.Content) > 0 {
+			return nodeEnd(node.Content[len(node.Content)-1], lines)
+		}
+		line, col = node.Line, node.Column+1
+		if len(node.Content) > 0 {
+			line, col = nodeEnd(node.Content[len(node.Content)-1], lines)
+		}
+		closing := '}'
+		if node.Kind == yaml.SequenceNode {
+			closing = ']'
+		}
+		if l, c, ok := scanForRune(lines, line, col, closing, 0); ok {
+			return l, c + 1
+		}
+		return line, col + 1
+	case yaml.ScalarNode:
+		switch {
+		case node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+			line = nodeEndLine(node)
+			if line <= len(lines) {
+				return line, utf8.RuneCountInString(strings.TrimRight(lines[line-1], " \t")) + 1
+			}
+			return line, node.Column
+		case node.Style&yaml.DoubleQuotedStyle != 0:
+			if l, c, ok := scanForRune(lines, node.Line, node.Column+1, '"', '\\'); ok {
+				return l, c + 1
+			}
+		case node.Style&yaml.SingleQuotedStyle != 0:
+			if l, c, ok := scanForRune(lines, node.Line, node.Column+1, '\'', '\''); ok {
+				return l, c + 1
+			}
+		}
+		return node.Line, node.Column + utf8.RuneCountInString(node.Value)
+	case yaml.AliasNode:
+		return node.Line, node.Column + 1 + utf8.RuneCountInString(node.Value)
+	default:
+		return node.Line, node.Column
+	}
+}
+
+// scanForRune finds target in lines starting at (line, col), skipping comments.
+// When escape is set, an escape rune followed by any rune is skipped; when it
+// equals target, a doubled target is skipped (as in single-quoted scalars).
+func scanForRune(lines []string, line, col int, target, escape rune) (int, int, bool) {
+	for ; line >= 1 && line <= len(lines); line, col = line+1, 1 {
+		runes := []rune(lines[line-1])
+		for i := col - 1; i < len(runes); i++ {
+			r := runes[i]
+			switch {
+			case escape != 0 && r == escape && i+1 < len(runes) && (escape != target || runes[i+1] == target):
+				i++
+			case r == target:
+				return line, i + 1, true
+			case escape == 0 && r == '#' && (i == 0 || unicode.IsSpace(runes[i-1])):
+				i = len(runes)
+			}
+		}
+	}
+	return 0, 0, false
+}
+
 func splitLines(data []byte) []string {
 	var lines []string
 	scanner := bufio.NewScanner(bytes.NewReader(data))
@@ -1193,11 +3330,21 @@ func quoteAll(ss []string) []string {
 
 // Package-level compiled regexes
 var (
-	yamlErrorLineColRe = regexp.MustCompile(`line (\d+):\s*column (\d+)`)
-	yamlErrorLineRe    = regexp.MustCompile(`line (\d+):`)
+	yamlErrorLineColRe     = regexp.MustCompile(`line (\d+):\s*column (\d+)`)
+	yamlErrorLineRe        = regexp.MustCompile(`line (\d+):`)
+	defaultInterpolationRe = regexp.MustCompile(`\$\{[^}]+\}`)
+
+	// YAML 1.1 implicit types (https://yaml.org/type/)
+	yaml11BoolRe  = regexp.MustCompile(`^(y|Y|yes|Yes|YES|n|N|no|No|NO|true|True|TRUE|false|False|FALSE|on|On|ON|off|Off|OFF)$`)
+	yaml11NullRe  = regexp.MustCompile(`^(~|null|Null|NULL)$`)
+	yaml11IntRe   = regexp.MustCompile(`^([-+]?0b[0-1_]+|[-+]?0[0-7_]+|[-+]?(0|[1-9][0-9_]*)|[-+]?0x[0-9a-fA-F_]+|[-+]?[1-9][0-9_]*(:[0-5]?[0-9])+)$`)
+	yaml11FloatRe = regexp.MustCompile(`^([-+]?([0-9][0-9_]*)?\.[0-9_]*([eE][-+][0-9]+)?|[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+\.[0-9_]*|[-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
 )
 
-func parseYAMLError(err error, docIndex int) ValidationError {
+// parseYAMLError converts a yaml.v3 decode error into a ValidationError.
+// yaml.v3 usually reports only a line; in that case the column is derived from
+// the source line so that FormatErrorWithSource can render a caret.
+func parseYAMLError(err error, docIndex int, lines []string) ValidationError {
 	msg := err.Error()
 	line, col := 0, 0
 
@@ -1208,15 +3355,34 @@ func parseYAMLError(err error, docIndex int) ValidationError {
 		line, _ = strconv.Atoi(m[1])
 	}
 
+	if col == 0 && line > 0 && line <= len(lines) {
+		col = guessErrorColumn(lines[line-1], msg)
+	}
+
 	return ValidationError{
 		Level:   LevelError,
 		Path:    fmt.Sprintf("doc[%d]", docIndex),
 		Line:    line,
 		Column:  col,
 		Message: msg,
+		Cause:   err,
 	}
 }
 
+// guessErrorColumn returns a 1-based byte column for a decode error on line:
+// the first tab for tab-related errors, otherwise the first non-blank byte.
+func guessErrorColumn(line, msg string) int {
+	if strings.Contains(msg, "tab") {
+		if idx := strings.IndexByte(line, '\t'); idx >= 0 {
+			return idx + 1
+		}
+	}
+	if idx := strings.IndexFunc(line, func(r rune) bool { return r != ' ' && r != '\t' }); idx >= 0 {
+		return idx + 1
+	}
+	return 0
+}
+
 // ============================================================================
 // Error Formatting
 // ============================================================================
@@ -1279,17 +3445,46 @@ func RenderLineWithCaret(line string, byteCol int) (string, int, int) {
 	return renderLineWithCaret(line, byteCol)
 }
 
+// ByteOffset returns the 0-based byte offset of a 1-based line and column in
+// the source the lines were split from, or -1 if the position is unknown or
+// outside the source. Lines are assumed to end in a single "\n".
+//
+// Columns count runes, as reported by the YAML decoder: a tab is one column and
+// so is each multi-byte rune. A column past the end of the line resolves to the
+// end of the line.
+func ByteOffset(lines []string, line, col int) int {
+	if line <= 0 || line > len(lines) || col <= 0 {
+		return -1
+	}
+	offset := 0
+	for _, l := range lines[:line-1] {
+		offset += len(l) + 1
+	}
+
+	current := lines[line-1]
+	pos := 0
+	for i := 1; i < col && pos < len(current); i++ {
+		_, size := utf8.DecodeRuneInString(current[pos:])
+		pos += size
+	}
+	return offset + pos
+}
+
 // FormatErrorWithSource formats an error with source context.
 // Correctly handles tabs and Unicode.
 func FormatErrorWithSource(err ValidationError, lines []string) string {
-	var sb strings.Builder
-	sb.WriteString(err.Error())
-	sb.WriteString("\n")
+	return err.Error() + "\n" + FormatSourceSnippet(err, lines)
+}
 
+// FormatSourceSnippet renders the source context of an error: the error line
+// with a caret under its column and one line before and after, numbered.
+// It returns "" when the error has no line within lines.
+func FormatSourceSnippet(err ValidationError, lines []string) string {
 	if err.Line <= 0 || err.Line > len(lines) {
-		return sb.String()
+		return ""
 	}
 
+	var sb strings.Builder
 	lineIdx := err.Line - 1
 
 	// Context: line before
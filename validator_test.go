@@ -1,13 +1,24 @@
 package yamlvalidator_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
 	. "github.com/yakwilikk/go-yamlvalidator"
 	keyv "github.com/yakwilikk/go-yamlvalidator/pkg/keyvalidator"
+	mapv "github.com/yakwilikk/go-yamlvalidator/pkg/mapvalidator"
+	seqv "github.com/yakwilikk/go-yamlvalidator/pkg/seqvalidator"
 	valv "github.com/yakwilikk/go-yamlvalidator/pkg/valuevalidator"
+	"gopkg.in/yaml.v3"
 )
 
 func TestBasicTypeValidation(t *testing.T) {
@@ -238,6 +249,55 @@ count: "not an int"
 	}
 }
 
+func TestAllOrNone(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"accessKey": {Type: TypeString},
+			"secretKey": {Type: TypeString},
+			"region":    {Type: TypeString},
+		},
+		AllOrNone: [][]string{{"accessKey", "secretKey"}},
+	}
+
+	tests := []struct {
+		name        string
+		yaml        string
+		wantErrors  int
+		wantMessage string
+	}{
+		{
+			name:       "all present",
+			yaml:       "accessKey: a\nsecretKey: s\n",
+			wantErrors: 0,
+		},
+		{
+			name:       "all absent",
+			yaml:       "region: eu\n",
+			wantErrors: 0,
+		},
+		{
+			name:        "partial",
+			yaml:        "region: eu\nsecretKey: s\n",
+			wantErrors:  1,
+			wantMessage: "fields [accessKey secretKey] must be set together or not at all, found: [secretKey], missing: [accessKey]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(schema)
+			errs := v.ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if len(errs) != tt.wantErrors {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), tt.wantErrors, errs)
+			}
+			if tt.wantMessage != "" && (errs[0].Message != tt.wantMessage || errs[0].Line != 2) {
+				t.Errorf("unexpected error: %v", errs[0])
+			}
+		})
+	}
+}
+
 func TestSequenceValidation(t *testing.T) {
 	schema := &FieldSchema{
 		Type:       TypeSequence,
@@ -284,6 +344,81 @@ func TestSequenceValidation(t *testing.T) {
 	}
 }
 
+func TestExactlyOneGroupOf(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"file": {Type: TypeString},
+			"host": {Type: TypeString},
+			"port": {Type: TypeInt},
+		},
+		ExactlyOneGroupOf: [][]string{{"file"}, {"host", "port"}},
+	}
+
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErrors int
+	}{
+		{
+			name:       "single-key group present",
+			yaml:       `file: "config.yaml"`,
+			wantErrors: 0,
+		},
+		{
+			name: "multi-key group present",
+			yaml: `
+host: "localhost"
+port: 8080
+`,
+			wantErrors: 0,
+		},
+		{
+			name:       "none present",
+			yaml:       `{}`,
+			wantErrors: 1,
+		},
+		{
+			name:       "partial group only",
+			yaml:       `host: "localhost"`,
+			wantErrors: 1,
+		},
+		{
+			name: "partial group does not count",
+			yaml: `
+file: "config.yaml"
+host: "localhost"
+`,
+			wantErrors: 0,
+		},
+		{
+			name: "two groups present",
+			yaml: `
+file: "config.yaml"
+host: "localhost"
+port: 8080
+`,
+			wantErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(schema)
+			result := v.ValidateBytes([]byte(tt.yaml))
+			if len(result.Collector.Errors()) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d", len(result.Collector.Errors()), tt.wantErrors)
+			}
+		})
+	}
+
+	errs := NewValidator(schema).ValidateString("file: a\nhost: b\nport: 1\n").Collector.Errors()
+	want := `exactly one of "file" or ("host" and "port") is required, found: "file", ("host" and "port")`
+	if errs[0].Message != want || errs[0].Line != 2 {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+}
+
 func TestMutuallyExclusive(t *testing.T) {
 	schema := &FieldSchema{
 		Type: TypeMap,
@@ -776,6 +911,40 @@ App: "nginx"
 	}
 }
 
+func TestForbiddenKeyValidatorPatterns(t *testing.T) {
+	schema := &FieldSchema{
+		Type:                 TypeMap,
+		AdditionalProperties: &FieldSchema{Type: TypeAny},
+		KeyValidators: []KeyValidator{
+			keyv.ForbiddenKeyValidator{
+				Forbidden: []string{"password"},
+				Patterns:  []*regexp.Regexp{regexp.MustCompile(`^tmp_`), regexp.MustCompile(`(?i)secret`)},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErrors int
+	}{
+		{name: "allowed keys", yaml: "name: a\ntemp: b\n", wantErrors: 0},
+		{name: "exact forbid", yaml: "password: x\n", wantErrors: 1},
+		{name: "prefix pattern", yaml: "tmp_cache: x\n", wantErrors: 1},
+		{name: "case-insensitive pattern", yaml: "apiSecret: x\n", wantErrors: 1},
+		{name: "mixed", yaml: "password: x\ntmp_a: y\nok: z\n", wantErrors: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			if len(result.Collector.Errors()) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d", len(result.Collector.Errors()), tt.wantErrors)
+			}
+		})
+	}
+}
+
 func TestLengthKeyValidatorUnicode(t *testing.T) {
 	schema := &FieldSchema{
 		Type:                 TypeMap,
@@ -798,6 +967,29 @@ func TestLengthKeyValidatorUnicode(t *testing.T) {
 	}
 }
 
+func TestLengthKeyValidatorBytes(t *testing.T) {
+	schema := &FieldSchema{
+		Type:                 TypeMap,
+		AdditionalProperties: &FieldSchema{Type: TypeString},
+		KeyValidators: []KeyValidator{
+			keyv.LengthKeyValidator{Max: Ptr[int](6), Unit: keyv.Bytes},
+		},
+	}
+
+	// 4 runes but 8 bytes
+	yaml := `
+ключ: "value"
+`
+	v := NewValidator(schema)
+	result := v.ValidateBytes([]byte(yaml))
+	if len(result.Collector.Errors()) != 1 {
+		t.Fatalf("expected byte length error for multibyte key, got %v", result.Collector.Errors())
+	}
+	if got := result.Collector.Errors()[0].Got; got != "8 bytes" {
+		t.Fatalf("expected byte count in error, got %q", got)
+	}
+}
+
 func TestMultiDocument(t *testing.T) {
 	schema := &FieldSchema{
 		Type: TypeMap,
@@ -911,6 +1103,62 @@ func TestDeprecatedField(t *testing.T) {
 	}
 }
 
+func TestDeprecatedInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		info DeprecatedInfo
+		want string
+	}{
+		{"full", DeprecatedInfo{Since: "v1.2", RemoveIn: "v2.0", Replacement: "newField"},
+			"deprecated since v1.2, removed in v2.0, use newField instead"},
+		{"since only", DeprecatedInfo{Since: "v1.2"}, "deprecated since v1.2"},
+		{"replacement and note", DeprecatedInfo{Replacement: "newField", Message: "values are in seconds now"},
+			"deprecated, use newField instead: values are in seconds now"},
+		{"empty", DeprecatedInfo{}, "deprecated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := tt.info
+			schema := &FieldSchema{
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"oldField": {Type: TypeString, Deprecated: "ignored", DeprecatedInfo: &info},
+				},
+			}
+			warnings := NewValidator(schema).ValidateString("oldField: x").Collector.Warnings()
+			if len(warnings) != 1 {
+				t.Fatalf("expected 1 warning, got %v", warnings)
+			}
+			if warnings[0].Message != tt.want {
+				t.Errorf("message = %q, want %q", warnings[0].Message, tt.want)
+			}
+			if warnings[0].Deprecation == nil || *warnings[0].Deprecation != tt.info {
+				t.Errorf("Deprecation = %+v, want %+v", warnings[0].Deprecation, tt.info)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		err := ValidationError{
+			Level:       LevelWarning,
+			Path:        "oldField",
+			Message:     "deprecated since v1.2",
+			Deprecation: &DeprecatedInfo{Since: "v1.2", RemoveIn: "v2.0"},
+		}
+		data, jerr := json.Marshal(err)
+		if jerr != nil {
+			t.Fatal(jerr)
+		}
+		if !strings.Contains(string(data), `"deprecation":{"since":"v1.2","removeIn":"v2.0"}`) {
+			t.Errorf("unexpected JSON: %s", data)
+		}
+		data, _ = json.Marshal(ValidationError{Path: "x"})
+		if strings.Contains(string(data), "deprecation") {
+			t.Errorf("deprecation should be omitted: %s", data)
+		}
+	})
+}
+
 func TestEmptyStringIsNotNull(t *testing.T) {
 	schema := &FieldSchema{
 		Type: TypeMap,
@@ -1002,6 +1250,90 @@ func TestRenderLineWithCaret(t *testing.T) {
 	}
 }
 
+func TestByteOffset(t *testing.T) {
+	lines := []string{
+		"name: привет",
+		"\tkey: 🎉 x",
+		"",
+		"last",
+	}
+	tests := []struct {
+		name      string
+		line, col int
+		want      int
+	}{
+		{"start", 1, 1, 0},
+		{"after two cyrillic runes", 1, 9, 10},
+		{"last cyrillic rune", 1, 12, 16},
+		{"end of line", 1, 13, 18},
+		{"past end of line", 1, 40, 18},
+		{"tab is one column", 2, 2, 20},
+		{"emoji", 2, 7, 25},
+		{"after emoji", 2, 8, 29},
+		{"after emoji and space", 2, 9, 30},
+		{"empty line", 3, 1, 32},
+		{"last line", 4, 3, 35},
+		{"line zero", 0, 1, -1},
+		{"column zero", 1, 0, -1},
+		{"line past end", 5, 1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ByteOffset(lines, tt.line, tt.col); got != tt.want {
+				t.Errorf("ByteOffset(%d, %d) = %d, want %d", tt.line, tt.col, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillOffsets(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"title": {Type: TypeString},
+			"count": {Type: TypeInt},
+		},
+	}
+	data := "title: \"日本語\"\ncount: many\n"
+	result := NewValidator(schema).ValidateString(data)
+	result.FillOffsets()
+
+	errs := result.Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	want := strings.Index(data, "many")
+	if errs[0].Offset != want {
+		t.Errorf("Offset = %d, want %d", errs[0].Offset, want)
+	}
+
+	out, err := json.Marshal(errs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), fmt.Sprintf(`"offset":%d`, want)) {
+		t.Errorf("JSON output lacks offset: %s", out)
+	}
+}
+
+func TestFillOffsets_MultibyteKey(t *testing.T) {
+	schema := &FieldSchema{
+		Type:                 TypeMap,
+		AdditionalProperties: &FieldSchema{Type: TypeInt},
+	}
+	data := "名前: many\n"
+	result := NewValidator(schema).ValidateString(data)
+	result.FillOffsets()
+
+	errs := result.Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	if want := strings.Index(data, "many"); errs[0].Offset != want {
+		t.Errorf("Offset = %d, want %d (column %d)", errs[0].Offset, want, errs[0].Column)
+	}
+}
+
 func TestStopOnFirstError(t *testing.T) {
 	schema := &FieldSchema{
 		Type: TypeMap,
@@ -1042,6 +1374,50 @@ func TestSortByPositionInterleaved(t *testing.T) {
 	}
 }
 
+func TestFormatSourceSnippet(t *testing.T) {
+	lines := []string{"kind: Deployment", "replicas: many", "image: nginx"}
+	err := ValidationError{Level: LevelError, Path: "replicas", Line: 2, Column: 11, Message: "type mismatch"}
+
+	snippet := FormatSourceSnippet(err, lines)
+	want := "     1 | kind: Deployment\n" +
+		">    2 | replicas: many\n" +
+		"       |           ^\n" +
+		"     3 | image: nginx\n"
+	if snippet != want {
+		t.Fatalf("snippet mismatch:\n got:\n%s\nwant:\n%s", snippet, want)
+	}
+	if strings.Contains(snippet, "[ERROR]") {
+		t.Errorf("snippet should not contain the error header: %s", snippet)
+	}
+	if full := FormatErrorWithSource(err, lines); full != err.Error()+"\n"+snippet {
+		t.Errorf("FormatErrorWithSource should be header plus snippet, got:\n%s", full)
+	}
+	if got := FormatSourceSnippet(ValidationError{Message: "no position"}, lines); got != "" {
+		t.Errorf("expected empty snippet without a line, got %q", got)
+	}
+}
+
+func TestAllSorted(t *testing.T) {
+	collector := NewErrorCollector()
+	collector.Add(ValidationError{Level: LevelError, Line: 3, Column: 1, Message: "error third"})
+	collector.Add(ValidationError{Level: LevelWarning, Line: 1, Column: 5, Message: "warn first"})
+	collector.Add(ValidationError{Level: LevelError, Line: 2, Column: 1, Message: "error second"})
+	collector.Add(ValidationError{Level: LevelWarning, Line: 3, Column: 1, Message: "warn after error"})
+	collector.Add(ValidationError{Level: LevelError, File: "a.yaml", Line: 9, Column: 1, Message: "other file"})
+
+	var got []string
+	for _, err := range collector.AllSorted() {
+		got = append(got, err.Message)
+	}
+	want := []string{"warn first", "error second", "error third", "warn after error", "other file"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AllSorted order = %v, want %v", got, want)
+	}
+	if all := collector.All(); all[0].Message != "error third" || all[3].Message != "warn first" {
+		t.Errorf("All should keep errors then warnings, got %v", all)
+	}
+}
+
 func TestMergeKeysSupported(t *testing.T) {
 	serverSchema := &FieldSchema{
 		Type: TypeMap,
@@ -1076,3 +1452,4105 @@ server:
 		t.Fatalf("expected merge keys to be honored, got errors: %v", result.Collector.Errors())
 	}
 }
+
+func TestLanguageTagValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeString,
+		Validators: []ValueValidator{
+			valv.LanguageTagValidator{},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErrors int
+	}{
+		{name: "language only", yaml: `en`, wantErrors: 0},
+		{name: "language and region", yaml: `en-US`, wantErrors: 0},
+		{name: "lowercase region", yaml: `pt-br`, wantErrors: 0},
+		{name: "script and region", yaml: `zh-Hant-TW`, wantErrors: 0},
+		{name: "numeric region", yaml: `es-419`, wantErrors: 0},
+		{name: "variant", yaml: `de-CH-1901`, wantErrors: 0},
+		{name: "extension", yaml: `en-US-u-ca-gregory`, wantErrors: 0},
+		{name: "private use", yaml: `x-custom`, wantErrors: 0},
+		{name: "underscore separator", yaml: `en_US`, wantErrors: 1},
+		{name: "empty subtag", yaml: `en--US`, wantErrors: 1},
+		{name: "single letter language", yaml: `e-US`, wantErrors: 1},
+		{name: "too long subtag", yaml: `en-toolongsubtag`, wantErrors: 1},
+		{name: "dangling extension", yaml: `en-u`, wantErrors: 1},
+		{name: "empty", yaml: `""`, wantErrors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(schema)
+			result := v.ValidateBytes([]byte(tt.yaml))
+			if len(result.Collector.Errors()) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d", len(result.Collector.Errors()), tt.wantErrors)
+				for _, err := range result.Collector.Errors() {
+					t.Logf("  error: %s", err)
+				}
+			}
+		})
+	}
+}
+
+func TestHexColorValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowAlpha bool
+		yaml       string
+		wantErrors int
+	}{
+		{name: "short form", yaml: `"#abc"`, wantErrors: 0},
+		{name: "long form", yaml: `"#1a2b3c"`, wantErrors: 0},
+		{name: "uppercase", yaml: `"#1A2B3C"`, wantErrors: 0},
+		{name: "alpha rejected by default", yaml: `"#1a2b3c4d"`, wantErrors: 1},
+		{name: "alpha allowed", allowAlpha: true, yaml: `"#1a2b3c4d"`, wantErrors: 0},
+		{name: "missing hash", yaml: `"1a2b3c"`, wantErrors: 1},
+		{name: "non-hex digit", yaml: `"#1g2b3c"`, wantErrors: 1},
+		{name: "wrong length", yaml: `"#1a2b"`, wantErrors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type: TypeString,
+				Validators: []ValueValidator{
+					valv.HexColorValidator{AllowAlpha: tt.allowAlpha},
+				},
+			}
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			if len(result.Collector.Errors()) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d", len(result.Collector.Errors()), tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestFilePathValidator(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("a: 1\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	missing := filepath.Join(dir, "missing.yaml")
+
+	tests := []struct {
+		name       string
+		validator  valv.FilePathValidator
+		value      string
+		checkFS    bool
+		wantErrors int
+	}{
+		{name: "existing file", validator: valv.FilePathValidator{MustExist: true, Mode: valv.PathModeFile}, value: file, checkFS: true, wantErrors: 0},
+		{name: "missing file", validator: valv.FilePathValidator{MustExist: true}, value: missing, checkFS: true, wantErrors: 1},
+		{name: "missing file without fs checks", validator: valv.FilePathValidator{MustExist: true}, value: missing, checkFS: false, wantErrors: 0},
+		{name: "file where dir expected", validator: valv.FilePathValidator{Mode: valv.PathModeDir}, value: file, checkFS: true, wantErrors: 1},
+		{name: "dir where file expected", validator: valv.FilePathValidator{Mode: valv.PathModeFile}, value: dir, checkFS: true, wantErrors: 1},
+		{name: "absolute path rejected", validator: valv.FilePathValidator{AllowRelative: true}, value: file, wantErrors: 1},
+		{name: "relative path rejected", validator: valv.FilePathValidator{AllowAbsolute: true}, value: "config.yaml", wantErrors: 1},
+		{name: "relative against base dir", validator: valv.FilePathValidator{MustExist: true, BaseDir: dir}, value: "config.yaml", checkFS: true, wantErrors: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"path": {Type: TypeString, Validators: []ValueValidator{tt.validator}},
+				},
+			}
+			data := []byte("path: " + strconv.Quote(tt.value))
+			result := NewValidator(schema).ValidateWithOptions(data, ValidationContext{CheckFilesystem: tt.checkFS})
+			if len(result.Collector.Errors()) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d", len(result.Collector.Errors()), tt.wantErrors)
+				for _, err := range result.Collector.Errors() {
+					t.Logf("  error: %s", err)
+				}
+			}
+		})
+	}
+
+	t.Run("stat errors other than not-exist are reported verbatim", func(t *testing.T) {
+		schema := &FieldSchema{
+			Type:        TypeMap,
+			AllowedKeys: map[string]*FieldSchema{"path": {Type: TypeString, Validators: []ValueValidator{valv.FilePathValidator{}}}},
+		}
+		data := []byte("path: " + strconv.Quote(filepath.Join(file, "child")))
+		errs := NewValidator(schema).ValidateWithOptions(data, ValidationContext{CheckFilesystem: true}).Collector.Errors()
+		if len(errs) != 1 || !strings.HasSuffix(errs[0].Message, "not a directory") {
+			t.Fatalf("expected the stat error, got %v", errs)
+		}
+	})
+
+	t.Run("custom message", func(t *testing.T) {
+		schema := &FieldSchema{
+			Type: TypeMap,
+			AllowedKeys: map[string]*FieldSchema{"path": {Type: TypeString, Validators: []ValueValidator{
+				valv.FilePathValidator{MustExist: true, Message: "{path}: {value} is missing"},
+			}}},
+		}
+		data := []byte("path: " + strconv.Quote(missing))
+		errs := NewValidator(schema).ValidateWithOptions(data, ValidationContext{CheckFilesystem: true}).Collector.Errors()
+		if len(errs) != 1 || errs[0].Message != "path: "+missing+" is missing" {
+			t.Fatalf("expected custom message, got %v", errs)
+		}
+	})
+}
+
+func TestDirectoryValidator(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"directory": {
+				Type:       TypeAny,
+				Validators: []ValueValidator{valv.DirectoryValidator{MustExist: true}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		yaml       string
+		checkFS    bool
+		wantErrors int
+	}{
+		{name: "existing dir as string", yaml: "directory: " + strconv.Quote(dir), checkFS: true, wantErrors: 0},
+		{name: "existing dir as mapping", yaml: "directory:\n  path: " + strconv.Quote(dir), checkFS: true, wantErrors: 0},
+		{name: "missing dir", yaml: "directory: " + strconv.Quote(filepath.Join(dir, "nope")), checkFS: true, wantErrors: 1},
+		{name: "missing dir in mapping", yaml: "directory:\n  path: " + strconv.Quote(filepath.Join(dir, "nope")), checkFS: true, wantErrors: 1},
+		{name: "file is not a dir", yaml: "directory: " + strconv.Quote(file), checkFS: true, wantErrors: 1},
+		{name: "missing dir without fs checks", yaml: "directory: " + strconv.Quote(filepath.Join(dir, "nope")), checkFS: false, wantErrors: 0},
+		{name: "missing path key", yaml: "directory:\n  root: src", checkFS: true, wantErrors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateWithOptions([]byte(tt.yaml), ValidationContext{CheckFilesystem: tt.checkFS})
+			if len(result.Collector.Errors()) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d", len(result.Collector.Errors()), tt.wantErrors)
+				for _, err := range result.Collector.Errors() {
+					t.Logf("  error: %s", err)
+				}
+			}
+		})
+	}
+
+	t.Run("path at document root", func(t *testing.T) {
+		root := &FieldSchema{Type: TypeAny, Validators: []ValueValidator{valv.DirectoryValidator{}}}
+		errs := NewValidator(root).ValidateBytes([]byte("root: src\n")).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "path" {
+			t.Fatalf("expected error at path, got %v", errs)
+		}
+	})
+}
+
+func TestRequiredKeysValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"base": {Type: TypeAny},
+			"labels": {
+				Type:                 TypeMap,
+				AdditionalProperties: &FieldSchema{Type: TypeString},
+				MapValidators: []MapValidator{
+					mapv.RequiredKeysValidator{Keys: []string{"app", "team"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		yaml      string
+		wantPaths []string
+		wantLine  int
+	}{
+		{
+			name: "all present",
+			yaml: `
+labels:
+  app: web
+  team: core
+  extra: x
+`,
+		},
+		{
+			name: "one missing",
+			yaml: `
+labels:
+  app: web
+`,
+			wantPaths: []string{"labels.team"},
+			wantLine:  3,
+		},
+		{
+			name: "present via merge",
+			yaml: `
+base: &base
+  team: core
+labels:
+  <<: *base
+  app: web
+`,
+		},
+		{
+			name:      "empty map",
+			yaml:      `labels: {}`,
+			wantPaths: []string{"labels.app", "labels.team"},
+			wantLine:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			errs := result.Collector.Errors()
+			if len(errs) != len(tt.wantPaths) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.wantPaths), errs)
+			}
+			for i, p := range tt.wantPaths {
+				if errs[i].Path != p {
+					t.Errorf("error %d path = %q, want %q", i, errs[i].Path, p)
+				}
+				if errs[i].Line != tt.wantLine {
+					t.Errorf("error %d line = %d, want %d", i, errs[i].Line, tt.wantLine)
+				}
+			}
+		})
+	}
+}
+
+func TestDependentRequiredValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type:                 TypeMap,
+		AdditionalProperties: &FieldSchema{Type: TypeAny},
+		MapValidators: []MapValidator{
+			mapv.DependentRequiredValidator{Dependencies: map[string][]string{"tls": {"cert", "key"}}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErrors int
+	}{
+		{name: "trigger absent", yaml: "host: a\n", wantErrors: 0},
+		{name: "all dependencies present", yaml: "tls: true\ncert: c\nkey: k\n", wantErrors: 0},
+		{name: "one dependency missing", yaml: "tls: true\ncert: c\n", wantErrors: 1},
+		{name: "all dependencies missing", yaml: "tls: true\n", wantErrors: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			if len(result.Collector.Errors()) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d: %v", len(result.Collector.Errors()), tt.wantErrors, result.Collector.Errors())
+			}
+		})
+	}
+}
+
+type orderRecorder struct {
+	name  string
+	calls *[]string
+}
+
+func (r orderRecorder) ValidateMap(node *yaml.Node, path string, ctx *ValidationContext) {
+	*r.calls = append(*r.calls, r.name)
+}
+
+func (r orderRecorder) Validate(node *yaml.Node, path string, ctx *ValidationContext) {
+	*r.calls = append(*r.calls, r.name)
+}
+
+func TestMapValidatorOrdering(t *testing.T) {
+	var calls []string
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"a": {Type: TypeString, Validators: []ValueValidator{orderRecorder{name: "key a", calls: &calls}}},
+			"b": {Type: TypeString},
+		},
+		ExactlyOneOf:  []string{"a", "b"},
+		MapValidators: []MapValidator{orderRecorder{name: "map", calls: &calls}},
+		Validators:    []ValueValidator{orderRecorder{name: "value", calls: &calls}},
+	}
+
+	result := NewValidator(schema).ValidateBytes([]byte("a: x\nb: y\n"))
+	if len(result.Collector.Errors()) != 1 {
+		t.Fatalf("expected ExactlyOneOf error, got %v", result.Collector.Errors())
+	}
+	if got := strings.Join(calls, ","); got != "key a,map,value" {
+		t.Fatalf("unexpected validator order: %s", got)
+	}
+
+	calls = nil
+	NewValidator(schema).ValidateBytes([]byte("[1, 2]"))
+	if len(calls) != 0 {
+		t.Fatalf("expected no validators on type mismatch, got %v", calls)
+	}
+}
+
+func TestMonotonicValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		validator  seqv.MonotonicValidator
+		yaml       string
+		wantErrors int
+		wantPath   string
+	}{
+		{name: "increasing", yaml: `[1, 2, 2, 5]`},
+		{name: "strictly increasing rejects equal", validator: seqv.MonotonicValidator{Strict: true}, yaml: `[1, 2, 2, 5]`, wantErrors: 1, wantPath: "[2]"},
+		{name: "decrease in increasing", yaml: `[1, 3, 2, 5]`, wantErrors: 1, wantPath: "[2]"},
+		{name: "decreasing", validator: seqv.MonotonicValidator{Decreasing: true}, yaml: `[10, 5.5, 0x2, -1]`},
+		{name: "increase in decreasing", validator: seqv.MonotonicValidator{Decreasing: true}, yaml: `[10, 11]`, wantErrors: 1, wantPath: "[1]"},
+		{name: "non-numeric item", yaml: `[1, abc]`, wantErrors: 1, wantPath: "[1]"},
+		{name: "empty", yaml: `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type:          TypeSequence,
+				SeqValidators: []SeqValidator{tt.validator},
+			}
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			errs := result.Collector.Errors()
+			if len(errs) != tt.wantErrors {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), tt.wantErrors, errs)
+			}
+			if tt.wantPath != "" && errs[0].Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", errs[0].Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestSortedValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		validator  seqv.SortedValidator
+		yaml       string
+		wantErrors int
+		wantPath   string
+	}{
+		{name: "lexical ascending", yaml: `[alpha, beta, beta, gamma]`},
+		{name: "lexical out of order", yaml: `[alpha, gamma, beta]`, wantErrors: 1, wantPath: "[2]"},
+		{name: "lexical compares digits as text", yaml: `[10, 9]`},
+		{name: "numeric ascending", validator: seqv.SortedValidator{Numeric: true}, yaml: `[9, 10, 10.5]`},
+		{name: "numeric out of order", validator: seqv.SortedValidator{Numeric: true}, yaml: `[10, 9]`, wantErrors: 1, wantPath: "[1]"},
+		{name: "lexical descending", validator: seqv.SortedValidator{Descending: true}, yaml: `[c, b, a]`},
+		{name: "numeric descending out of order", validator: seqv.SortedValidator{Descending: true, Numeric: true}, yaml: `[3, 1, 2]`, wantErrors: 1, wantPath: "[2]"},
+		{name: "numeric with non-number", validator: seqv.SortedValidator{Numeric: true}, yaml: `[1, x]`, wantErrors: 1, wantPath: "[1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type:          TypeSequence,
+				SeqValidators: []SeqValidator{tt.validator},
+			}
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			errs := result.Collector.Errors()
+			if len(errs) != tt.wantErrors {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), tt.wantErrors, errs)
+			}
+			if tt.wantPath != "" && errs[0].Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", errs[0].Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"port": {
+				Type:       TypeInt,
+				Validators: []ValueValidator{valv.RangeValidator{Min: Ptr[float64](1), Max: Ptr[float64](65535)}},
+			},
+			"host": {Type: TypeString},
+		},
+	}
+	data := []byte("port: ${PORT}\nhost: db\n")
+
+	t.Run("disabled by default", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes(data)
+		if len(res.Collector.Errors()) != 1 {
+			t.Fatalf("expected type error without interpolation, got %v", res.Collector.Errors())
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		res := NewValidator(schema).ValidateWithOptions(data, ValidationContext{AllowInterpolation: true})
+		if len(res.Collector.All()) != 0 {
+			t.Fatalf("expected interpolated port to pass, got %v", res.Collector.All())
+		}
+	})
+
+	t.Run("enabled with warning", func(t *testing.T) {
+		res := NewValidator(schema).ValidateWithOptions(data, ValidationContext{AllowInterpolation: true, WarnInterpolation: true})
+		if len(res.Collector.Errors()) != 0 || len(res.Collector.Warnings()) != 1 {
+			t.Fatalf("expected 1 warning, got errors=%v warnings=%v", res.Collector.Errors(), res.Collector.Warnings())
+		}
+		if res.Collector.Warnings()[0].Path != "port" {
+			t.Fatalf("unexpected warning path %q", res.Collector.Warnings()[0].Path)
+		}
+	})
+
+	t.Run("custom pattern", func(t *testing.T) {
+		res := NewValidator(schema).ValidateWithOptions([]byte("port: '{{ .Port }}'\n"), ValidationContext{
+			AllowInterpolation:   true,
+			InterpolationPattern: regexp.MustCompile(`\{\{.*\}\}`),
+		})
+		if len(res.Collector.All()) != 0 {
+			t.Fatalf("expected custom placeholder to pass, got %v", res.Collector.All())
+		}
+	})
+
+	t.Run("literal values still validated", func(t *testing.T) {
+		res := NewValidator(schema).ValidateWithOptions([]byte("port: 70000\n"), ValidationContext{AllowInterpolation: true})
+		if len(res.Collector.Errors()) != 1 {
+			t.Fatalf("expected range error, got %v", res.Collector.Errors())
+		}
+	})
+}
+
+func TestWarnAmbiguousUnquoted(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"value": {Type: TypeString, Nullable: true},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		yaml         string
+		wantWarnings int
+	}{
+		{name: "no", yaml: "value: NO", wantWarnings: 1},
+		{name: "yes", yaml: "value: yes", wantWarnings: 1},
+		{name: "null", yaml: "value: null", wantWarnings: 1},
+		{name: "hex", yaml: "value: 0xFF", wantWarnings: 1},
+		{name: "yaml 1.1 octal", yaml: "value: 0755", wantWarnings: 1},
+		{name: "sexagesimal", yaml: "value: 1:30", wantWarnings: 1},
+		{name: "quoted no", yaml: `value: "NO"`, wantWarnings: 0},
+		{name: "single-quoted hex", yaml: `value: '0xFF'`, wantWarnings: 0},
+		{name: "plain string", yaml: "value: norway", wantWarnings: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := NewValidator(schema).ValidateWithOptions([]byte(tt.yaml), ValidationContext{WarnAmbiguousUnquoted: true})
+			if len(res.Collector.Warnings()) != tt.wantWarnings {
+				t.Fatalf("got %d warnings, want %d: %v", len(res.Collector.Warnings()), tt.wantWarnings, res.Collector.Warnings())
+			}
+			if tt.wantWarnings > 0 && !strings.Contains(res.Collector.Warnings()[0].Message, "quote it") {
+				t.Fatalf("warning should suggest quoting, got %q", res.Collector.Warnings()[0].Message)
+			}
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes([]byte("value: NO"))
+		if len(res.Collector.All()) != 0 {
+			t.Fatalf("expected no findings, got %v", res.Collector.All())
+		}
+	})
+
+	t.Run("non-string field", func(t *testing.T) {
+		s := &FieldSchema{Type: TypeMap, AllowedKeys: map[string]*FieldSchema{"value": {Type: TypeInt}}}
+		res := NewValidator(s).ValidateWithOptions([]byte("value: 0xFF"), ValidationContext{WarnAmbiguousUnquoted: true})
+		if len(res.Collector.All()) != 0 {
+			t.Fatalf("expected no findings for int field, got %v", res.Collector.All())
+		}
+	})
+}
+
+func TestParseErrorWithSource(t *testing.T) {
+	schema := &FieldSchema{Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}}
+	data := []byte("a: 1\n\tb: 2\nc: 3\n")
+
+	res := NewValidator(schema).ValidateBytes(data)
+	errs := res.Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 parse error, got %v", errs)
+	}
+	if errs[0].Line != 2 || errs[0].Column != 1 {
+		t.Fatalf("expected parse error at 2:1, got %d:%d", errs[0].Line, errs[0].Column)
+	}
+
+	out := res.FormatAll(true)
+	want := "" +
+		"     1 | a: 1\n" +
+		">    2 |     b: 2\n" +
+		"       | ^\n" +
+		"     3 | c: 3\n"
+	if !strings.Contains(out, want) {
+		t.Fatalf("expected source context with caret, got:\n%s", out)
+	}
+}
+
+func TestParseErrorCause(t *testing.T) {
+	schema := &FieldSchema{Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}}
+	errs := NewValidator(schema).ValidateString("a: [1, 2\n").Collector.Errors()
+	if len(errs) != 1 || errs[0].Cause == nil {
+		t.Fatalf("expected a parse error with a cause, got %v", errs)
+	}
+
+	wrapped := fmt.Errorf("load config: %w", errs[0])
+	var verr ValidationError
+	if !errors.As(wrapped, &verr) {
+		t.Fatal("errors.As should find the ValidationError")
+	}
+	if !errors.Is(wrapped, verr.Cause) {
+		t.Error("errors.Is should reach the yaml.v3 error")
+	}
+	if !strings.HasPrefix(errors.Unwrap(verr).Error(), "yaml: ") {
+		t.Errorf("unexpected cause: %v", verr.Cause)
+	}
+
+	// Schema errors have no cause.
+	errs = NewValidator(schema).ValidateString("[]").Collector.Errors()
+	if len(errs) != 1 || errs[0].Unwrap() != nil {
+		t.Errorf("expected a type error without cause, got %v", errs)
+	}
+}
+
+func TestBestEffortMultiDoc(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString, Required: true},
+		},
+	}
+
+	data := []byte(`name: first
+---
+name: [unterminated
+---
+missing: third
+`)
+
+	t.Run("stops at decode error by default", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes(data)
+		errs := res.Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "doc[1]" {
+			t.Fatalf("expected only the doc[1] parse error, got %v", errs)
+		}
+	})
+
+	t.Run("resyncs at next document", func(t *testing.T) {
+		res := NewValidator(schema).ValidateWithOptions(data, ValidationContext{BestEffortMultiDoc: true})
+		errs := res.Collector.Errors()
+		if len(errs) != 2 {
+			t.Fatalf("expected parse error and doc[2] error, got %v", errs)
+		}
+		if errs[0].Path != "doc[1]" {
+			t.Errorf("first error path = %q, want doc[1]", errs[0].Path)
+		}
+		if errs[1].Path != "doc[2].name" {
+			t.Errorf("second error path = %q, want doc[2].name", errs[1].Path)
+		}
+		if errs[1].Line != 5 {
+			t.Errorf("second error line = %d, want 5", errs[1].Line)
+		}
+	})
+
+	t.Run("flow collection opened on separator", func(t *testing.T) {
+		// The decoder reports this error at the separator line itself, so the
+		// resync must move past it rather than restart there forever.
+		data := []byte("name: a\n--- [1\n---\nmissing: x\n")
+		res := NewValidator(schema).ValidateWithOptions(data, ValidationContext{BestEffortMultiDoc: true})
+		errs := res.Collector.Errors()
+		if len(errs) == 0 {
+			t.Fatal("expected errors, got none")
+		}
+		last := errs[len(errs)-1]
+		if !strings.HasSuffix(last.Path, ".name") || last.Line != 4 {
+			t.Errorf("last error = %v, want missing name in the final document", last)
+		}
+	})
+}
+
+func TestRequiredFieldPosition(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"server": {
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"host":    {Type: TypeString},
+					"port":    {Type: TypeInt, Required: true},
+					"options": {Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}},
+					"motd":    {Type: TypeString},
+				},
+			},
+			"other": {Type: TypeString},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		wantLine int
+		wantCol  int
+	}{
+		{
+			name: "after last nested key",
+			yaml: `server:
+  host: example.com
+  options:
+    a: 1
+    b: 2
+other: x
+`,
+			wantLine: 5,
+			wantCol:  3,
+		},
+		{
+			name: "after block scalar",
+			yaml: `server:
+  host: example.com
+  motd: |
+    hello
+    world
+other: x
+`,
+			wantLine: 5,
+			wantCol:  3,
+		},
+		{
+			name:     "flow mapping falls back to map position",
+			yaml:     `server: {host: example.com}`,
+			wantLine: 1,
+			wantCol:  9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			errs := res.Collector.Errors()
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %v", errs)
+			}
+			if errs[0].Line != tt.wantLine || errs[0].Column != tt.wantCol {
+				t.Fatalf("got position %d:%d, want %d:%d", errs[0].Line, errs[0].Column, tt.wantLine, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestOneErrorPerPath(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {
+				Type: TypeString,
+				Validators: []ValueValidator{
+					valv.LengthValidator{Min: Ptr[int](5)},
+					valv.RegexValidator{Pattern: regexp.MustCompile(`^[a-z]+$`)},
+					valv.EnumValidator{Allowed: []string{"alpha", "beta"}},
+				},
+			},
+			"old":  {Type: TypeString, Deprecated: "true"},
+			"port": {Type: TypeInt},
+		},
+	}
+	data := []byte("name: AB\nold: x\nport: 1\n")
+
+	t.Run("disabled", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes(data)
+		if len(res.Collector.Errors()) != 3 {
+			t.Fatalf("expected 3 errors for name, got %v", res.Collector.Errors())
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		res := NewValidator(schema).ValidateWithOptions(data, ValidationContext{OneErrorPerPath: true})
+		errs := res.Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "name" || errs[0].Message != "length below minimum" {
+			t.Fatalf("expected only the first error for name, got %v", errs)
+		}
+		if len(res.Collector.Warnings()) != 1 {
+			t.Fatalf("expected deprecation warning to be kept, got %v", res.Collector.Warnings())
+		}
+	})
+}
+
+func TestMergeResults(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString, Required: true},
+			"port": {Type: TypeInt},
+		},
+	}
+	v := NewValidator(schema)
+	a := v.ValidateBytes([]byte("name: a\nport: x\n"))
+	b := v.ValidateBytes([]byte("port: 1\n"))
+
+	merged := MergeResults(map[string]*ValidationResult{"b.yaml": b, "a.yaml": a, "empty.yaml": nil})
+	errs := merged.Collector.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if errs[0].File != "a.yaml" || errs[0].Path != "port" || errs[1].File != "b.yaml" || errs[1].Path != "name" {
+		t.Fatalf("unexpected findings: %v", errs)
+	}
+	if len(merged.Files) != 2 {
+		t.Fatalf("expected source lines for 2 files, got %v", merged.Files)
+	}
+
+	out := merged.FormatAll(true)
+	if !strings.Contains(out, ">    2 | port: x") {
+		t.Fatalf("expected snippet from a.yaml, got:\n%s", out)
+	}
+	if !strings.Contains(out, ">    1 | port: 1") {
+		t.Fatalf("expected snippet from b.yaml, got:\n%s", out)
+	}
+	if strings.Index(out, "a.yaml:2:7") > strings.Index(out, "b.yaml:1:1") {
+		t.Fatalf("expected a.yaml findings first, got:\n%s", out)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"port": {Type: TypeInt},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("port: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	v := NewValidator(schema)
+	res, err := v.ValidateFile(path, ValidationContext{})
+	if err != nil {
+		t.Fatalf("ValidateFile: %v", err)
+	}
+	errs := res.Collector.Errors()
+	if len(errs) != 1 || errs[0].File != path {
+		t.Fatalf("expected one error for %s, got %v", path, errs)
+	}
+	if !strings.HasPrefix(errs[0].Error(), "[ERROR] "+path+":1:7: ") {
+		t.Fatalf("unexpected Error(): %s", errs[0].Error())
+	}
+
+	out, err := json.Marshal(errs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["file"] != path || decoded["level"] != "error" || decoded["path"] != "port" {
+		t.Fatalf("unexpected JSON: %s", out)
+	}
+
+	// Merging keeps the path unprefixed and still finds the source lines.
+	merged := MergeResults(map[string]*ValidationResult{"app": res})
+	if p := merged.Collector.Errors()[0].Path; p != "port" {
+		t.Fatalf("expected unprefixed path, got %q", p)
+	}
+	if !strings.Contains(merged.FormatAll(true), ">    1 | port: x") {
+		t.Fatalf("expected source snippet, got:\n%s", merged.FormatAll(true))
+	}
+
+	if _, err := v.ValidateFile(filepath.Join(t.TempDir(), "missing.yaml"), ValidationContext{}); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+
+	// In-memory validation keeps the old output.
+	mem := v.ValidateBytes([]byte("port: x\n")).Collector.Errors()[0]
+	if mem.File != "" || !strings.HasPrefix(mem.Error(), "[ERROR] line 1:7: ") {
+		t.Fatalf("unexpected in-memory error: %s", mem.Error())
+	}
+	out, _ = json.Marshal(mem)
+	if strings.Contains(string(out), `"file"`) {
+		t.Fatalf("expected no file key for in-memory error: %s", out)
+	}
+}
+
+func TestValidateString(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"known": {Type: TypeString},
+		},
+		UnknownKeyPolicy: UnknownKeyInherit,
+	}
+
+	yaml := `
+known: "value"
+unknown: "value"
+`
+
+	t.Run("default options", func(t *testing.T) {
+		result := NewValidator(schema).ValidateString(yaml)
+		if len(result.Collector.Errors()) != 0 {
+			t.Errorf("got %d errors, want 0", len(result.Collector.Errors()))
+		}
+		if len(result.Collector.Warnings()) != 1 {
+			t.Errorf("got %d warnings, want 1", len(result.Collector.Warnings()))
+		}
+	})
+
+	t.Run("with options", func(t *testing.T) {
+		result := NewValidator(schema).ValidateStringWithOptions(yaml, ValidationContext{StrictKeys: true})
+		if len(result.Collector.Errors()) != 1 {
+			t.Errorf("got %d errors, want 1", len(result.Collector.Errors()))
+		}
+		if len(result.SourceLines) == 0 {
+			t.Error("expected source lines to be kept")
+		}
+	})
+}
+
+func TestNestedDefaults(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString},
+			"server": {
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"port": {Type: TypeInt, Default: 8080},
+					"host": {Type: TypeString},
+					"tls": {
+						Type: TypeMap,
+						AllowedKeys: map[string]*FieldSchema{
+							"enabled": {Type: TypeBool, Default: false},
+							"cert":    {Type: TypeString, Required: true, Default: "x"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	warningPaths := func(res *ValidationResult) []string {
+		var paths []string
+		for _, w := range res.Collector.Warnings() {
+			paths = append(paths, w.Path)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	t.Run("absent parent", func(t *testing.T) {
+		res := NewValidator(schema).ValidateString("name: app\n")
+		got := warningPaths(res)
+		want := []string{"server.port", "server.tls.enabled"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("got warnings %v, want %v", got, want)
+		}
+		for _, w := range res.Collector.Warnings() {
+			if w.Line != 1 || w.Column != 1 {
+				t.Errorf("expected warning anchored at the root mapping, got %d:%d", w.Line, w.Column)
+			}
+		}
+	})
+
+	t.Run("present parent", func(t *testing.T) {
+		res := NewValidator(schema).ValidateString("server:\n  tls:\n    cert: c\n")
+		got := warningPaths(res)
+		want := []string{"server.port", "server.tls.enabled"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("got warnings %v, want %v", got, want)
+		}
+	})
+}
+
+func TestValidateSchemaDefaults(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"port":  {Type: TypeInt, Default: "abc"},
+			"level": {Type: TypeString, Default: "trace", Validators: []ValueValidator{valv.EnumValidator{Allowed: []string{"debug", "info"}}}},
+			"ok":    {Type: TypeFloat, Default: 3},
+			"tags": {
+				Type:       TypeSequence,
+				ItemSchema: &FieldSchema{Type: TypeString},
+				Default:    []int{1, 2},
+			},
+		},
+	}
+
+	errs := ValidateSchema(schema)
+	byPath := map[string][]ValidationError{}
+	for _, e := range errs {
+		byPath[e.Path] = append(byPath[e.Path], e)
+	}
+
+	if got := byPath["port"]; len(got) != 1 || got[0].Expected != "integer" || !strings.HasPrefix(got[0].Message, "invalid default: ") {
+		t.Errorf("expected type error for port default, got %v", got)
+	}
+	if got := byPath["level"]; len(got) != 1 || got[0].Got != "trace" {
+		t.Errorf("expected enum error for level default, got %v", got)
+	}
+	if got := byPath["tags[0]"]; len(got) != 1 || got[0].Expected != "string" {
+		t.Errorf("expected item type error for tags default, got %v", got)
+	}
+	if got := byPath["ok"]; len(got) != 0 {
+		t.Errorf("int default should satisfy a float field, got %v", got)
+	}
+	if len(errs) != 4 {
+		t.Errorf("expected 4 errors, got %v", errs)
+	}
+	for _, e := range errs {
+		if e.Line != 0 || e.Column != 0 {
+			t.Errorf("schema errors should have no position, got %d:%d", e.Line, e.Column)
+		}
+	}
+
+	if errs := ValidateSchema(&FieldSchema{Type: TypeString, Default: "x"}); len(errs) != 0 {
+		t.Errorf("expected valid schema, got %v", errs)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString, Required: true},
+			"port": {Type: TypeInt, Default: 8080},
+			"tls": {
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"enabled": {Type: TypeBool},
+					"cert":    {Type: TypeString},
+				},
+			},
+			"debug": {Type: TypeBool},
+		},
+	}
+	data := []byte("name: app\ntls:\n  enabled: true\n---\nport: 1\n")
+
+	report, err := NewValidator(schema).Explain(data)
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if len(report.Documents) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(report.Documents))
+	}
+
+	type row struct {
+		status FieldStatus
+		typ    NodeType
+		line   int
+	}
+	flatten := func(fields []*ExplainField) map[string]row {
+		out := map[string]row{}
+		var walk func([]*ExplainField)
+		walk = func(fs []*ExplainField) {
+			for _, f := range fs {
+				out[f.Path] = row{f.Status, f.Type, f.Line}
+				walk(f.Fields)
+			}
+		}
+		walk(fields)
+		return out
+	}
+
+	got := flatten(report.Documents[0].Fields)
+	want := map[string]row{
+		"name":        {FieldPresent, TypeString, 1},
+		"port":        {FieldDefaulted, TypeInt, 0},
+		"tls":         {FieldPresent, TypeMap, 3},
+		"tls.enabled": {FieldPresent, TypeBool, 3},
+		"tls.cert":    {FieldAbsent, TypeString, 0},
+		"debug":       {FieldAbsent, TypeBool, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d fields, want %d: %v", len(got), len(want), got)
+	}
+	for path, w := range want {
+		if got[path] != w {
+			t.Errorf("%s: got %+v, want %+v", path, got[path], w)
+		}
+	}
+	if names := report.Documents[0].Fields; names[0].Name != "debug" || names[3].Name != "tls" {
+		t.Errorf("expected fields sorted by name")
+	}
+
+	second := flatten(report.Documents[1].Fields)
+	if r := second["doc[1].port"]; r.status != FieldPresent || r.line != 5 {
+		t.Errorf("unexpected second document port: %+v", r)
+	}
+	if r := second["doc[1].name"]; r.status != FieldAbsent {
+		t.Errorf("unexpected second document name: %+v", r)
+	}
+
+	if _, err := NewValidator(schema).Explain([]byte("a: [\n")); err == nil {
+		t.Error("expected decode error")
+	}
+}
+
+func TestIntRangeValidator(t *testing.T) {
+	const limit = int64(9_000_000_000_000_000_000)
+	doc := []byte("9000000000000000001")
+
+	// Float comparison rounds both sides to the same float64 and misses the overflow.
+	floatSchema := &FieldSchema{
+		Type:       TypeInt,
+		Validators: []ValueValidator{valv.RangeValidator{Max: Ptr(float64(limit))}},
+	}
+	if errs := NewValidator(floatSchema).ValidateBytes(doc).Collector.Errors(); len(errs) != 0 {
+		t.Fatalf("expected RangeValidator to miss the difference, got %v", errs)
+	}
+
+	// TypeAny so that non-integers reach the validator.
+	schema := &FieldSchema{
+		Type:       TypeAny,
+		Validators: []ValueValidator{valv.IntRangeValidator{Min: Ptr(int64(-limit)), Max: Ptr(limit)}},
+	}
+	tests := []struct {
+		name    string
+		yaml    string
+		wantMsg string
+	}{
+		{"above max by one", "9000000000000000001", "value above maximum"},
+		{"at max", "9000000000000000000", ""},
+		{"below min by one", "-9000000000000000001", "value below minimum"},
+		{"hex", "0x10", ""},
+		{"octal", "0o17", ""},
+		{"float rejected", "1.5", "expected integer value"},
+		{"overflow rejected", "99999999999999999999", "expected integer value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if tt.wantMsg == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Message != tt.wantMsg {
+				t.Fatalf("expected %q, got %v", tt.wantMsg, errs)
+			}
+		})
+	}
+}
+
+func TestPrecisionValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type:       TypeAny,
+		Validators: []ValueValidator{valv.PrecisionValidator{MaxDecimals: 2}},
+	}
+	tests := []struct {
+		yaml    string
+		wantErr string
+	}{
+		{"10", ""},
+		{"10.5", ""},
+		{"10.25", ""},
+		{"-0.99", ""},
+		{"1.50", ""},
+		{"10.250", ""},
+		{"1.2345e2", ""},
+		{"100e-2", ""},
+		{"10.255", "too many decimal places"},
+		{"0.1", ""},
+		{"5e-3", "too many decimal places"},
+		{"12.5e-2", "too many decimal places"},
+		{".inf", "expected decimal number"},
+		{"abc", "expected decimal number"},
+		{"1.2.3", "expected decimal number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.yaml, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.HasPrefix(errs[0].Message, tt.wantErr) {
+				t.Fatalf("expected %q, got %v", tt.wantErr, errs)
+			}
+		})
+	}
+}
+
+func TestPercentValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		vld     valv.PercentValidator
+		yaml    string
+		wantErr string
+	}{
+		{"bare int", valv.PercentValidator{}, "80", ""},
+		{"bare float", valv.PercentValidator{}, "12.5", ""},
+		{"bare above max", valv.PercentValidator{}, "101", "percentage out of range"},
+		{"negative", valv.PercentValidator{}, "-1", "percentage out of range"},
+		{"suffix not allowed", valv.PercentValidator{}, "80%", "percent suffix is not allowed"},
+		{"suffix allowed", valv.PercentValidator{AllowSuffix: true}, `"80%"`, ""},
+		{"suffix above max", valv.PercentValidator{AllowSuffix: true}, "120%", "percentage out of range"},
+		{"custom max", valv.PercentValidator{AllowSuffix: true, Max: Ptr(200.0)}, "150%", ""},
+		{"not a number", valv.PercentValidator{AllowSuffix: true}, "high%", "expected percentage"},
+		{"nan", valv.PercentValidator{}, ".nan", "expected percentage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{Type: TypeAny, Validators: []ValueValidator{tt.vld}}
+			errs := NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Message != tt.wantErr {
+				t.Fatalf("expected %q, got %v", tt.wantErr, errs)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString},
+			"base": {Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}},
+			"svc":  {Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}},
+		},
+	}
+	data := []byte(`name: first
+base: &base
+  port: 1
+svc:
+  <<: *base
+  port: 2
+name: second
+`)
+
+	t.Run("default warns at second occurrence", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes(data)
+		if len(res.Collector.Errors()) != 0 {
+			t.Fatalf("expected no errors, got %v", res.Collector.Errors())
+		}
+		warnings := res.Collector.Warnings()
+		if len(warnings) != 1 {
+			t.Fatalf("expected 1 warning (merge override is not a duplicate), got %v", warnings)
+		}
+		w := warnings[0]
+		if w.Path != "name" || w.Line != 7 || w.Column != 1 || !strings.Contains(w.Message, "first defined at line 1") {
+			t.Fatalf("unexpected warning: %+v", w)
+		}
+	})
+
+	t.Run("error policy", func(t *testing.T) {
+		res := NewValidator(schema).ValidateWithOptions(data, ValidationContext{DuplicateKeyPolicy: DuplicateKeyError})
+		if len(res.Collector.Errors()) != 1 {
+			t.Fatalf("expected 1 error, got %v", res.Collector.All())
+		}
+	})
+
+	t.Run("ignore policy", func(t *testing.T) {
+		res := NewValidator(schema).ValidateWithOptions(data, ValidationContext{DuplicateKeyPolicy: DuplicateKeyIgnore})
+		if len(res.Collector.All()) != 0 {
+			t.Fatalf("expected nothing reported, got %v", res.Collector.All())
+		}
+	})
+}
+
+func TestMergedKeyAnnotation(t *testing.T) {
+	service := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"port": {Type: TypeInt},
+			"host": {Type: TypeString},
+		},
+	}
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"defaults": {Type: TypeMap, UnknownKeyPolicy: UnknownKeyIgnore},
+			"api":      service,
+		},
+	}
+	data := []byte(`defaults: &defaults
+  port: http
+  host: 1.0
+api:
+  <<: *defaults
+  host: example.org
+`)
+
+	errs := NewValidator(schema).ValidateBytes(data).Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	err := errs[0]
+	if err.Path != "api.port" || err.Line != 2 {
+		t.Fatalf("unexpected error location: %+v", err)
+	}
+	if err.Message != "type mismatch (inherited via merge)" {
+		t.Fatalf("expected merge annotation, got %q", err.Message)
+	}
+
+	// Explicit keys are not annotated.
+	errs = NewValidator(schema).ValidateBytes([]byte("api:\n  port: http\n")).Collector.Errors()
+	if len(errs) != 1 || errs[0].Message != "type mismatch" {
+		t.Fatalf("expected plain message, got %v", errs)
+	}
+}
+
+func TestMergedKeyAnnotation_Nested(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"base": {Type: TypeMap, UnknownKeyPolicy: UnknownKeyIgnore},
+			"mid":  {Type: TypeMap, UnknownKeyPolicy: UnknownKeyIgnore},
+			"api": {
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"server": {
+						Type:        TypeMap,
+						AllowedKeys: map[string]*FieldSchema{"port": {Type: TypeInt}},
+					},
+				},
+			},
+		},
+	}
+	data := []byte(`base: &base
+  port: http
+mid: &mid
+  server:
+    <<: *base
+api:
+  <<: *mid
+`)
+
+	errs := NewValidator(schema).ValidateBytes(data).Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	if errs[0].Path != "api.server.port" || errs[0].Message != "type mismatch (inherited via merge)" {
+		t.Fatalf("expected a single merge annotation, got %+v", errs[0])
+	}
+}
+
+func TestRequireNonEmpty(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString},
+		},
+	}
+	opts := ValidationContext{RequireNonEmpty: true}
+
+	tests := []struct {
+		name      string
+		yaml      string
+		wantEmpty bool
+	}{
+		{"empty file", "", true},
+		{"whitespace only", "  \n   \n\n", true},
+		{"comments only", "# nothing here\n", true},
+		{"bare separator", "---\n", true},
+		{"only separators", "---\n---\n", true},
+		{"trailing separator", "name: a\n---\n", false},
+		{"leading empty document", "---\n---\nname: a\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateWithOptions([]byte(tt.yaml), opts).Collector.Errors()
+			if !tt.wantEmpty {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Message != "document is empty, expected map" {
+				t.Fatalf("expected a single empty-document error, got %v", errs)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		if errs := NewValidator(schema).ValidateBytes([]byte("  \n")).Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("expected no errors without RequireNonEmpty, got %v", errs)
+		}
+	})
+
+	t.Run("required root implies it", func(t *testing.T) {
+		required := *schema
+		required.Required = true
+		errs := NewValidator(&required).ValidateBytes([]byte("")).Collector.Errors()
+		if len(errs) != 1 || !strings.HasPrefix(errs[0].Message, "document is empty") {
+			t.Fatalf("expected empty-document error, got %v", errs)
+		}
+	})
+}
+
+func TestDocumentCount(t *testing.T) {
+	schema := &FieldSchema{Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}}
+
+	t.Run("too many", func(t *testing.T) {
+		data := []byte("a: 1\n---\nb: 2\n---\nc: 3\n")
+		errs := NewValidator(schema).ValidateWithOptions(data, ValidationContext{MaxDocuments: 1}).Collector.Errors()
+		if len(errs) != 1 {
+			t.Fatalf("expected 1 error, got %v", errs)
+		}
+		e := errs[0]
+		if e.Message != "too many documents" || e.Got != "3" || e.Path != "doc[1]" || e.Line != 3 {
+			t.Fatalf("unexpected error: %+v", e)
+		}
+	})
+
+	t.Run("empty documents keep their index", func(t *testing.T) {
+		data := []byte("a: 1\n---\n---\nb: 2\n")
+		errs := NewValidator(&FieldSchema{Type: TypeAny}).ValidateWithOptions(data, ValidationContext{MaxDocuments: 1}).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "doc[2]" || errs[0].Line != 4 {
+			t.Fatalf("expected error at doc[2], got %v", errs)
+		}
+	})
+
+	t.Run("trailing separator is not a document", func(t *testing.T) {
+		errs := NewValidator(schema).ValidateWithOptions([]byte("a: 1\n---\n"), ValidationContext{MaxDocuments: 1}).Collector.Errors()
+		for _, e := range errs {
+			if e.Message == "too many documents" {
+				t.Fatalf("empty trailing document should not count: %v", e)
+			}
+		}
+	})
+
+	t.Run("too few", func(t *testing.T) {
+		errs := NewValidator(schema).ValidateWithOptions([]byte("a: 1\n"), ValidationContext{MinDocuments: 2}).Collector.Errors()
+		if len(errs) != 1 || errs[0].Message != "too few documents" || errs[0].Got != "1" || errs[0].Expected != "at least 2" {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("within bounds", func(t *testing.T) {
+		opts := ValidationContext{MinDocuments: 1, MaxDocuments: 2}
+		errs := NewValidator(schema).ValidateWithOptions([]byte("a: 1\n---\nb: 2\n"), opts).Collector.Errors()
+		if len(errs) != 0 {
+			t.Fatalf("expected no errors, got %v", errs)
+		}
+	})
+}
+
+func TestSchemaSelector(t *testing.T) {
+	deployment := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"kind":     {Type: TypeString, Required: true},
+			"replicas": {Type: TypeInt, Required: true},
+		},
+	}
+	service := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"kind": {Type: TypeString, Required: true},
+			"port": {Type: TypeInt, Required: true},
+		},
+	}
+	selector := func(root *yaml.Node) *FieldSchema {
+		_, kind := MappingLookup(root, "kind")
+		if kind == nil {
+			return nil
+		}
+		switch kind.Value {
+		case "Deployment":
+			return deployment
+		case "Service":
+			return service
+		}
+		return nil
+	}
+	v := NewValidator(nil, WithSchemaSelector(selector))
+
+	data := []byte(`kind: Deployment
+replicas: 3
+---
+kind: Service
+port: http
+---
+kind: Ingress
+---
+`)
+	errs := v.ValidateBytes(data).Collector.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if errs[0].Path != "doc[1].port" || errs[0].Message != "type mismatch" {
+		t.Errorf("expected service port type error, got %v", errs[0])
+	}
+	if errs[1].Path != "doc[2]" || !strings.HasPrefix(errs[1].Message, "unrecognized document") || errs[1].Line != 7 {
+		t.Errorf("expected unrecognized document error, got %v", errs[1])
+	}
+}
+
+func TestContextInferType(t *testing.T) {
+	parse := func(src string) *yaml.Node {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc.Content[0]
+	}
+
+	tests := []struct {
+		src  string
+		ctx  ValidationContext
+		want NodeType
+	}{
+		{"42", ValidationContext{}, TypeInt},
+		{`"42"`, ValidationContext{}, TypeString},
+		{"yes", ValidationContext{}, TypeString},
+		{"yes", ValidationContext{YAML11Booleans: true}, TypeBool},
+		{"{a: 1}", ValidationContext{}, TypeMap},
+		{"!port 8080", ValidationContext{}, TypeAny},
+		{"!port 8080", ValidationContext{StrictTypes: true}, TypeAny},
+		{"!port 8080", ValidationContext{CustomTags: map[string]NodeType{"!port": TypeInt}}, TypeInt},
+		{"~", ValidationContext{}, TypeNull},
+	}
+	for _, tt := range tests {
+		ctx := tt.ctx
+		if got := ctx.InferType(parse(tt.src)); got != tt.want {
+			t.Errorf("InferType(%q) with %+v = %v, want %v", tt.src, tt.ctx, got, tt.want)
+		}
+	}
+
+	// OneOfTypeValidator goes through the context as well.
+	schema := &FieldSchema{
+		Type:       TypeAny,
+		Validators: []ValueValidator{valv.OneOfTypeValidator{Types: []NodeType{TypeBool}}},
+	}
+	if errs := NewValidator(schema).ValidateBytes([]byte("on")).Collector.Errors(); len(errs) != 1 {
+		t.Fatalf("expected lax inference to reject 'on', got %v", errs)
+	}
+	res := NewValidator(schema).ValidateWithOptions([]byte("on"), ValidationContext{YAML11Booleans: true})
+	if errs := res.Collector.Errors(); len(errs) != 0 {
+		t.Fatalf("expected YAML 1.1 inference to accept 'on', got %v", errs)
+	}
+}
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	schema := &FieldSchema{
+		Type:                TypeMap,
+		CaseInsensitiveKeys: true,
+		AllowedKeys: map[string]*FieldSchema{
+			"apiVersion": {Type: TypeString, Required: true},
+			"kind":       {Type: TypeString},
+		},
+		UnknownKeyPolicy: UnknownKeyError,
+	}
+
+	t.Run("matches ignoring case with a warning", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes([]byte("apiversion: v1\nkind: Pod\n"))
+		if errs := res.Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("expected no errors, got %v", errs)
+		}
+		warnings := res.Collector.Warnings()
+		if len(warnings) != 1 || warnings[0].Path != "apiVersion" || warnings[0].Got != "apiversion" {
+			t.Fatalf("expected case warning, got %v", warnings)
+		}
+	})
+
+	t.Run("value validated against canonical schema", func(t *testing.T) {
+		errs := NewValidator(schema).ValidateBytes([]byte("APIVERSION: [1]\n")).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "apiVersion" || errs[0].Message != "type mismatch" {
+			t.Fatalf("expected type mismatch at apiVersion, got %v", errs)
+		}
+	})
+
+	t.Run("exact spelling has no warning", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes([]byte("apiVersion: v1\n"))
+		if len(res.Collector.All()) != 0 {
+			t.Fatalf("expected nothing reported, got %v", res.Collector.All())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		strict := *schema
+		strict.CaseInsensitiveKeys = false
+		errs := NewValidator(&strict).ValidateBytes([]byte("apiversion: v1\n")).Collector.Errors()
+		if len(errs) != 2 {
+			t.Fatalf("expected unknown key and missing required errors, got %v", errs)
+		}
+	})
+
+	t.Run("collision guard", func(t *testing.T) {
+		colliding := &FieldSchema{
+			Type:                TypeMap,
+			CaseInsensitiveKeys: true,
+			AllowedKeys: map[string]*FieldSchema{
+				"spec": {
+					Type:                TypeMap,
+					CaseInsensitiveKeys: true,
+					AllowedKeys: map[string]*FieldSchema{
+						"hostIP": {Type: TypeString},
+						"hostIp": {Type: TypeString},
+					},
+				},
+			},
+		}
+		errs := ValidateSchema(colliding)
+		if len(errs) != 1 || errs[0].Path != "spec" || !strings.Contains(errs[0].Message, "differ only by case") {
+			t.Fatalf("expected collision error, got %v", errs)
+		}
+		if errs := ValidateSchema(schema); len(errs) != 0 {
+			t.Fatalf("expected no schema errors, got %v", errs)
+		}
+	})
+}
+
+func TestKeyAliases(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"new_name": {Type: TypeString, Required: true, Aliases: []string{"old_name"}},
+			"url":      {Type: TypeString},
+			"path":     {Type: TypeString},
+		},
+		ExactlyOneOf:     []string{"url", "path"},
+		UnknownKeyPolicy: UnknownKeyError,
+	}
+	schema.AllowedKeys["path"].Aliases = []string{"file"}
+
+	t.Run("alias accepted with deprecation warning", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes([]byte("old_name: x\nfile: /tmp/a\n"))
+		if errs := res.Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("alias should satisfy Required and ExactlyOneOf, got %v", errs)
+		}
+		warnings := res.Collector.Warnings()
+		if len(warnings) != 2 {
+			t.Fatalf("expected 2 alias warnings, got %v", warnings)
+		}
+		w := warnings[0]
+		if w.Path != "new_name" || w.Line != 1 || w.Message != `key "old_name" is a deprecated alias, use "new_name"` {
+			t.Fatalf("unexpected warning: %+v", w)
+		}
+	})
+
+	t.Run("alias value validated", func(t *testing.T) {
+		errs := NewValidator(schema).ValidateBytes([]byte("old_name: [1]\nurl: x\n")).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "new_name" || errs[0].Message != "type mismatch" {
+			t.Fatalf("expected type mismatch at new_name, got %v", errs)
+		}
+	})
+
+	t.Run("alias and name together", func(t *testing.T) {
+		errs := NewValidator(schema).ValidateBytes([]byte("new_name: a\nold_name: b\nurl: x\n")).Collector.Errors()
+		if len(errs) != 1 || errs[0].Line != 2 || !strings.Contains(errs[0].Message, "both set field") {
+			t.Fatalf("expected conflict error, got %v", errs)
+		}
+	})
+
+	t.Run("deprecated field through alias", func(t *testing.T) {
+		info := &DeprecatedInfo{Since: "v2"}
+		deprecated := &FieldSchema{
+			Type: TypeMap,
+			AllowedKeys: map[string]*FieldSchema{
+				"timeout": {Type: TypeInt, Aliases: []string{"wait"}, DeprecatedInfo: info},
+			},
+		}
+		warnings := NewValidator(deprecated).ValidateString("wait: 5\n").Collector.Warnings()
+		if len(warnings) != 2 || warnings[1].Message != "deprecated since v2" || warnings[1].Deprecation == nil {
+			t.Fatalf("expected alias and deprecation warnings, got %v", warnings)
+		}
+		w := warnings[0]
+		if w.Message != `key "wait" is a deprecated alias, use "timeout"; "timeout": deprecated since v2` || w.Deprecation == nil {
+			t.Fatalf("expected the alias warning to carry the deprecation, got %+v", w)
+		}
+
+		// With one finding per path only the alias warning remains.
+		res := NewValidator(deprecated).ValidateWithOptions([]byte("wait: 5\n"), ValidationContext{OneErrorPerPath: true})
+		if warnings := res.Collector.Warnings(); len(warnings) != 1 || warnings[0].Deprecation == nil || *warnings[0].Deprecation != *info {
+			t.Fatalf("expected deprecation info to survive, got %v", warnings)
+		}
+	})
+
+	t.Run("alias collisions", func(t *testing.T) {
+		bad := &FieldSchema{
+			Type: TypeMap,
+			AllowedKeys: map[string]*FieldSchema{
+				"a": {Aliases: []string{"b", "x"}},
+				"b": {Aliases: []string{"x"}},
+			},
+		}
+		errs := ValidateSchema(bad)
+		if len(errs) != 2 {
+			t.Fatalf("expected 2 schema errors, got %v", errs)
+		}
+	})
+}
+
+func TestCharsetValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		vld     valv.CharsetValidator
+		yaml    string
+		wantMsg string
+	}{
+		{"allowed ok", valv.CharsetValidator{Allowed: "abc-"}, "a-b-c", ""},
+		{"allowed violation", valv.CharsetValidator{Allowed: "abc-"}, "ab_c", `character '_' at offset 2 is not allowed`},
+		{"disallowed ok", valv.CharsetValidator{Disallowed: "&*"}, "plain", ""},
+		{"disallowed violation", valv.CharsetValidator{Disallowed: "&*"}, `"a*b&c"`, `character '*' at offset 1 is not allowed`},
+		{"rune offset", valv.CharsetValidator{Disallowed: "!"}, "héllo!", `character '!' at offset 5 is not allowed`},
+		{"both", valv.CharsetValidator{Allowed: "abc", Disallowed: "c"}, "abc", `character 'c' at offset 2 is not allowed`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{Type: TypeString, Validators: []ValueValidator{tt.vld}}
+			errs := NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if tt.wantMsg == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Message != tt.wantMsg {
+				t.Fatalf("expected %q, got %v", tt.wantMsg, errs)
+			}
+		})
+	}
+}
+
+func TestAnyOfValidator(t *testing.T) {
+	ipOrHost := valv.AnyOfValidator{Validators: []ValueValidator{
+		valv.RegexValidator{Pattern: regexp.MustCompile(`^\d+\.\d+\.\d+\.\d+$`), Message: "not an IPv4 address"},
+		valv.RegexValidator{Pattern: regexp.MustCompile(`^[a-z0-9.-]+$`), Message: "not a hostname"},
+	}}
+	schema := &FieldSchema{Type: TypeString, Validators: []ValueValidator{ipOrHost}}
+
+	for _, ok := range []string{"10.0.0.1", "example.org"} {
+		if errs := NewValidator(schema).ValidateBytes([]byte(ok)).Collector.Errors(); len(errs) != 0 {
+			t.Errorf("%s: expected no errors, got %v", ok, errs)
+		}
+	}
+
+	errs := NewValidator(schema).ValidateBytes([]byte("Not_Valid")).Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected one combined error, got %v", errs)
+	}
+	if errs[0].Message != "value matches none of the alternatives: not an IPv4 address; not a hostname" {
+		t.Fatalf("unexpected message: %q", errs[0].Message)
+	}
+
+	// StopOnFirst in the parent does not stop the alternatives.
+	res := NewValidator(schema).ValidateWithOptions([]byte("example.org"), ValidationContext{StopOnFirst: true})
+	if len(res.Collector.Errors()) != 0 {
+		t.Fatalf("expected no errors with StopOnFirst, got %v", res.Collector.Errors())
+	}
+}
+
+func TestSiblingConditionValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"protocol": {Type: TypeString},
+			"port":     {Type: TypeInt},
+		},
+		MapValidators: []MapValidator{
+			mapv.SiblingConditionValidator{
+				Field:     "protocol",
+				Value:     "https",
+				Target:    "port",
+				Validator: valv.EnumValidator{Allowed: []string{"443"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want int
+	}{
+		{"triggered and valid", "protocol: https\nport: 443\n", 0},
+		{"triggered and invalid", "protocol: https\nport: 8443\n", 1},
+		{"not triggered", "protocol: http\nport: 8080\n", 0},
+		{"trigger missing", "port: 8080\n", 0},
+		{"target missing", "protocol: https\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if len(errs) != tt.want {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), tt.want, errs)
+			}
+			if tt.want == 1 && (errs[0].Path != "port" || errs[0].Line != 2 || errs[0].Column != 7) {
+				t.Fatalf("expected error at the target field, got %+v", errs[0])
+			}
+		})
+	}
+}
+
+func TestFieldComparisonValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"minReplicas": {Type: TypeInt},
+			"maxReplicas": {Type: TypeInt},
+		},
+		MapValidators: []MapValidator{
+			mapv.FieldComparisonValidator{Left: "minReplicas", Op: mapv.OpLessEqual, Right: "maxReplicas"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want int
+	}{
+		{"less", "minReplicas: 1\nmaxReplicas: 5\n", 0},
+		{"equal", "minReplicas: 3\nmaxReplicas: 3\n", 0},
+		{"greater", "minReplicas: 5\nmaxReplicas: 2\n", 1},
+		{"hex forms", "minReplicas: 0x10\nmaxReplicas: 0o20\n", 0},
+		{"missing right", "minReplicas: 5\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if len(errs) != tt.want {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), tt.want, errs)
+			}
+			if tt.want == 1 {
+				e := errs[0]
+				if e.Path != "maxReplicas" || e.Line != 2 || e.Got != "minReplicas=5, maxReplicas=2" {
+					t.Fatalf("unexpected error: %+v", e)
+				}
+			}
+		})
+	}
+
+	for _, op := range []mapv.CompareOp{"<", "<=", "==", "!=", ">", ">="} {
+		if !op.Valid() {
+			t.Errorf("%q should be valid", op)
+		}
+	}
+	if mapv.CompareOp("=<").Valid() {
+		t.Error("=< should be invalid")
+	}
+}
+
+func TestSumValidator(t *testing.T) {
+	weights := valv.RangeValidator{Min: Ptr(0.0)}
+	schema := &FieldSchema{
+		Type: TypeSequence,
+		ItemSchema: &FieldSchema{
+			Type: TypeMap,
+			AllowedKeys: map[string]*FieldSchema{
+				"name":   {Type: TypeString},
+				"weight": {Type: TypeFloat, Validators: []ValueValidator{weights}},
+			},
+		},
+		SeqValidators: []SeqValidator{seqv.SumValidator{Field: "weight", Total: 100, Tolerance: 0.001}},
+	}
+
+	t.Run("sums to 100", func(t *testing.T) {
+		data := []byte("- {name: a, weight: 60}\n- {name: b, weight: 39.9995}\n- {name: c, weight: 0.0005}\n")
+		if errs := NewValidator(schema).ValidateBytes(data).Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("expected no errors, got %v", errs)
+		}
+	})
+
+	t.Run("sums to 99", func(t *testing.T) {
+		data := []byte("- {name: a, weight: 60}\n- {name: b, weight: 39}\n")
+		errs := NewValidator(schema).ValidateBytes(data).Collector.Errors()
+		if len(errs) != 1 || errs[0].Got != "99" || errs[0].Expected != "== 100" || errs[0].Line != 1 {
+			t.Fatalf("expected sum error, got %v", errs)
+		}
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		data := []byte("- {name: a, weight: 100}\n- {name: b}\n")
+		errs := NewValidator(schema).ValidateBytes(data).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "[1]" || errs[0].Message != `field "weight" is missing` {
+			t.Fatalf("expected missing field error, got %v", errs)
+		}
+	})
+
+	t.Run("dotted field name", func(t *testing.T) {
+		s := &FieldSchema{
+			Type:          TypeSequence,
+			ItemSchema:    &FieldSchema{Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}},
+			SeqValidators: []SeqValidator{seqv.SumValidator{Field: "unit.cost", Total: 10}},
+		}
+		errs := NewValidator(s).ValidateBytes([]byte("- {unit.cost: ten}\n")).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != `[0]["unit.cost"]` {
+			t.Fatalf("expected error at quoted field path, got %v", errs)
+		}
+	})
+
+	t.Run("scalar items at most", func(t *testing.T) {
+		s := &FieldSchema{
+			Type:          TypeSequence,
+			ItemSchema:    &FieldSchema{Type: TypeInt},
+			SeqValidators: []SeqValidator{seqv.SumValidator{Op: seqv.SumAtMost, Total: 10}},
+		}
+		if errs := NewValidator(s).ValidateBytes([]byte("[3, 3, 4]")).Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("expected no errors, got %v", errs)
+		}
+		if errs := NewValidator(s).ValidateBytes([]byte("[3, 3, 5]")).Collector.Errors(); len(errs) != 1 {
+			t.Fatalf("expected 1 error, got %v", errs)
+		}
+	})
+
+	t.Run("map values", func(t *testing.T) {
+		s := &FieldSchema{
+			Type:                 TypeMap,
+			AdditionalProperties: &FieldSchema{Type: TypeInt},
+			MapValidators:        []MapValidator{seqv.SumValidator{Total: 100}},
+		}
+		if errs := NewValidator(s).ValidateBytes([]byte("canary: 10\nstable: 90\n")).Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("expected no errors, got %v", errs)
+		}
+		errs := NewValidator(s).ValidateBytes([]byte("canary: 10\nstable: 89\n")).Collector.Errors()
+		if len(errs) != 1 || errs[0].Message != "sum of values is 99" {
+			t.Fatalf("expected sum error, got %v", errs)
+		}
+	})
+}
+
+type registryValidator struct{}
+
+func (registryValidator) Validate(node *yaml.Node, path string, ctx *ValidationContext) {
+	registries, ok := ContextValue[[]string](ctx, "registries")
+	if !ok {
+		return
+	}
+	registry, _, _ := strings.Cut(node.Value, "/")
+	for _, r := range registries {
+		if r == registry {
+			return
+		}
+	}
+	ctx.AddError(ValidationError{
+		Level:   LevelError,
+		Path:    path,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: "image registry is not allowed",
+		Got:     registry,
+	})
+}
+
+func TestContextValues(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"image": {Type: TypeString, Validators: []ValueValidator{registryValidator{}}},
+		},
+	}
+	opts := ValidationContext{Values: map[string]interface{}{"registries": []string{"ghcr.io", "quay.io"}}}
+
+	if errs := NewValidator(schema).ValidateStringWithOptions("image: ghcr.io/app:1", opts).Collector.Errors(); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	errs := NewValidator(schema).ValidateStringWithOptions("image: docker.io/app:1", opts).Collector.Errors()
+	if len(errs) != 1 || errs[0].Got != "docker.io" {
+		t.Fatalf("expected registry error, got %v", errs)
+	}
+
+	// Without the value the validator has nothing to check against.
+	if errs := NewValidator(schema).ValidateString("image: docker.io/app:1").Collector.Errors(); len(errs) != 0 {
+		t.Fatalf("expected no errors without Values, got %v", errs)
+	}
+
+	ctx := NewValidationContext()
+	ctx.Values = map[string]interface{}{"n": 1}
+	if _, ok := ContextValue[string](ctx, "n"); ok {
+		t.Error("ContextValue should reject a value of another type")
+	}
+	if val, ok := ctx.Value("n"); !ok || val != 1 {
+		t.Errorf("Value(n) = %v, %v", val, ok)
+	}
+	if _, ok := ctx.Fork().Value("n"); !ok {
+		t.Error("forked context should share Values")
+	}
+}
+
+func TestStability(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"stable":       {Type: TypeString},
+			"beta":         {Type: TypeString, Stability: StabilityBeta},
+			"experimental": {Type: TypeString, Stability: StabilityExperimental},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		doc          string
+		opts         ValidationContext
+		wantErrors   int
+		wantWarnings int
+	}{
+		{"stable", "stable: x", ValidationContext{}, 0, 0},
+		{"stable strict", "stable: x", ValidationContext{StrictStability: true}, 0, 0},
+		{"beta", "beta: x", ValidationContext{}, 0, 1},
+		{"beta strict", "beta: x", ValidationContext{StrictStability: true}, 0, 1},
+		{"beta allowed", "beta: x", ValidationContext{AllowExperimental: true}, 0, 0},
+		{"experimental", "experimental: x", ValidationContext{}, 0, 1},
+		{"experimental strict", "experimental: x", ValidationContext{StrictStability: true}, 1, 0},
+		{"experimental allowed", "experimental: x", ValidationContext{StrictStability: true, AllowExperimental: true}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateStringWithOptions(tt.doc, tt.opts)
+			errs, warnings := result.Collector.Errors(), result.Collector.Warnings()
+			if len(errs) != tt.wantErrors || len(warnings) != tt.wantWarnings {
+				t.Fatalf("got %d errors, %d warnings, want %d, %d: %v",
+					len(errs), len(warnings), tt.wantErrors, tt.wantWarnings, result.Collector.All())
+			}
+		})
+	}
+
+	warnings := NewValidator(schema).ValidateString("experimental: x").Collector.Warnings()
+	if warnings[0].Message != "field is experimental and may change without notice" || warnings[0].Path != "experimental" {
+		t.Errorf("unexpected warning: %v", warnings[0])
+	}
+}
+
+func TestNonEmpty(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name":   {Type: TypeString, NonEmpty: true},
+			"tags":   {Type: TypeSequence, NonEmpty: true, ItemSchema: &FieldSchema{Type: TypeString}},
+			"labels": {Type: TypeMap, NonEmpty: true, AdditionalProperties: &FieldSchema{Type: TypeString}},
+			"note":   {Type: TypeString, NonEmpty: true, Nullable: true},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErrors int
+		wantPath   string
+	}{
+		{"non-empty string", `name: "x"`, 0, ""},
+		{"empty string", `name: ""`, 1, "name"},
+		{"non-empty sequence", `tags: [a]`, 0, ""},
+		{"empty sequence", `tags: []`, 1, "tags"},
+		{"non-empty map", `labels: {a: b}`, 0, ""},
+		{"empty map", `labels: {}`, 1, "labels"},
+		{"nullable null", `note: null`, 0, ""},
+		{"nullable empty string", `note: ''`, 1, "note"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateString(tt.yaml).Collector.Errors()
+			if len(errs) != tt.wantErrors {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), tt.wantErrors, errs)
+			}
+			if tt.wantErrors > 0 && (errs[0].Path != tt.wantPath || errs[0].Message != "value cannot be empty") {
+				t.Errorf("unexpected error: %v", errs[0])
+			}
+		})
+	}
+}
+
+func TestSelectPath(t *testing.T) {
+	var doc yaml.Node
+	src := `
+base: &base {image: nginx}
+spec:
+  containers:
+    - name: a
+      <<: *base
+    - name: b
+      image: redis
+`
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Content[0]
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"", []string{""}},
+		{"spec.containers[1].image", []string{"spec.containers[1].image"}},
+		{"spec.containers[*].image", []string{"spec.containers[0].image", "spec.containers[1].image"}},
+		{"spec.*[0].name", []string{"spec.containers[0].name"}},
+		{"spec.containers[5]", nil},
+		{"spec.missing", nil},
+	}
+	for _, tt := range tests {
+		matches, err := SelectPath(root, tt.selector)
+		if err != nil {
+			t.Fatalf("SelectPath(%q): %v", tt.selector, err)
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.Path)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SelectPath(%q) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"a..b", "a.", ".a", "a[x]", "a[1", "a[-1]"} {
+		if _, err := SelectPath(root, bad); err == nil {
+			t.Errorf("SelectPath(%q): expected error", bad)
+		}
+	}
+}
+
+func TestRequirePaths(t *testing.T) {
+	schema := &FieldSchema{Type: TypeMap, UnknownKeyPolicy: UnknownKeyIgnore}
+	v := NewValidator(schema, WithRequirePaths("spec.template.spec.containers[*].image", "metadata.name"))
+
+	ok := `
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - image: nginx
+`
+	if errs := v.ValidateString(ok).Collector.Errors(); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	missing := `
+metadata: {}
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+`
+	errs := v.ValidateString(missing).Collector.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if errs[0].Path != "spec.template.spec.containers[*].image" || errs[0].Line != 7 || errs[0].Message != "required path is missing" {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+	if errs[1].Path != "metadata.name" || errs[1].Line != 2 {
+		t.Errorf("unexpected error: %v", errs[1])
+	}
+
+	errs = v.ValidateString("metadata: {name: a}\n---\nmetadata: {name: b}\n").Collector.Errors()
+	if len(errs) != 2 || errs[1].Path != "doc[1].spec.template.spec.containers[*].image" {
+		t.Fatalf("expected a missing path per document, got %v", errs)
+	}
+	if err := v.Err(); err != nil {
+		t.Fatalf("unexpected option error: %v", err)
+	}
+
+	// Invalid selectors are reported once, by Err, not in every document.
+	bad := NewValidator(schema, WithRequirePaths("metadata..name", "metadata.name"))
+	if err := bad.Err(); err == nil || !strings.Contains(err.Error(), `invalid selector "metadata..name"`) {
+		t.Fatalf("expected invalid selector error, got %v", err)
+	}
+	errs = bad.ValidateString("metadata: {name: a}\n---\nmetadata: {}\n").Collector.Errors()
+	if len(errs) != 1 || errs[0].Path != "doc[1].metadata.name" {
+		t.Fatalf("expected only the valid selector to be checked, got %v", errs)
+	}
+}
+
+func TestForbidPaths(t *testing.T) {
+	schema := &FieldSchema{Type: TypeMap, UnknownKeyPolicy: UnknownKeyIgnore}
+	v := NewValidator(schema, WithForbidPaths("spec.hostNetwork", "spec.containers[*].securityContext.privileged"))
+
+	clean := `
+spec:
+  containers:
+    - name: web
+      securityContext: {runAsNonRoot: true}
+`
+	if errs := v.ValidateString(clean).Collector.Errors(); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	doc := `
+spec:
+  hostNetwork: true
+  containers:
+    - name: web
+    - name: sidecar
+      securityContext:
+        privileged: true
+`
+	errs := v.ValidateString(doc).Collector.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if errs[0].Path != "spec.hostNetwork" || errs[0].Line != 3 || errs[0].Column != 3 || errs[0].Message != "path is forbidden" {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+	if errs[1].Path != "spec.containers[1].securityContext.privileged" || errs[1].Line != 8 {
+		t.Errorf("unexpected wildcard error: %v", errs[1])
+	}
+	bad := NewValidator(schema, WithForbidPaths("spec[x]"))
+	if err := bad.Err(); err == nil || !strings.Contains(err.Error(), "bad index") {
+		t.Fatalf("expected invalid selector error, got %v", err)
+	}
+	if errs := bad.ValidateString("spec: {}\n---\nspec: {}\n").Collector.Errors(); len(errs) != 0 {
+		t.Fatalf("expected invalid selector to be skipped, got %v", errs)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString, Aliases: []string{"title"}},
+			"port": {Type: TypeInt},
+			"labels": {
+				Type:                 TypeMap,
+				AdditionalProperties: &FieldSchema{Type: TypeString},
+			},
+		},
+	}
+	doc := `title: web
+port: http
+labels:
+  app: web
+extra: 1
+`
+	var trace strings.Builder
+	NewValidator(schema).ValidateStringWithOptions(doc, ValidationContext{Trace: &trace})
+
+	want := `1:1 (root): map value, schema type map
+1:1 name: known key (written as "title")
+1:8 name: string value, schema type string
+2:1 port: known key
+2:7 port: string value, schema type integer
+2:7 port: type check failed, children not validated
+3:1 labels: known key
+4:3 labels: map value, schema type map
+4:3 labels.app: additional property
+4:8 labels.app: string value, schema type string
+5:1 extra: unknown key, reported as warning
+`
+	if trace.String() != want {
+		t.Errorf("trace mismatch:\ngot:\n%s\nwant:\n%s", trace.String(), want)
+	}
+}
+
+func TestErrorSpan(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name":  {Type: TypeString},
+			"ports": {Type: TypeSequence, MaxItems: Ptr(1), ItemSchema: &FieldSchema{Type: TypeAny}},
+			"tags":  {Type: TypeString},
+			"env":   {Type: TypeMap, NonEmpty: true},
+			"port":  {Type: TypeString},
+		},
+	}
+	doc := `name:
+  first: a
+  last: "b # c"
+ports: [80, 'x''y' ]  # two
+tags:
+  - a
+  - |
+    long text
+env: {}
+port: 8080
+`
+	errs := NewValidator(schema).ValidateString(doc).Collector.Errors()
+	spans := make(map[string][4]int)
+	for _, err := range errs {
+		spans[err.Path] = [4]int{err.Line, err.Column, err.EndLine, err.EndColumn}
+	}
+	want := map[string][4]int{
+		"name":  {2, 3, 3, 16},
+		"ports": {4, 8, 4, 21},
+		"tags":  {6, 3, 8, 14},
+		"env":   {9, 6, 9, 8},
+		"port":  {10, 7, 0, 0},
+	}
+	if !reflect.DeepEqual(spans, want) {
+		t.Errorf("spans = %v, want %v", spans, want)
+	}
+
+	data, err := json.Marshal(errs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"endLine":3,"endColumn":16`) {
+		t.Errorf("expected end position in JSON, got %s", data)
+	}
+}
+
+func TestSetType(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"features": {
+				Type:       TypeSet,
+				MaxItems:   Ptr(3),
+				ItemSchema: &FieldSchema{Type: TypeString, Validators: []ValueValidator{valv.EnumValidator{Allowed: []string{"a", "b", "c"}}}},
+			},
+			"labels": {Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny, Nullable: true}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		wantMsgs []string
+	}{
+		{"tagged set", "features: !!set {a, b}", nil},
+		{"explicit keys", "features: !!set\n  ? a\n  ? c\n", nil},
+		{"untagged null mapping", "features: {a: ~}", nil},
+		{"member with value", "features: !!set {a, b: 1}", []string{"set members cannot have values"}},
+		{"duplicate member", "features: !!set\n  ? a\n  ? a\n", []string{"duplicate set member \"a\" (first defined at line 2)"}},
+		{"invalid member", "features: !!set {a, z}", []string{`invalid value "z"`}},
+		{"too many members", "features: !!set {a, b, c, a2}", []string{"too many items", `invalid value "a2"`}},
+		{"set accepted as map", "labels: !!set {x, y}", nil},
+		{"sequence is not a set", "features: [a]", []string{"type mismatch"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateString(tt.yaml).Collector.Errors()
+			var msgs []string
+			for _, err := range errs {
+				msgs = append(msgs, err.Message)
+			}
+			if !reflect.DeepEqual(msgs, tt.wantMsgs) {
+				t.Errorf("errors = %v, want messages %v", errs, tt.wantMsgs)
+			}
+		})
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("!!set {a}"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := NewValidationContext().InferType(doc.Content[0]); got != TypeSet {
+		t.Errorf("InferType(!!set) = %v, want set", got)
+	}
+}
+
+func TestCustomTags(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"port":   {Type: TypeInt},
+			"secret": {Type: TypeString},
+			"env":    {Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeString}},
+		},
+	}
+	tags := map[string]NodeType{"!port": TypeInt, "!Env": TypeMap}
+
+	tests := []struct {
+		name         string
+		yaml         string
+		opts         ValidationContext
+		wantErrors   int
+		wantWarnings int
+	}{
+		{"recognized tag", "port: !port 8080", ValidationContext{CustomTags: tags}, 0, 0},
+		{"recognized tag wrong type", "secret: !port 8080", ValidationContext{CustomTags: tags}, 1, 0},
+		{"recognized mapping tag", "env: !Env {A: b}", ValidationContext{CustomTags: tags}, 0, 0},
+		{"unknown tag not guessed", "secret: !Ref 8080", ValidationContext{}, 0, 0},
+		{"unknown tag accepted as int", "port: !Ref other.port", ValidationContext{}, 0, 0},
+		{"unknown tag warning", "port: !Ref other.port", ValidationContext{WarnUnknownTags: true}, 0, 1},
+		{"standard tag still checked", "port: !!str 8080", ValidationContext{WarnUnknownTags: true}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateStringWithOptions(tt.yaml, tt.opts)
+			errs, warnings := result.Collector.Errors(), result.Collector.Warnings()
+			if len(errs) != tt.wantErrors || len(warnings) != tt.wantWarnings {
+				t.Fatalf("got %d errors, %d warnings, want %d, %d: %v",
+					len(errs), len(warnings), tt.wantErrors, tt.wantWarnings, result.Collector.All())
+			}
+		})
+	}
+
+	warnings := NewValidator(schema).ValidateStringWithOptions("port: !Ref other.port", ValidationContext{WarnUnknownTags: true}).Collector.Warnings()
+	if warnings[0].Message != `unknown tag "!Ref", type not checked` || warnings[0].Expected != "integer" {
+		t.Errorf("unexpected warning: %v", warnings[0])
+	}
+}
+
+func TestAdditionalPropertiesByType(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AdditionalPropertiesByType: map[NodeType]*FieldSchema{
+			TypeString: {Type: TypeString, Validators: []ValueValidator{valv.LengthValidator{Max: Ptr(5)}}},
+			TypeFloat:  {Type: TypeFloat, Validators: []ValueValidator{valv.RangeValidator{Min: Ptr(0.0)}}},
+		},
+		AdditionalProperties: &FieldSchema{Type: TypeBool},
+	}
+
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErrors int
+	}{
+		{"mixed valid", "name: web\nreplicas: 3\nratio: 0.5\nenabled: true\n", 0},
+		{"string rule", "name: webserver\n", 1},
+		{"int routed to float rule", "replicas: -1\n", 1},
+		{"float rule", "ratio: -0.5\n", 1},
+		{"fallback to AdditionalProperties", "tags: [a]\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateString(tt.yaml).Collector.Errors()
+			if len(errs) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d: %v", len(errs), tt.wantErrors, errs)
+			}
+		})
+	}
+
+	// Without AdditionalProperties, unmatched types follow UnknownKeyPolicy.
+	strict := &FieldSchema{
+		Type:                       TypeMap,
+		AdditionalPropertiesByType: map[NodeType]*FieldSchema{TypeString: {Type: TypeString}},
+		UnknownKeyPolicy:           UnknownKeyError,
+	}
+	errs := NewValidator(strict).ValidateString("a: x\nb: 1\n").Collector.Errors()
+	if len(errs) != 1 || errs[0].Message != `unknown key "b"` {
+		t.Errorf("expected unknown key error, got %v", errs)
+	}
+}
+
+func TestDisplayWidthValidator(t *testing.T) {
+	widths := map[string]int{
+		"hello":     5,
+		"日本語":       6,
+		"abc日本":     7,
+		"ＡＢ":        4, // fullwidth Latin
+		"ｱｲ":        2, // halfwidth Katakana
+		"한국":        4,
+		"e\u0301":   1, // combining acute accent
+		"😀 ok":      5,
+		"a\u200db":  2, // zero-width joiner
+		"":          0,
+		"Grüße, Ω!": 9,
+	}
+	for s, want := range widths {
+		if got := valv.DisplayWidth(s); got != want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+
+	schema := &FieldSchema{
+		Type: TypeString,
+		Validators: []ValueValidator{
+			valv.DisplayWidthValidator{Min: Ptr(2), Max: Ptr(8)},
+		},
+	}
+	tests := []struct {
+		value      string
+		wantErrors int
+		wantGot    string
+	}{
+		{"label", 0, ""},
+		{"設定ファイル", 1, "12"}, // 6 runes, but 12 columns
+		{"ab設定", 0, ""},
+		{"x", 1, "1"},
+	}
+	for _, tt := range tests {
+		errs := NewValidator(schema).ValidateString(tt.value).Collector.Errors()
+		if len(errs) != tt.wantErrors {
+			t.Errorf("%q: got %d errors, want %d: %v", tt.value, len(errs), tt.wantErrors, errs)
+			continue
+		}
+		if tt.wantErrors > 0 && errs[0].Got != tt.wantGot {
+			t.Errorf("%q: Got = %q, want %q", tt.value, errs[0].Got, tt.wantGot)
+		}
+	}
+}
+
+func TestAdditionalPropertiesLevel(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString},
+		},
+		AdditionalProperties:      &FieldSchema{Type: TypeInt},
+		AdditionalPropertiesLevel: Ptr(LevelWarning),
+	}
+	data := "name: web\nretries: many\ntimeout: 30\n"
+
+	result := NewValidator(schema).ValidateWithOptions([]byte(data), ValidationContext{StopOnFirst: true})
+	if errs := result.Collector.Errors(); len(errs) != 0 {
+		t.Fatalf("expected no errors for additional properties, got %v", errs)
+	}
+	warnings := result.Collector.Warnings()
+	if len(warnings) != 1 || warnings[0].Path != "retries" || warnings[0].Message != "type mismatch" {
+		t.Fatalf("expected demoted type mismatch for retries, got %v", warnings)
+	}
+
+	// Known keys are unaffected.
+	data = "name: [a]\nretries: many\n"
+	result = NewValidator(schema).ValidateString(data)
+	if errs := result.Collector.Errors(); len(errs) != 1 || errs[0].Path != "name" {
+		t.Errorf("expected error for known key only, got %v", errs)
+	}
+
+	schema.AdditionalPropertiesLevel = nil
+	if errs := NewValidator(schema).ValidateString("retries: many\n").Collector.Errors(); len(errs) != 1 {
+		t.Errorf("expected error by default, got %v", errs)
+	}
+}
+
+func TestSkipValueOnKeyError(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		KeyValidators: []KeyValidator{
+			keyv.RegexKeyValidator{Pattern: regexp.MustCompile(`^[a-z_]+$`)},
+		},
+		AdditionalProperties: &FieldSchema{Type: TypeInt},
+		SkipValueOnKeyError:  true,
+	}
+	data := "Bad-Key: many\ngood_key: lots\nok: 1\n"
+
+	errs := NewValidator(schema).ValidateString(data).Collector.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected key error for Bad-Key and type error for good_key, got %v", errs)
+	}
+	if errs[0].Path != "Bad-Key" || !strings.HasPrefix(errs[0].Message, "key does not match pattern") {
+		t.Errorf("expected key error first, got %v", errs[0])
+	}
+	if errs[1].Path != "good_key" || errs[1].Message != "type mismatch" {
+		t.Errorf("expected value error for the valid key, got %v", errs[1])
+	}
+
+	schema.SkipValueOnKeyError = false
+	if errs := NewValidator(schema).ValidateString(data).Collector.Errors(); len(errs) != 3 {
+		t.Errorf("expected key and both value errors without the option, got %v", errs)
+	}
+}
+
+func TestMergeSchemas(t *testing.T) {
+	base := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name":     {Type: TypeString, Required: true},
+			"replicas": {Type: TypeInt},
+			"tags": {
+				Type:       TypeSequence,
+				MinItems:   Ptr(1),
+				MaxItems:   Ptr(10),
+				ItemSchema: &FieldSchema{Type: TypeString},
+			},
+		},
+	}
+
+	t.Run("additive", func(t *testing.T) {
+		overlay := &FieldSchema{
+			AllowedKeys: map[string]*FieldSchema{
+				"region": {Type: TypeString, Required: true},
+			},
+		}
+		merged, err := MergeSchemas(base, overlay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if merged.Type != TypeMap || len(merged.AllowedKeys) != 4 {
+			t.Fatalf("expected base keys plus region, got %v", merged.AllowedKeys)
+		}
+		errs := NewValidator(merged).ValidateString("name: web\n").Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "region" {
+			t.Errorf("expected missing region, got %v", errs)
+		}
+		if len(base.AllowedKeys) != 3 {
+			t.Errorf("base schema was modified: %v", base.AllowedKeys)
+		}
+	})
+
+	t.Run("tightening", func(t *testing.T) {
+		overlay := &FieldSchema{
+			AllowedKeys: map[string]*FieldSchema{
+				"replicas": {
+					Required:   true,
+					Validators: []ValueValidator{valv.RangeValidator{Min: Ptr(2.0)}},
+				},
+				"tags": {MinItems: Ptr(0), MaxItems: Ptr(3)},
+			},
+		}
+		merged, err := MergeSchemas(base, overlay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replicas := merged.AllowedKeys["replicas"]
+		if replicas.Type != TypeInt || !replicas.Required || len(replicas.Validators) != 1 {
+			t.Errorf("expected required int replicas with the overlay validator, got %+v", replicas)
+		}
+		tags := merged.AllowedKeys["tags"]
+		if *tags.MinItems != 1 || *tags.MaxItems != 3 || tags.ItemSchema == nil {
+			t.Errorf("expected tighter bounds 1..3 and kept item schema, got %+v", tags)
+		}
+
+		doc := "name: web\nreplicas: 1\ntags: [a, b, c, d]\n"
+		if errs := NewValidator(base).ValidateString(doc).Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("base schema should accept the document, got %v", errs)
+		}
+		errs := NewValidator(merged).ValidateString(doc).Collector.Errors()
+		if len(errs) != 2 {
+			t.Errorf("expected range and too many items errors, got %v", errs)
+		}
+		if base.AllowedKeys["replicas"].Required {
+			t.Error("base schema was modified")
+		}
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		_, err := MergeSchemas(base, &FieldSchema{
+			AllowedKeys: map[string]*FieldSchema{"name": {Type: TypeInt}},
+		})
+		if err == nil || !strings.HasPrefix(err.Error(), "name: overlay type integer conflicts with base type string") {
+			t.Errorf("expected type conflict at name, got %v", err)
+		}
+		_, err = MergeSchemas(base, &FieldSchema{
+			AllowedKeys: map[string]*FieldSchema{"tags": {MinItems: Ptr(20)}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "minItems 20 exceeds maxItems 10") {
+			t.Errorf("expected empty range conflict, got %v", err)
+		}
+	})
+}
+
+func TestFilterByPathPrefix(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"replicas": {Type: TypeInt},
+			"template": {
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"image": {Type: TypeString},
+					"ports": {Type: TypeSequence, ItemSchema: &FieldSchema{Type: TypeInt}},
+				},
+			},
+			"templates": {Type: TypeInt},
+		},
+	}
+	data := "replicas: x\ntemplate:\n  image: [a]\n  ports: [80, http]\n  extra: 1\ntemplates: y\n"
+	result := NewValidator(schema).ValidateString(data)
+
+	filtered := result.FilterByPathPrefix("template")
+	var paths []string
+	for _, err := range filtered.Collector.All() {
+		paths = append(paths, err.Path)
+	}
+	want := []string{"template.image", "template.ports[1]", "template.extra"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if !reflect.DeepEqual(filtered.SourceLines, result.SourceLines) {
+		t.Error("source lines not preserved")
+	}
+	if !strings.Contains(filtered.FormatAll(true), ">    3 |   image: [a]") {
+		t.Errorf("expected source snippet, got:\n%s", filtered.FormatAll(true))
+	}
+	if len(result.Collector.All()) != 5 {
+		t.Errorf("original result was modified: %v", result.Collector.All())
+	}
+	if got := result.FilterByPathPrefix("template.ports[1]").Collector.All(); len(got) != 1 {
+		t.Errorf("expected one finding for an index prefix, got %v", got)
+	}
+}
+
+func TestForbidAliases(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"defaults": {Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}},
+			"name":     {Type: TypeString},
+			"server": {
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"host": {Type: TypeString, Required: true},
+					"port": {Type: TypeInt},
+				},
+			},
+		},
+	}
+	opts := ValidationContext{ForbidAliases: true}
+
+	t.Run("aliased value", func(t *testing.T) {
+		data := "defaults: {n: &n [1, 2]}\nname: *n\n"
+		if errs := NewValidator(schema).ValidateString(data).Collector.Errors(); len(errs) != 1 {
+			t.Fatalf("without ForbidAliases the alias is resolved and type-checked, got %v", errs)
+		}
+		errs := NewValidator(schema).ValidateWithOptions([]byte(data), opts).Collector.Errors()
+		var msgs []string
+		for _, err := range errs {
+			msgs = append(msgs, err.Path+": "+err.Message)
+		}
+		want := []string{"defaults.n: anchor &n is not allowed", "name: alias *n is not allowed"}
+		if !reflect.DeepEqual(msgs, want) {
+			t.Errorf("errors = %v, want %v", msgs, want)
+		}
+	})
+
+	t.Run("merge key", func(t *testing.T) {
+		data := "defaults: &d {host: example.com}\nserver:\n  <<: *d\n  port: 80\n"
+		if errs := NewValidator(schema).ValidateString(data).Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("merge should satisfy host without ForbidAliases, got %v", errs)
+		}
+		errs := NewValidator(schema).ValidateWithOptions([]byte(data), opts).Collector.Errors()
+		var msgs []string
+		for _, err := range errs {
+			msgs = append(msgs, err.Path+": "+err.Message)
+		}
+		want := []string{
+			"defaults: anchor &d is not allowed",
+			"server.<<: alias *d is not allowed",
+			`server.host: required field "host" is missing`,
+		}
+		if !reflect.DeepEqual(msgs, want) {
+			t.Errorf("errors = %v, want %v", msgs, want)
+		}
+	})
+}
+
+func TestAliasExpansionLimits(t *testing.T) {
+	// Each level holds ten aliases of the previous one: 10^6 leaves when expanded.
+	var sb strings.Builder
+	sb.WriteString("l0: &l0 [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n")
+	for i := 1; i <= 6; i++ {
+		fmt.Fprintf(&sb, "l%d: &l%d [", i, i)
+		for j := 0; j < 10; j++ {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "*l%d", i-1)
+		}
+		sb.WriteString("]\n")
+	}
+	data := []byte(sb.String())
+
+	anything := &FieldSchema{Type: TypeAny}
+	anything.ItemSchema = anything
+	anything.AdditionalProperties = anything
+
+	t.Run("alias expansions", func(t *testing.T) {
+		result := NewValidator(anything).ValidateWithOptions(data, ValidationContext{MaxAliasExpansions: 1000})
+		errs := result.Collector.Errors()
+		if len(errs) != 1 || !strings.HasPrefix(errs[0].Message, "alias expansion limit exceeded") {
+			t.Fatalf("expected alias expansion limit error, got %v", errs)
+		}
+	})
+
+	t.Run("nodes", func(t *testing.T) {
+		result := NewValidator(anything).ValidateWithOptions(data, ValidationContext{MaxNodes: 5000})
+		errs := result.Collector.Errors()
+		if len(errs) != 1 || !strings.HasPrefix(errs[0].Message, "node limit exceeded") {
+			t.Fatalf("expected node limit error, got %v", errs)
+		}
+	})
+
+	t.Run("merge keys", func(t *testing.T) {
+		merges := "a: &a {x: 1}\nb: {<<: *a}\nc: {<<: [*a, *a]}\n"
+		opts := ValidationContext{MaxAliasExpansions: 2}
+		errs := NewValidator(anything).ValidateWithOptions([]byte(merges), opts).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "c.<<" {
+			t.Fatalf("expected limit error at the second merge, got %v", errs)
+		}
+	})
+
+	t.Run("per document", func(t *testing.T) {
+		docs := "a: &a 1\nb: *a\n---\na: &a 1\nb: *a\n"
+		opts := ValidationContext{MaxAliasExpansions: 1}
+		if errs := NewValidator(anything).ValidateWithOptions([]byte(docs), opts).Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("expected counts to reset per document, got %v", errs)
+		}
+	})
+}
+
+func TestUniqueFields(t *testing.T) {
+	schema := &FieldSchema{
+		Type:         TypeSequence,
+		UniqueFields: []string{"host", "port"},
+	}
+	data := `
+- {host: a, port: 80}
+- {host: a, port: 443}
+- {host: b, port: 80}
+- {host: a, port: 80}
+- {host: a}
+- {host: a, port: null}
+- {host: a}
+- plain
+`
+	errs := NewValidator(schema).ValidateString(data).Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected one duplicate, got %v", errs)
+	}
+	if errs[0].Path != "[3]" || errs[0].Line != 5 ||
+		errs[0].Message != `duplicate (host, port) ("a", "80") (first defined at line 2)` {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+
+	schema.UniqueFields = []string{"name"}
+	data = "- name: web\n  port: 80\n- name: api\n- name: web\n  port: 81\n"
+	errs = NewValidator(schema).ValidateString(data).Collector.Errors()
+	if len(errs) != 1 || errs[0].Path != "[2]" || errs[0].Message != `duplicate name "web" (first defined at line 1)` {
+		t.Errorf("expected single-field duplicate at [2], got %v", errs)
+	}
+}
+
+func TestItemsNonNull(t *testing.T) {
+	schema := &FieldSchema{
+		Type:         TypeSequence,
+		ItemsNonNull: true,
+		ItemSchema:   &FieldSchema{Type: TypeString, Nullable: true},
+	}
+	data := "- a\n- null\n-\n- ~\n- b\n"
+	errs := NewValidator(schema).ValidateString(data).Collector.Errors()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 null item errors despite Nullable items, got %v", errs)
+	}
+	for i, want := range []string{"[1]", "[2]", "[3]"} {
+		if errs[i].Path != want || errs[i].Message != "null item not allowed" {
+			t.Errorf("error %d = %v, want null item at %s", i, errs[i], want)
+		}
+	}
+	if errs[0].Line != 2 || errs[0].Column != 3 {
+		t.Errorf("expected error at the item (2:3), got %d:%d", errs[0].Line, errs[0].Column)
+	}
+
+	// Without an item schema, and not reported twice for non-nullable items.
+	for _, items := range []*FieldSchema{nil, {Type: TypeString}} {
+		schema := &FieldSchema{Type: TypeSequence, ItemsNonNull: true, ItemSchema: items}
+		if errs := NewValidator(schema).ValidateString("- a\n- null\n").Collector.Errors(); len(errs) != 1 {
+			t.Errorf("expected one error with ItemSchema %v, got %v", items, errs)
+		}
+	}
+}
+
+func TestValidateAndFill(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"name": {Type: TypeString, Required: true},
+			"tags": {
+				Type:       TypeSequence,
+				ItemSchema: &FieldSchema{Type: TypeString},
+				Default:    []interface{}{"default"},
+			},
+			"labels": {
+				Type:                 TypeMap,
+				AdditionalProperties: &FieldSchema{Type: TypeString},
+				Default:              map[string]interface{}{"team": "core"},
+			},
+			"server": {
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"host": {Type: TypeString},
+					"port": {Type: TypeInt, Default: 8080},
+				},
+			},
+			"workers": {
+				Type: TypeSequence,
+				ItemSchema: &FieldSchema{
+					Type: TypeMap,
+					AllowedKeys: map[string]*FieldSchema{
+						"name":  {Type: TypeString},
+						"queue": {Type: TypeString, Default: "main"},
+					},
+				},
+			},
+		},
+	}
+
+	data := "name: app # the app\nworkers:\n  - name: a\n  - name: b\n    queue: slow\n"
+	result, filled, err := NewValidator(schema).ValidateAndFill([]byte(data), ValidationContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.HasErrors() || len(result.Collector.Warnings()) != 4 {
+		t.Errorf("expected default warnings for the original data, got %v", result.Collector.All())
+	}
+	want := `name: app # the app
+workers:
+  - name: a
+    queue: main
+  - name: b
+    queue: slow
+labels:
+  team: core
+server:
+  port: 8080
+tags:
+  - default
+`
+	if string(filled) != want {
+		t.Fatalf("filled output mismatch:\n got:\n%s\nwant:\n%s", filled, want)
+	}
+	if errs := NewValidator(schema).ValidateBytes(filled).Collector.All(); len(errs) != 0 {
+		t.Errorf("filled document should validate cleanly, got %v", errs)
+	}
+
+	// Present collections are not overwritten.
+	_, filled, err = NewValidator(schema).ValidateAndFill([]byte("name: app\ntags: []\nlabels: {a: b}\n"), ValidationContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(filled), "tags: []") || strings.Contains(string(filled), "team") {
+		t.Errorf("present fields must be kept, got:\n%s", filled)
+	}
+}
+
+func TestValidateSchema_CollectionDefaults(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"tags": {
+				Type:       TypeSequence,
+				ItemSchema: &FieldSchema{Type: TypeString},
+				MaxItems:   Ptr(1),
+				Default:    []interface{}{"a", "b"},
+			},
+			"labels": {
+				Type:                 TypeMap,
+				AdditionalProperties: &FieldSchema{Type: TypeInt},
+				Default:              map[string]interface{}{"team": "core"},
+			},
+		},
+	}
+	var msgs []string
+	for _, err := range ValidateSchema(schema) {
+		msgs = append(msgs, err.Path+": "+err.Message)
+	}
+	want := []string{"labels.team: invalid default: type mismatch", "tags: invalid default: too many items"}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("ValidateSchema = %v, want %v", msgs, want)
+	}
+}
+
+func TestEnumFromPathValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"activeProfile": {
+				Type:       TypeString,
+				Validators: []ValueValidator{valv.EnumFromPathValidator{Path: "profiles"}},
+			},
+			"profiles": {Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeAny}},
+			"shared":   {Type: TypeAny},
+		},
+	}
+	valid := "activeProfile: dev\nprofiles:\n  dev: {debug: true}\n  prod: {debug: false}\n"
+	if errs := NewValidator(schema).ValidateString(valid).Collector.Errors(); len(errs) != 0 {
+		t.Errorf("expected valid profile reference, got %v", errs)
+	}
+
+	invalid := "activeProfile: staging\nprofiles:\n  dev: {debug: true}\n  prod: {debug: false}\n"
+	errs := NewValidator(schema).ValidateString(invalid).Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+	if errs[0].Path != "activeProfile" || errs[0].Message != `"staging" is not a key of profiles` ||
+		errs[0].Expected != "one of [dev prod]" {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+
+	// Each document is resolved on its own.
+	docs := "activeProfile: dev\nprofiles: {dev: {}}\n---\nactiveProfile: dev\nprofiles: {prod: {}}\n"
+	errs = NewValidator(schema).ValidateString(docs).Collector.Errors()
+	if len(errs) != 1 || errs[0].Path != "doc[1].activeProfile" {
+		t.Errorf("expected error in the second document only, got %v", errs)
+	}
+
+	// Keys inherited via merge are both accepted and listed.
+	merged := "activeProfile: staging\nshared: &shared {base: {}}\nprofiles:\n  <<: *shared\n  dev: {}\n"
+	errs = NewValidator(schema).ValidateString(merged).Collector.Errors()
+	if len(errs) != 1 || errs[0].Expected != "one of [base dev]" {
+		t.Errorf("expected merged keys in Expected, got %v", errs)
+	}
+}
+
+func TestMessageTemplates(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator ValueValidator
+		yaml      string
+		want      string
+	}{
+		{"enum value", valv.EnumValidator{Allowed: []string{"a"}, Message: "{value} is not allowed"}, "field: b", "b is not allowed"},
+		{"enum path", valv.EnumValidator{Allowed: []string{"a"}, Message: "bad {path}"}, "field: b", "bad field"},
+		{"regex value", valv.RegexValidator{Pattern: regexp.MustCompile(`^\d+$`), Message: "{value} is not a number"}, "field: x1", "x1 is not a number"},
+		{"range min", valv.RangeValidator{Min: Ptr(1.5), Message: "{value} must be at least {min}"}, "field: 1", "1 must be at least 1.5"},
+		{"range max", valv.RangeValidator{Max: Ptr(10.0), Message: "{path} must be at most {max}"}, "field: 11", "field must be at most 10"},
+		{"intrange max", valv.IntRangeValidator{Max: Ptr[int64](5), Message: "at most {max}"}, "field: 6", "at most 5"},
+		{"length min and max", valv.LengthValidator{Min: Ptr(2), Max: Ptr(4), Message: "{value}: {min}-{max} chars"}, "field: abcde", "abcde: 2-4 chars"},
+		{"unset bound", valv.LengthValidator{Max: Ptr(1), Message: "[{min}, {max}]"}, "field: ab", "[, 1]"},
+		{"unknown placeholder", valv.EnumValidator{Allowed: []string{"a"}, Message: "{other} stays"}, "field: b", "{other} stays"},
+		{"no placeholders", valv.RegexValidator{Pattern: regexp.MustCompile(`^a$`), Message: "plain message"}, "field: b", "plain message"},
+		{"default message", valv.RangeValidator{Max: Ptr(10.0)}, "field: 11", "value above maximum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type:        TypeMap,
+				AllowedKeys: map[string]*FieldSchema{"field": {Type: TypeAny, Validators: []ValueValidator{tt.validator}}},
+			}
+			errs := NewValidator(schema).ValidateString(tt.yaml).Collector.Errors()
+			if len(errs) != 1 || errs[0].Message != tt.want {
+				t.Fatalf("expected message %q, got %v", tt.want, errs)
+			}
+		})
+	}
+}
+
+func TestMessagesLocalization(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"port":  {Type: TypeInt},
+			"mode":  {Type: TypeString, Validators: []ValueValidator{valv.EnumValidator{Allowed: []string{"fast", "slow"}}}},
+			"level": {Type: TypeString, Validators: []ValueValidator{valv.EnumValidator{Allowed: []string{"a"}, Message: "custom {value}"}}},
+			"name":  {Type: TypeString, Required: true},
+		},
+	}
+	opts := ValidationContext{Messages: map[string]string{
+		MsgTypeMismatch:     "type incorrect : {got} au lieu de {expected} ({path})",
+		MsgInvalidEnumValue: "valeur invalide {value}",
+	}}
+	yaml := "port: abc\nmode: medium\nlevel: b\n"
+	messages := map[string]string{}
+	for _, e := range NewValidator(schema).ValidateWithOptions([]byte(yaml), opts).Collector.Errors() {
+		messages[e.Path] = e.Message
+	}
+	want := map[string]string{
+		"port":  `type incorrect : str "abc" au lieu de integer (port)`,
+		"mode":  "valeur invalide medium",
+		"level": "custom b",                         // a validator's own Message wins
+		"name":  `required field "name" is missing`, // no override: English default
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("messages = %q, want %q", messages, want)
+	}
+}
+
+func TestMessagesLocalizationValidatorPackages(t *testing.T) {
+	schema := &FieldSchema{
+		Type:          TypeMap,
+		KeyValidators: []KeyValidator{keyv.ForbiddenKeyValidator{Forbidden: []string{"debug"}}},
+		MapValidators: []MapValidator{mapv.RequiredKeysValidator{Keys: []string{"owner"}}},
+		AllowedKeys: map[string]*FieldSchema{
+			"debug": {Type: TypeBool},
+			"url":   {Type: TypeString, Validators: []ValueValidator{valv.URLValidator{RequireScheme: true}}},
+			"color": {Type: TypeString, Validators: []ValueValidator{valv.HexColorValidator{}}},
+			"owner": {Type: TypeString},
+			"ports": {
+				Type:          TypeSequence,
+				ItemSchema:    &FieldSchema{Type: TypeInt},
+				SeqValidators: []SeqValidator{seqv.SortedValidator{Numeric: true}},
+			},
+		},
+	}
+	opts := ValidationContext{Messages: map[string]string{
+		MsgKeyForbidden:     "clé interdite {key}",
+		MsgRequiredMissing:  "le champ obligatoire {key} est absent",
+		MsgURLSchemeMissing: "schéma manquant dans {value}",
+		MsgNotSorted:        "{value} après {previous} ({order})",
+	}}
+	yaml := "debug: true\nurl: example.com\ncolor: red\nports: [80, 22]\n"
+	messages := map[string]string{}
+	for _, e := range NewValidator(schema).ValidateWithOptions([]byte(yaml), opts).Collector.Errors() {
+		messages[e.Path] = e.Message
+	}
+	want := map[string]string{
+		"debug":    "clé interdite debug",
+		"owner":    "le champ obligatoire owner est absent",
+		"url":      "schéma manquant dans example.com",
+		"ports[1]": "22 après 80 (ascending)",
+		"color":    "invalid hex color", // no override: English default
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("messages = %q, want %q", messages, want)
+	}
+}
+
+func TestStructuredGotExpected(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"port":     {Type: TypeInt},
+			"tags":     {Type: TypeString},
+			"name":     {Type: TypeString},
+			"replicas": {Type: TypeInt, Validators: []ValueValidator{valv.RangeValidator{Max: Ptr(10.0)}}},
+		},
+	}
+	errs := NewValidator(schema).ValidateString("port: abc\ntags: [a]\nname: ~\nreplicas: 0x10\n").Collector.Errors()
+	got := map[string]ValidationError{}
+	for _, e := range errs {
+		got[e.Path] = e
+	}
+
+	check := func(path string, gotType, expectedType NodeType, gotValue string) {
+		t.Helper()
+		e := got[path]
+		if e.GotType != gotType || e.ExpectedType != expectedType || e.GotValue != gotValue {
+			t.Errorf("%s: GotType=%v ExpectedType=%v GotValue=%q, want %v %v %q",
+				path, e.GotType, e.ExpectedType, e.GotValue, gotType, expectedType, gotValue)
+		}
+	}
+	check("port", TypeString, TypeInt, "abc")
+	check("tags", TypeSequence, TypeString, "")
+	check("name", TypeNull, TypeString, "~")
+	check("replicas", TypeAny, TypeAny, "0x10")
+	if got["port"].Got != `str "abc"` || got["port"].Expected != "integer" {
+		t.Errorf("display strings changed: %+v", got["port"])
+	}
+
+	data, err := json.Marshal(got["port"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"gotType":"string","expectedType":"integer","gotValue":"abc"`) {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+	var decoded ValidationError
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.GotType != TypeString || decoded.ExpectedType != TypeInt {
+		t.Errorf("JSON round trip: %+v, %v", decoded, err)
+	}
+	if data, _ := json.Marshal(got["replicas"]); strings.Contains(string(data), "gotType") {
+		t.Errorf("unset GotType should be omitted: %s", data)
+	}
+}
+
+func TestSingleLineValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type:                 TypeMap,
+		AdditionalProperties: &FieldSchema{Type: TypeString, Validators: []ValueValidator{valv.SingleLineValidator{}}},
+	}
+	yaml := "plain: one line\n" +
+		"quoted: \"a\\nb\"\n" +
+		"cr: \"a\\rb\"\n" +
+		"literal: |-\n  first\n  second\n" +
+		"stripped: |-\n  only\n" +
+		"folded: >\n  folded\n  text\n" +
+		"deep: |\n      indented\n" +
+		"blank: |\n\n  after\n"
+	checkFindings(t, NewValidator(schema).ValidateString(yaml).Collector.Errors(), []string{
+		"2:9 quoted: line break at offset 1, value must be a single line",
+		"3:5 cr: line break at offset 1, value must be a single line",
+		"5:3 literal: line break at offset 5, value must be a single line",
+		"9:9 folded: line break at offset 11, value must be a single line",
+		"13:7 deep: line break at offset 8, value must be a single line",
+		"15:1 blank: line break at offset 0, value must be a single line",
+	})
+}
+
+// checkFindings fails the test unless errs, each formatted as
+// "line:column path: message", are exactly want.
+func checkFindings(t *testing.T, errs []ValidationError, want []string) {
+	t.Helper()
+	got := make([]string, 0, len(errs))
+	for _, e := range errs {
+		got = append(got, fmt.Sprintf("%d:%d %s: %s", e.Line, e.Column, e.Path, e.Message))
+	}
+	if len(want) == 0 {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+}
+
+func TestMaxNesting(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"config": {Type: TypeMap, MaxNesting: Ptr(2)},
+		},
+	}
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{"scalars only", "config: {a: 1, b: 2}\n", nil},
+		{"at the limit", "config:\n  a: {b: 1}\n  c: [1, 2]\n", nil},
+		{"map too deep", "config:\n  a: {b: 1}\n  c:\n    d:\n      e: 1\n", []string{"5:7 config.c.d: nested 3 levels deep, at most 2 allowed"}},
+		{"sequence too deep", "config:\n  list:\n    - [1]\n", []string{"3:7 config.list[0]: nested 3 levels deep, at most 2 allowed"}},
+		{"merged values count at the merging level", "base: &b {x: 1}\nconfig:\n  a:\n    <<: *b\n", nil},
+		{"merged mapping nesting counts", "base: &b {x: {y: 1}}\nconfig:\n  a:\n    <<: *b\n", []string{"1:14 config.a.x: nested 3 levels deep, at most 2 allowed"}},
+		{"alias cycle", "config: &c\n  a: *c\n", []string{"1:9 config.a.a: nested 3 levels deep, at most 2 allowed"}},
+		{"merge cycle", "config: &c\n  <<: *c\n  a: 1\n", nil},
+	}
+	schema.AllowedKeys["base"] = &FieldSchema{Type: TypeAny}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFindings(t, NewValidator(schema).ValidateString(tt.yaml).Collector.Errors(), tt.want)
+		})
+	}
+}
+
+func TestASCIIValidator(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator valv.ASCIIValidator
+		value     string
+		want      []string
+	}{
+		{"plain ASCII", valv.ASCIIValidator{}, "hello-world_1", nil},
+		{"accented", valv.ASCIIValidator{}, "café", []string{`1:7 name: non-ASCII character 'é' (U+00E9) at byte 3`}},
+		{"emoji", valv.ASCIIValidator{}, "ok 👍 é", []string{`1:7 name: non-ASCII character '👍' (U+1F44D) at byte 3`}},
+		{"accented with extended", valv.ASCIIValidator{AllowExtended: true}, "café crème", nil},
+		{"emoji with extended", valv.ASCIIValidator{AllowExtended: true}, "café 👍", []string{`1:7 name: non-Latin-1 character '👍' (U+1F44D) at byte 6`}},
+		{"custom message", valv.ASCIIValidator{Message: "ASCII only"}, "ü", []string{"1:7 name: ASCII only"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type:        TypeMap,
+				AllowedKeys: map[string]*FieldSchema{"name": {Type: TypeString, Validators: []ValueValidator{tt.validator}}},
+			}
+			checkFindings(t, NewValidator(schema).ValidateString("name: "+strconv.Quote(tt.value)).Collector.Errors(), tt.want)
+		})
+	}
+}
+
+func TestAlphabeticalKeysValidator(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator mapv.AlphabeticalKeysValidator
+		yaml      string
+		want      []string
+	}{
+		{"sorted", mapv.AlphabeticalKeysValidator{}, "alpha: 1\nbeta: 2\ngamma: 3\n", nil},
+		{"unsorted", mapv.AlphabeticalKeysValidator{}, "alpha: 1\ngamma: 3\nbeta: 2\ndelta: 4\n",
+			[]string{`3:1 beta: key "beta" is out of order, it should come before "gamma"`}},
+		{"byte order puts upper case first", mapv.AlphabeticalKeysValidator{}, "Zeta: 1\nalpha: 2\n", nil},
+		{"case-sensitive mismatch", mapv.AlphabeticalKeysValidator{}, "alpha: 1\nBeta: 2\n",
+			[]string{`2:1 Beta: key "Beta" is out of order, it should come before "alpha"`}},
+		{"case-insensitive", mapv.AlphabeticalKeysValidator{CaseInsensitive: true}, "alpha: 1\nBeta: 2\ngamma: 3\n", nil},
+		{"case-insensitive unsorted", mapv.AlphabeticalKeysValidator{CaseInsensitive: true}, "Zeta: 1\nalpha: 2\n",
+			[]string{`2:1 alpha: key "alpha" is out of order, it should come before "Zeta"`}},
+		{"merge key ignored", mapv.AlphabeticalKeysValidator{}, "beta: 2\n<<: {x: 1}\ngamma: 3\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type:                 TypeMap,
+				AdditionalProperties: &FieldSchema{Type: TypeAny},
+				MapValidators:        []MapValidator{tt.validator},
+			}
+			checkFindings(t, NewValidator(schema).ValidateString(tt.yaml).Collector.Errors(), tt.want)
+		})
+	}
+}
+
+func TestEnumKeyValidator(t *testing.T) {
+	tests := []struct {
+		name            string
+		caseInsensitive bool
+		yaml            string
+		wantGot         []string
+	}{
+		{name: "allowed keys", yaml: "dev: 1\nprod: 2\n"},
+		{name: "disallowed key", yaml: "dev: 1\nqa: 2\n", wantGot: []string{"qa"}},
+		{name: "case differs", yaml: "Dev: 1\nPROD: 2\n", wantGot: []string{"Dev", "PROD"}},
+		{name: "case-insensitive", caseInsensitive: true, yaml: "Dev: 1\nPROD: 2\n"},
+		{name: "case-insensitive still rejects", caseInsensitive: true, yaml: "Staging: 1\n", wantGot: []string{"Staging"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type:                 TypeMap,
+				AdditionalProperties: &FieldSchema{Type: TypeInt},
+				KeyValidators: []KeyValidator{
+					keyv.EnumKeyValidator{Allowed: []string{"dev", "prod"}, CaseInsensitive: tt.caseInsensitive},
+				},
+			}
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			var got []string
+			for _, e := range result.Collector.Errors() {
+				got = append(got, e.Got)
+				if e.Expected != "one of [dev prod]" {
+					t.Errorf("Expected = %q", e.Expected)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantGot) {
+				t.Errorf("rejected keys %v, want %v", got, tt.wantGot)
+			}
+		})
+	}
+}
+
+func TestRequireWhenValueValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type:                 TypeMap,
+		AdditionalProperties: &FieldSchema{Type: TypeAny, Nullable: true},
+		MapValidators: []MapValidator{
+			mapv.RequireWhenValueValidator{Field: "auth", Value: "token", Require: []string{"token"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		wantMsgs []string
+	}{
+		{name: "trigger absent", yaml: "host: a\n"},
+		{name: "other value", yaml: "auth: basic\n"},
+		{name: "requirement met", yaml: "auth: token\ntoken: s3cr3t\n"},
+		{name: "missing", yaml: "auth: token\n", wantMsgs: []string{`token:1: field "token" is required when "auth" is "token"`}},
+		{name: "null", yaml: "auth: token\ntoken: ~\n", wantMsgs: []string{`token:2: field "token" must not be empty when "auth" is "token"`}},
+		{name: "empty string", yaml: "auth: token\ntoken: \"\"\n", wantMsgs: []string{`token:2: field "token" must not be empty when "auth" is "token"`}},
+		{name: "trigger merged", yaml: "<<: {auth: token}\ntoken: t\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			var got []string
+			for _, e := range result.Collector.Errors() {
+				got = append(got, fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Message))
+			}
+			if !reflect.DeepEqual(got, tt.wantMsgs) {
+				t.Errorf("got %q, want %q", got, tt.wantMsgs)
+			}
+		})
+	}
+}
+
+func TestBestEffort(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"db": {
+				Type: TypeMap,
+				AllowedKeys: map[string]*FieldSchema{
+					"host": {Type: TypeString, Required: true},
+					"port": {Type: TypeInt},
+				},
+				Validators: []ValueValidator{valv.EnumValidator{Allowed: []string{"none"}}},
+			},
+			"ports": {Type: TypeSequence, ItemSchema: &FieldSchema{Type: TypeInt}},
+			"name":  {Type: TypeString, AllowedKeys: map[string]*FieldSchema{"x": {Type: TypeInt}}},
+		},
+	}
+	data := []byte("db: !Conn {port: abc}\nports: !Conn [1, x]\nname: {x: y}\n")
+	customTags := map[string]NodeType{"!Conn": TypeString}
+
+	messages := func(best bool) []string {
+		result := NewValidator(schema).ValidateWithOptions(data, ValidationContext{CustomTags: customTags, BestEffort: best})
+		var got []string
+		for _, e := range result.Collector.Errors() {
+			got = append(got, e.Path+": "+e.Message)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	want := []string{"db: type mismatch", "name: type mismatch", "ports: type mismatch"}
+	if got := messages(false); !reflect.DeepEqual(got, want) {
+		t.Errorf("default: got %q, want %q", got, want)
+	}
+
+	// The string field's AllowedKeys describe children, so name is descended
+	// into too; db's EnumValidator, which would reject it, is not run.
+	want = []string{
+		`db.host: required field "host" is missing`,
+		"db.port: type mismatch",
+		"db: type mismatch",
+		"name.x: type mismatch",
+		"name: type mismatch",
+		"ports: type mismatch",
+		"ports[1]: type mismatch",
+	}
+	if got := messages(true); !reflect.DeepEqual(got, want) {
+		t.Errorf("best effort: got %q, want %q", got, want)
+	}
+}
+
+func TestSemVerRangeValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"range": {Type: TypeString, Validators: []ValueValidator{valv.SemVerRangeValidator{}}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{name: "exact", yaml: `1.2.3`},
+		{name: "caret", yaml: `^1.2`},
+		{name: "caret zero major", yaml: `^0.2.3`},
+		{name: "tilde", yaml: `~1.2.3`},
+		{name: "tilde partial", yaml: `~1`},
+		{name: "comparators", yaml: `">=1.2.0 <2.0.0"`},
+		{name: "spaced operator", yaml: `">= 1.2.0 < 2"`},
+		{name: "wildcards", yaml: `1.2.x`},
+		{name: "star", yaml: `"*"`},
+		{name: "v prefix", yaml: `v1.2.3`},
+		{name: "hyphen range", yaml: `1.0.0 - 2.0.0`},
+		{name: "alternatives", yaml: `^1.0 || ^2.0 || >=3.1.0-rc.1`},
+		{name: "build metadata", yaml: `=1.2.3+build.5`},
+		{name: "bad comparator", yaml: `">=1.2.0 <2.a"`, want: []string{`1:8 range: invalid semver range: cannot parse "<2.a": "a" is not a version number`}},
+		{name: "four numbers", yaml: `^1.2.3.4`, want: []string{`1:8 range: invalid semver range: cannot parse "^1.2.3.4": more than three version numbers`}},
+		{name: "number after wildcard", yaml: `1.x.3`, want: []string{`1:8 range: invalid semver range: cannot parse "1.x.3": number "3" after a wildcard`}},
+		{name: "leading zero", yaml: `~01.2`, want: []string{`1:8 range: invalid semver range: cannot parse "~01.2": "01" is not a version number`}},
+		{name: "suffix on partial", yaml: `^1.2-beta`, want: []string{`1:8 range: invalid semver range: cannot parse "^1.2-beta": pre-release or build suffix needs a full version`}},
+		{name: "dangling operator", yaml: `">="`, want: []string{`1:8 range: invalid semver range: cannot parse ">=": operator without a version`}},
+		{name: "unknown operator", yaml: `~>1.2`, want: []string{`1:8 range: invalid semver range: cannot parse "~>1.2": unknown operator "~>"`}},
+		{name: "reversed operator", yaml: `"=>1.0"`, want: []string{`1:8 range: invalid semver range: cannot parse "=>1.0": unknown operator "=>"`}},
+		{name: "empty alternative", yaml: `"^1.0 ||"`, want: []string{`1:8 range: invalid semver range: empty alternative in "^1.0 ||"`}},
+		{name: "empty", yaml: `""`, want: []string{`1:8 range: invalid semver range: empty range`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFindings(t, NewValidator(schema).ValidateString("range: "+tt.yaml).Collector.Errors(), tt.want)
+		})
+	}
+}
+
+func TestIntWidthValidator(t *testing.T) {
+	tests := []struct {
+		name string
+		vld  valv.IntWidthValidator
+		yaml string
+		want []string
+	}{
+		{name: "uint8 min", vld: valv.IntWidthValidator{Bits: 8}, yaml: "0"},
+		{name: "uint8 max", vld: valv.IntWidthValidator{Bits: 8}, yaml: "255"},
+		{name: "uint8 hex max", vld: valv.IntWidthValidator{Bits: 8}, yaml: "0xFF"},
+		{name: "uint8 overflow", vld: valv.IntWidthValidator{Bits: 8}, yaml: "256", want: []string{"1:8 width: value 256 does not fit in uint8 (0 to 255)"}},
+		{name: "uint8 negative", vld: valv.IntWidthValidator{Bits: 8}, yaml: "-1", want: []string{"1:8 width: value -1 does not fit in uint8 (0 to 255)"}},
+		{name: "int16 min", vld: valv.IntWidthValidator{Bits: 16, Signed: true}, yaml: "-32768"},
+		{name: "int16 max", vld: valv.IntWidthValidator{Bits: 16, Signed: true}, yaml: "32767"},
+		{name: "int16 underflow", vld: valv.IntWidthValidator{Bits: 16, Signed: true}, yaml: "-32769", want: []string{"1:8 width: value -32769 does not fit in int16 (-32768 to 32767)"}},
+		{name: "int16 overflow", vld: valv.IntWidthValidator{Bits: 16, Signed: true}, yaml: "32768", want: []string{"1:8 width: value 32768 does not fit in int16 (-32768 to 32767)"}},
+		{name: "uint64 max", vld: valv.IntWidthValidator{Bits: 64}, yaml: "18446744073709551615"},
+		{name: "uint64 overflow", vld: valv.IntWidthValidator{Bits: 64}, yaml: "18446744073709551616", want: []string{"1:8 width: value 18446744073709551616 does not fit in uint64 (0 to 18446744073709551615)"}},
+		{name: "int64 min", vld: valv.IntWidthValidator{Bits: 64, Signed: true}, yaml: "-9223372036854775808"},
+		{name: "not an integer", vld: valv.IntWidthValidator{Bits: 8}, yaml: "1.5", want: []string{"1:8 width: expected integer value"}},
+		{name: "custom message", vld: valv.IntWidthValidator{Bits: 4, Message: "{value} is not in {min}..{max}"}, yaml: "16", want: []string{"1:8 width: 16 is not in 0..15"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type:        TypeMap,
+				AllowedKeys: map[string]*FieldSchema{"width": {Type: TypeAny, Validators: []ValueValidator{tt.vld}}},
+			}
+			checkFindings(t, NewValidator(schema).ValidateString("width: "+tt.yaml).Collector.Errors(), tt.want)
+		})
+	}
+}
+
+func TestRawByteChecks(t *testing.T) {
+	schema := &FieldSchema{Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeString}}
+	opts := ValidationContext{RequireFinalNewline: true, ForbidBOM: true}
+
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{name: "clean", data: "a: b\n"},
+		{name: "empty input", data: ""},
+		{name: "missing newline", data: "a: b\nc: d", want: []string{"warning 2:5 file does not end with a newline"}},
+		{name: "missing newline after non-ASCII", data: "a: b\nname: café ✓", want: []string{"warning 2:13 file does not end with a newline"}},
+		{name: "utf-8 bom", data: "\xEF\xBB\xBFa: b\n", want: []string{"error 1:1 file starts with a UTF-8 byte order mark"}},
+		{name: "both", data: "\xEF\xBB\xBFa: b", want: []string{
+			"error 1:1 file starts with a UTF-8 byte order mark",
+			"warning 1:5 file does not end with a newline",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateWithOptions([]byte(tt.data), opts)
+			var got []string
+			for _, e := range result.Collector.All() {
+				got = append(got, fmt.Sprintf("%s %d:%d %s", strings.ToLower(e.Level.String()), e.Line, e.Column, e.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if result := NewValidator(schema).ValidateString("\xEF\xBB\xBFa: b"); len(result.Collector.All()) != 0 {
+		t.Errorf("checks are off by default, got %v", result.Collector.All())
+	}
+}
+
+func TestPathEscaping(t *testing.T) {
+	src := `
+metadata:
+  my.key: 1
+  my:
+    key: 2
+  "a[0]": 3
+  "say \"hi\"": 4
+  "*": 5
+  "": 6
+items:
+  - "x.y": 7
+`
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AdditionalProperties: &FieldSchema{
+			Type:                 TypeAny,
+			AdditionalProperties: &FieldSchema{Type: TypeString},
+			ItemSchema:           &FieldSchema{Type: TypeMap, AdditionalProperties: &FieldSchema{Type: TypeString}},
+		},
+	}
+	var got []string
+	for _, e := range NewValidator(schema).ValidateString(src).Collector.Errors() {
+		got = append(got, e.Path)
+	}
+	sort.Strings(got)
+	want := []string{
+		`items[0]["x.y"]`,
+		`metadata.my`,
+		`metadata[""]`,
+		`metadata["*"]`,
+		`metadata["a[0]"]`,
+		`metadata["my.key"]`,
+		`metadata["say \"hi\""]`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %q, want %q", got, want)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Content[0]
+	for _, path := range want {
+		matches, err := SelectPath(root, path)
+		if err != nil {
+			t.Errorf("SelectPath(%q): %v", path, err)
+			continue
+		}
+		if len(matches) != 1 || matches[0].Path != path {
+			t.Errorf("SelectPath(%q) = %v, want the node itself", path, matches)
+		}
+	}
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{`metadata.my.key`, []string{"metadata.my.key"}},
+		{`metadata["my"].key`, []string{"metadata.my.key"}},
+		{`metadata["*"]`, []string{`metadata["*"]`}},
+		{`["metadata"]["a[0]"]`, []string{`metadata["a[0]"]`}},
+		{`items[*]["x.y"]`, []string{`items[0]["x.y"]`}},
+	}
+	for _, tt := range tests {
+		matches, err := SelectPath(root, tt.selector)
+		if err != nil {
+			t.Errorf("SelectPath(%q): %v", tt.selector, err)
+			continue
+		}
+		var paths []string
+		for _, m := range matches {
+			paths = append(paths, m.Path)
+		}
+		if !reflect.DeepEqual(paths, tt.want) {
+			t.Errorf("SelectPath(%q) = %q, want %q", tt.selector, paths, tt.want)
+		}
+	}
+
+	for _, selector := range []string{`metadata["my.key`, `metadata["a"x]`, `metadata["a"]x`} {
+		if _, err := SelectPath(root, selector); err == nil {
+			t.Errorf("SelectPath(%q): expected an error", selector)
+		}
+	}
+
+	if got := JoinPath("metadata", "my.key"); got != `metadata["my.key"]` {
+		t.Errorf("JoinPath = %q", got)
+	}
+}
+
+func TestNumericKeys(t *testing.T) {
+	schema := func(numeric bool) *FieldSchema {
+		return &FieldSchema{
+			Type:        TypeMap,
+			NumericKeys: numeric,
+			AllowedKeys: map[string]*FieldSchema{
+				"80":  {Type: TypeString, Required: true},
+				"443": {Type: TypeString},
+			},
+			UnknownKeyPolicy: UnknownKeyError,
+		}
+	}
+
+	t.Run("integer key matches its string spelling", func(t *testing.T) {
+		// A plain integer key has Value "80", so it matches without NumericKeys.
+		if all := NewValidator(schema(false)).ValidateBytes([]byte("80: http\n\"443\": https\n")).Collector.All(); len(all) != 0 {
+			t.Fatalf("expected nothing reported, got %v", all)
+		}
+	})
+
+	t.Run("other spellings need NumericKeys", func(t *testing.T) {
+		doc := []byte("0x50: http\n+443: https\n")
+		errs := NewValidator(schema(false)).ValidateBytes(doc).Collector.Errors()
+		if len(errs) != 3 {
+			t.Fatalf("expected two unknown keys and a missing required key, got %v", errs)
+		}
+		if all := NewValidator(schema(true)).ValidateBytes(doc).Collector.All(); len(all) != 0 {
+			t.Fatalf("expected nothing reported, got %v", all)
+		}
+	})
+
+	t.Run("value validated at the schema path", func(t *testing.T) {
+		errs := NewValidator(schema(true)).ValidateBytes([]byte("0o120: [1]\n")).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != "80" || errs[0].Message != "type mismatch" {
+			t.Fatalf("expected type mismatch at 80, got %v", errs)
+		}
+	})
+
+	t.Run("quoted keys are strings", func(t *testing.T) {
+		errs := NewValidator(schema(true)).ValidateBytes([]byte("\"0x50\": http\n80: http\n")).Collector.Errors()
+		if len(errs) != 1 || errs[0].Path != `0x50` || !strings.Contains(errs[0].Message, "unknown key") {
+			t.Fatalf("expected unknown key 0x50, got %v", errs)
+		}
+	})
+
+	t.Run("quoted keys do not stand in for a default", func(t *testing.T) {
+		filled := &FieldSchema{
+			Type:                 TypeMap,
+			NumericKeys:          true,
+			AllowedKeys:          map[string]*FieldSchema{"80": {Type: TypeString, Default: "http"}},
+			AdditionalProperties: &FieldSchema{Type: TypeString},
+		}
+		for doc, want := range map[string]string{
+			"0x50: web\n":     "0x50: web\n",
+			"\"0x50\": web\n": "\"0x50\": web\n\"80\": http\n",
+		} {
+			_, out, err := NewValidator(filled).ValidateAndFill([]byte(doc), ValidationContext{})
+			if err != nil || string(out) != want {
+				t.Errorf("ValidateAndFill(%q) = %q, %v, want %q", doc, out, err, want)
+			}
+		}
+	})
+
+	t.Run("two spellings of one key", func(t *testing.T) {
+		errs := NewValidator(schema(true)).ValidateBytes([]byte("80: a\n0x50: b\n")).Collector.Errors()
+		if len(errs) != 1 || !strings.Contains(errs[0].Message, "both set field") {
+			t.Fatalf("expected conflict error, got %v", errs)
+		}
+	})
+
+	t.Run("collision guard", func(t *testing.T) {
+		colliding := schema(true)
+		colliding.AllowedKeys["0x50"] = &FieldSchema{Type: TypeString}
+		errs := ValidateSchema(colliding)
+		if len(errs) != 1 || !strings.Contains(errs[0].Message, "same integer") {
+			t.Fatalf("expected collision error, got %v", errs)
+		}
+		if errs := ValidateSchema(schema(true)); len(errs) != 0 {
+			t.Fatalf("expected no schema errors, got %v", errs)
+		}
+	})
+}
+
+func TestFieldsDifferValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeSequence,
+		ItemSchema: &FieldSchema{
+			Type:                 TypeMap,
+			AdditionalProperties: &FieldSchema{Type: TypeAny},
+			MapValidators: []MapValidator{
+				mapv.FieldsDifferValidator{Left: "from", Right: "to"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		wantMsgs []string
+	}{
+		{name: "different", yaml: "- {from: a, to: b}\n"},
+		{name: "field missing", yaml: "- {from: a}\n"},
+		{name: "not scalars", yaml: "- {from: [a], to: [a]}\n"},
+		{name: "equal", yaml: "- {from: a, to: b}\n- {from: c, to: c}\n", wantMsgs: []string{`[1].to:2: "to" must differ from "from"`}},
+		{name: "equal via alias", yaml: "- {from: &n a, to: *n}\n", wantMsgs: []string{`[0].to:1: "to" must differ from "from"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator(schema).ValidateBytes([]byte(tt.yaml))
+			var got []string
+			for _, e := range result.Collector.Errors() {
+				got = append(got, fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Message))
+			}
+			if !reflect.DeepEqual(got, tt.wantMsgs) {
+				t.Errorf("got %q, want %q", got, tt.wantMsgs)
+			}
+		})
+	}
+}
+
+func TestRequireStyle(t *testing.T) {
+	errLevel := LevelError
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"labels": {Type: TypeMap, RequireStyle: StyleBlock, AdditionalProperties: &FieldSchema{Type: TypeString}},
+			"ports":  {Type: TypeSequence, RequireStyle: StyleFlow, RequireStyleLevel: &errLevel, ItemSchema: &FieldSchema{Type: TypeInt}},
+			"name":   {Type: TypeString, RequireStyle: StyleBlock},
+		},
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{name: "matching styles", yaml: "name: a\nlabels:\n  app: web\nports: [80, 443]\n"},
+		{name: "empty collections exempt", yaml: "labels: {}\nports: []\n"},
+		{name: "flow mapping", yaml: "labels: {app: web}\n", want: []string{"WARNING labels:1: mapping must be written in block style"}},
+		{name: "block sequence", yaml: "ports:\n  - 80\n", want: []string{"ERROR ports:2: sequence must be written in flow style"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.AllSorted() {
+				got = append(got, fmt.Sprintf("%s %s:%d: %s", e.Level, e.Path, e.Line, e.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnumValidator_Descriptions(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeString,
+		Validators: []ValueValidator{
+			valv.EnumValidator{
+				Allowed:      []string{"v1", "v1beta1", "v2"},
+				Descriptions: map[string]string{"v1": "stable", "v1beta1": "deprecated"},
+			},
+		},
+	}
+
+	errs := NewValidator(schema).ValidateBytes([]byte("v3\n")).Collector.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+	if want := "one of: v1 (stable), v1beta1 (deprecated), v2"; errs[0].Expected != want {
+		t.Errorf("Expected = %q, want %q", errs[0].Expected, want)
+	}
+	if errs := NewValidator(schema).ValidateBytes([]byte("v1beta1\n")).Collector.Errors(); len(errs) != 0 {
+		t.Errorf("expected described value to be accepted, got %v", errs)
+	}
+}
+
+func TestWarnRedundantDefaults(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"port":    {Type: TypeInt, Default: 8080},
+			"mode":    {Type: TypeString, Default: "fast"},
+			"tags":    {Type: TypeSequence, Default: []string{"a"}, ItemSchema: &FieldSchema{Type: TypeString}},
+			"name":    {Type: TypeString, Required: true},
+			"timeout": {Type: TypeFloat, Default: 1.5},
+		},
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{name: "different values", yaml: "name: a\nport: 80\nmode: slow\ntags: [b]\ntimeout: 2\n"},
+		{name: "int default", yaml: "name: a\nport: 8080\nmode: slow\ntags: [b]\ntimeout: 2\n", want: []string{"port:2"}},
+		{name: "hex spelling", yaml: "name: a\nport: 0x1F90\nmode: slow\ntags: [b]\ntimeout: 2\n", want: []string{"port:2"}},
+		{name: "string and list defaults", yaml: "name: a\nport: 80\nmode: fast\ntags: [a]\ntimeout: 1.5\n", want: []string{"mode:3", "tags:4", "timeout:5"}},
+		{name: "quoted number is not the int default", yaml: "name: a\nport: \"8080\"\nmode: slow\ntags: [b]\ntimeout: 2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			res := NewValidator(schema).ValidateWithOptions([]byte(tt.yaml), ValidationContext{WarnRedundantDefaults: true})
+			for _, w := range res.Collector.AllSorted() {
+				if strings.Contains(w.Message, "can be omitted") {
+					got = append(got, fmt.Sprintf("%s:%d", w.Path, w.Line))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes([]byte("name: a\nport: 8080\nmode: fast\ntags: [a]\ntimeout: 1.5\n"))
+		if all := res.Collector.All(); len(all) != 0 {
+			t.Fatalf("expected nothing reported, got %v", all)
+		}
+	})
+}
+
+func TestGoTemplateValidator(t *testing.T) {
+	tests := []struct {
+		name string
+		vld  valv.GoTemplateValidator
+		yaml string
+		want []string
+	}{
+		{name: "plain text", yaml: `hello`},
+		{name: "field", yaml: `"Hello {{ .Name }}"`},
+		{name: "control flow", yaml: `"{{ range .Items }}{{ . }},{{ end }}"`},
+		{name: "builtin function", yaml: `"{{ printf \"%d\" .Port }}"`},
+		{name: "unclosed action", yaml: `"{{ .Name "`, want: []string{`1:7 tmpl: invalid Go template: line 1: unclosed action`}},
+		{name: "missing end", yaml: `"{{ if .Debug }}on"`, want: []string{`1:7 tmpl: invalid Go template: line 1: unexpected EOF`}},
+		{name: "stray end on second line", yaml: "|\n  a\n  {{ end }}\n", want: []string{`1:7 tmpl: invalid Go template: line 2: unexpected {{end}}`}},
+		{name: "unknown function", yaml: `"{{ upper .Name }}"`, want: []string{`1:7 tmpl: invalid Go template: line 1: function "upper" not defined`}},
+		{name: "extra function", vld: valv.GoTemplateValidator{Funcs: []string{"upper"}}, yaml: `"{{ upper .Name }}"`},
+		{name: "actions required", vld: valv.GoTemplateValidator{RequireActions: true}, yaml: `hello`, want: []string{`1:7 tmpl: value must contain a template action ({{ ... }})`}},
+		{name: "actions required and present", vld: valv.GoTemplateValidator{RequireActions: true}, yaml: `"{{ .A }}"`},
+		{name: "actions forbidden", vld: valv.GoTemplateValidator{ForbidActions: true}, yaml: `"x {{ .A }}"`, want: []string{`1:7 tmpl: value must not contain template actions`}},
+		{name: "actions forbidden, plain", vld: valv.GoTemplateValidator{ForbidActions: true}, yaml: `hello`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &FieldSchema{
+				Type:        TypeMap,
+				AllowedKeys: map[string]*FieldSchema{"tmpl": {Type: TypeString, Validators: []ValueValidator{tt.vld}}},
+			}
+			checkFindings(t, NewValidator(schema).ValidateString("tmpl: "+tt.yaml).Collector.Errors(), tt.want)
+		})
+	}
+}
+
+func TestGlobValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type:       TypeString,
+		Validators: []ValueValidator{valv.GlobValidator{}},
+	}
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{name: "literal", yaml: `config.yaml`},
+		{name: "star", yaml: `"*.yaml"`},
+		{name: "class", yaml: `"logs/[0-9]*"`},
+		{name: "negated class", yaml: `"[^.]*"`},
+		{name: "escape", yaml: `'\*'`},
+		{name: "double star is literal", yaml: `"**/*.go"`},
+		{name: "unterminated class", yaml: `"[a-z"`, wantErr: true},
+		{name: "unterminated after match", yaml: `"src/*.go["`, wantErr: true},
+		{name: "empty class", yaml: `"[]a]"`, wantErr: true},
+		{name: "trailing backslash", yaml: `'a\'`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if tt.wantErr {
+				if len(errs) != 1 || errs[0].Message != "invalid glob pattern" {
+					t.Fatalf("expected invalid glob pattern, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("expected no errors, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestJSONPointerValidator(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"pointer": {Type: TypeString, Validators: []ValueValidator{valv.JSONPointerValidator{}}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{name: "whole document", yaml: `""`},
+		{name: "root key", yaml: `/`},
+		{name: "nested", yaml: `/a/b/0`},
+		{name: "escapes", yaml: `/paths/~1users~1{id}/get/~0tmp`},
+		{name: "empty tokens", yaml: `//a/`},
+		{name: "missing leading slash", yaml: `a/b`, want: []string{`1:10 pointer: invalid JSON pointer: must start with "/"`}},
+		{name: "fragment form", yaml: `"#/a"`, want: []string{`1:10 pointer: invalid JSON pointer: must start with "/"`}},
+		{name: "bad escape", yaml: `/a/b~2c`, want: []string{`1:10 pointer: invalid JSON pointer: token "b~2c": "~" must be followed by 0 or 1`}},
+		{name: "trailing tilde", yaml: `/a~`, want: []string{`1:10 pointer: invalid JSON pointer: token "a~": "~" must be followed by 0 or 1`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFindings(t, NewValidator(schema).ValidateString("pointer: "+tt.yaml).Collector.Errors(), tt.want)
+		})
+	}
+}
+
+func TestAtLeastNOf(t *testing.T) {
+	contact := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"email":   {Type: TypeString, Aliases: []string{"mail"}},
+			"phone":   {Type: TypeString},
+			"address": {Type: TypeString},
+			"name":    {Type: TypeString},
+		},
+		AtLeastNOf: []KeyCount{{Keys: []string{"email", "phone", "address"}, N: 2}},
+	}
+	schema := &FieldSchema{Type: TypeMap, AllowedKeys: map[string]*FieldSchema{"contact": contact}}
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{name: "at threshold", yaml: "contact:\n  email: a\n  phone: b\n"},
+		{name: "above threshold", yaml: "contact:\n  email: a\n  phone: b\n  address: c\n"},
+		{name: "alias counts as its key", yaml: "contact:\n  mail: a\n  phone: b\n"},
+		{name: "below threshold", yaml: "contact:\n  name: x\n  address: c\n",
+			want: []string{"2:3 contact: at least 2 of [email phone address] are required, found: [address]"}},
+		{name: "none", yaml: "contact:\n  name: x\n",
+			want: []string{"2:3 contact: at least 2 of [email phone address] are required, found: []"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFindings(t, NewValidator(schema).ValidateString(tt.yaml).Collector.Errors(), tt.want)
+		})
+	}
+
+	t.Run("schema check", func(t *testing.T) {
+		bad := &FieldSchema{Type: TypeMap, AtLeastNOf: []KeyCount{{Keys: []string{"a", "b"}, N: 3}}}
+		errs := ValidateSchema(bad)
+		if len(errs) != 1 || errs[0].Message != "AtLeastNOf: N must be between 1 and 2 (the number of keys), got 3" {
+			t.Fatalf("expected count error, got %v", errs)
+		}
+
+		unknown := &FieldSchema{
+			Type:        TypeMap,
+			AllowedKeys: contact.AllowedKeys,
+			AtLeastNOf:  []KeyCount{{Keys: []string{"mail", "phone", "fax"}, N: 1}},
+			AtMostNOf:   []KeyCount{{Keys: []string{"email", "pager"}, N: 1}},
+		}
+		wrapped := &FieldSchema{Type: TypeMap, AllowedKeys: map[string]*FieldSchema{"contact": unknown}}
+		checkFindings(t, ValidateSchema(wrapped), []string{
+			`0:0 contact: AtLeastNOf: key "mail" is an alias of "email", name the allowed key`,
+			`0:0 contact: AtLeastNOf: key "fax" is not an allowed key`,
+			`0:0 contact: AtMostNOf: key "pager" is not an allowed key`,
+		})
+
+		unknown.AdditionalProperties = &FieldSchema{Type: TypeString}
+		checkFindings(t, ValidateSchema(wrapped), []string{`0:0 contact: AtLeastNOf: key "mail" is an alias of "email", name the allowed key`})
+
+		checkFindings(t, ValidateSchema(schema), nil)
+	})
+}
+
+func TestAtMostNOf(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"token":       {Type: TypeString},
+			"password":    {Type: TypeString},
+			"certificate": {Type: TypeString},
+			"user":        {Type: TypeString},
+		},
+		AtMostNOf: []KeyCount{{Keys: []string{"token", "password", "certificate"}, N: 2}},
+	}
+
+	tests := []struct {
+		name        string
+		yaml        string
+		wantMessage string
+		wantLine    int
+	}{
+		{name: "none", yaml: "user: a\n"},
+		{name: "at cap", yaml: "token: t\npassword: p\n"},
+		{
+			name:        "above cap",
+			yaml:        "user: a\ncertificate: c\ntoken: t\npassword: p\n",
+			wantMessage: "at most 2 of [token password certificate] may be set, found: [certificate token password]",
+			wantLine:    4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors()
+			if tt.wantMessage == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Message != tt.wantMessage || errs[0].Line != tt.wantLine {
+				t.Fatalf("got %v, want %q at line %d", errs, tt.wantMessage, tt.wantLine)
+			}
+		})
+	}
+
+	t.Run("negative count is skipped", func(t *testing.T) {
+		bad := &FieldSchema{
+			Type:        TypeMap,
+			AllowedKeys: schema.AllowedKeys,
+			AtMostNOf:   []KeyCount{{Keys: []string{"token"}, N: -1}},
+		}
+		if errs := NewValidator(bad).ValidateBytes([]byte("token: t\n")).Collector.Errors(); len(errs) != 0 {
+			t.Fatalf("expected no errors, got %v", errs)
+		}
+	})
+}
+
+func TestConditionPattern(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"image":           {Type: TypeString},
+			"imagePullPolicy": {Type: TypeString},
+			"digest":          {Type: TypeString},
+		},
+		Conditions: []ConditionalRule{
+			{
+				ConditionField:   "image",
+				ConditionValue:   "ignored when a pattern is set",
+				ConditionPattern: regexp.MustCompile(`:latest$`),
+				ThenRequired:     []string{"imagePullPolicy"},
+				ThenForbidden:    []string{"digest"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		wantMsgs []string
+	}{
+		{name: "pattern does not match", yaml: "image: nginx:1.25\n"},
+		{name: "pattern matches, requirement met", yaml: "image: nginx:latest\nimagePullPolicy: Always\n"},
+		{name: "pattern matches, required missing", yaml: "image: nginx:latest\n", wantMsgs: []string{`field "imagePullPolicy" is required when image matches ":latest$"`}},
+		{name: "pattern matches, forbidden present", yaml: "image: nginx:latest\nimagePullPolicy: Always\ndigest: sha256:x\n", wantMsgs: []string{`field "digest" is forbidden when image matches ":latest$"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.Errors() {
+				got = append(got, e.Message)
+			}
+			if !reflect.DeepEqual(got, tt.wantMsgs) {
+				t.Errorf("got %q, want %q", got, tt.wantMsgs)
+			}
+		})
+	}
+}
+
+func TestConditionThenSchema(t *testing.T) {
+	schema := &FieldSchema{
+		Type: TypeMap,
+		AllowedKeys: map[string]*FieldSchema{
+			"type":   {Type: TypeString, Required: true},
+			"url":    {Type: TypeString},
+			"method": {Type: TypeString},
+			"path":   {Type: TypeString},
+		},
+		UnknownKeyPolicy: UnknownKeyError,
+		Conditions: []ConditionalRule{
+			{
+				ConditionField: "type",
+				ConditionValue: "http",
+				ThenSchema: &FieldSchema{
+					Type: TypeMap,
+					AllowedKeys: map[string]*FieldSchema{
+						"url":    {Type: TypeString, Required: true},
+						"method": {Type: TypeString, Required: true, Validators: []ValueValidator{valv.EnumValidator{Allowed: []string{"GET", "POST"}}}},
+					},
+					UnknownKeyPolicy: UnknownKeyIgnore,
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		wantMsgs []string
+	}{
+		{name: "condition not met", yaml: "type: file\npath: /tmp/x\n"},
+		{name: "then-schema satisfied", yaml: "type: http\nurl: http://x\nmethod: GET\n"},
+		{name: "then-schema adds required keys", yaml: "type: http\n", wantMsgs: []string{
+			`method: required field "method" is missing`,
+			`url: required field "url" is missing`,
+		}},
+		{name: "then-schema validators run", yaml: "type: http\nurl: http://x\nmethod: PUT\n", wantMsgs: []string{
+			`method: invalid value "PUT"`,
+		}},
+		{name: "outer findings are not repeated", yaml: "type: http\nurl: http://x\nmethod: GET\nextra: 1\n", wantMsgs: []string{
+			`extra: unknown key "extra"`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range NewValidator(schema).ValidateBytes([]byte(tt.yaml)).Collector.AllSorted() {
+				got = append(got, e.Path+": "+e.Message)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.wantMsgs) {
+				t.Errorf("got %q, want %q", got, tt.wantMsgs)
+			}
+		})
+	}
+
+	t.Run("duplicate keys reported once", func(t *testing.T) {
+		res := NewValidator(schema).ValidateBytes([]byte("type: http\nurl: a\nurl: b\nmethod: GET\n"))
+		if warnings := res.Collector.Warnings(); len(warnings) != 1 {
+			t.Fatalf("expected one duplicate key warning, got %v", warnings)
+		}
+	})
+
+	t.Run("cycles end", func(t *testing.T) {
+		cyclic := &FieldSchema{
+			Type:        TypeMap,
+			AllowedKeys: map[string]*FieldSchema{"type": {Type: TypeString}, "url": {Type: TypeString}},
+		}
+		other := &FieldSchema{Type: TypeMap, UnknownKeyPolicy: UnknownKeyIgnore}
+		cyclic.Conditions = []ConditionalRule{{ConditionField: "type", ConditionValue: "http", ThenRequired: []string{"url"}, ThenSchema: cyclic}}
+		other.Conditions = []ConditionalRule{{ConditionField: "type", ConditionValue: "http", ThenSchema: cyclic}}
+		cyclic.Conditions = append(cyclic.Conditions, ConditionalRule{ConditionField: "type", ConditionValue: "http", ThenSchema: other})
+		checkFindings(t, NewValidator(cyclic).ValidateString("type: http\n").Collector.Errors(), []string{
+			`1:7 url: field "url" is required when type="http"`,
+		})
+	})
+}